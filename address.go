@@ -1,7 +1,12 @@
 package iota
 
 import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+
+	"golang.org/x/crypto/blake2b"
 )
 
 // Defines the type of addresses.
@@ -65,9 +70,36 @@ func (wotsAddr *WOTSAddress) Serialize(deSeriMode DeSerializationMode) (data []b
 	return b[:], nil
 }
 
+// jsonAddress defines the JSON form of an address.
+type jsonAddress struct {
+	Type    int    `json:"type"`
+	Address string `json:"address"`
+}
+
+func (wotsAddr *WOTSAddress) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonAddress{Type: int(AddressWOTS), Address: hex.EncodeToString(wotsAddr[:])})
+}
+
+func (wotsAddr *WOTSAddress) UnmarshalJSON(data []byte) error {
+	jAddr := &jsonAddress{}
+	if err := json.Unmarshal(data, jAddr); err != nil {
+		return err
+	}
+	if err := checkJSONType(jAddr.Type, uint32(AddressWOTS)); err != nil {
+		return fmt.Errorf("unable to decode WOTS address from JSON: %w", err)
+	}
+	return decodeHexJSON("address", jAddr.Address, wotsAddr[:])
+}
+
 // Defines an Ed25519 address.
 type Ed25519Address [Ed25519AddressBytesLength]byte
 
+// AddressFromEd25519PubKey returns the address belonging to the given Ed25519 public key,
+// which is the BLAKE2b-256 hash of the public key.
+func AddressFromEd25519PubKey(pubKey ed25519.PublicKey) Ed25519Address {
+	return blake2b.Sum256(pubKey[:])
+}
+
 func (edAddr *Ed25519Address) Deserialize(data []byte, deSeriMode DeSerializationMode) (int, error) {
 	if deSeriMode.HasMode(DeSeriModePerformValidation) {
 		if err := checkMinByteLength(Ed25519AddressSerializedBytesSize, len(data)); err != nil {
@@ -87,3 +119,18 @@ func (edAddr *Ed25519Address) Serialize(deSeriMode DeSerializationMode) (data []
 	copy(b[SmallTypeDenotationByteSize:], edAddr[:])
 	return b[:], nil
 }
+
+func (edAddr *Ed25519Address) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonAddress{Type: int(AddressEd25519), Address: hex.EncodeToString(edAddr[:])})
+}
+
+func (edAddr *Ed25519Address) UnmarshalJSON(data []byte) error {
+	jAddr := &jsonAddress{}
+	if err := json.Unmarshal(data, jAddr); err != nil {
+		return err
+	}
+	if err := checkJSONType(jAddr.Type, uint32(AddressEd25519)); err != nil {
+		return fmt.Errorf("unable to decode Ed25519 address from JSON: %w", err)
+	}
+	return decodeHexJSON("address", jAddr.Address, edAddr[:])
+}
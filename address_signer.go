@@ -0,0 +1,86 @@
+package iota
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrAddressSignerUnknownAddress = errors.New("address signer doesn't hold the key of the address")
+	ErrAddressSignerRemote         = errors.New("remote address signer failed")
+)
+
+// AddressSigner signs messages with the keys owning addresses, without handing out the keys themselves.
+// It allows signing with keys held by external custody, for example a separate signing service.
+type AddressSigner interface {
+	// Sign signs the given message with the key owning the given address.
+	// It returns an error wrapping ErrAddressSignerUnknownAddress if it doesn't hold the key of the address.
+	Sign(addr Serializable, msg []byte) (*Ed25519Signature, error)
+}
+
+// InMemoryKeyring is an AddressSigner holding Ed25519 private keys in memory.
+type InMemoryKeyring struct {
+	mu   sync.RWMutex
+	keys map[Ed25519Address]ed25519.PrivateKey
+}
+
+// NewInMemoryKeyring creates a new InMemoryKeyring holding the given private keys.
+func NewInMemoryKeyring(prvKeys ...ed25519.PrivateKey) (*InMemoryKeyring, error) {
+	keyring := &InMemoryKeyring{keys: make(map[Ed25519Address]ed25519.PrivateKey)}
+	if err := keyring.Add(prvKeys...); err != nil {
+		return nil, err
+	}
+	return keyring, nil
+}
+
+// Add adds the given private keys to the keyring.
+func (k *InMemoryKeyring) Add(prvKeys ...ed25519.PrivateKey) error {
+	for i, prvKey := range prvKeys {
+		if len(prvKey) != ed25519.PrivateKeySize {
+			return fmt.Errorf("%w: key %d", ErrTransactionBuilderInvalidPrivateKey, i)
+		}
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, prvKey := range prvKeys {
+		k.keys[AddressFromEd25519PubKey(prvKey.Public().(ed25519.PublicKey))] = prvKey
+	}
+	return nil
+}
+
+// Addresses returns the addresses of the keys the keyring holds in their lexical order.
+func (k *InMemoryKeyring) Addresses() []Ed25519Address {
+	k.mu.RLock()
+	addrs := make([]Ed25519Address, 0, len(k.keys))
+	for addr := range k.keys {
+		addrs = append(addrs, addr)
+	}
+	k.mu.RUnlock()
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	return addrs
+}
+
+// Sign signs the given message with the key owning the given Ed25519Address.
+func (k *InMemoryKeyring) Sign(addr Serializable, msg []byte) (*Ed25519Signature, error) {
+	edAddr, ok := addr.(*Ed25519Address)
+	if !ok {
+		return nil, fmt.Errorf("%w: address is %T", ErrAddressSignerUnknownAddress, addr)
+	}
+	k.mu.RLock()
+	prvKey, has := k.keys[*edAddr]
+	k.mu.RUnlock()
+	if !has {
+		return nil, fmt.Errorf("%w: %x", ErrAddressSignerUnknownAddress, edAddr[:])
+	}
+
+	sig := &Ed25519Signature{}
+	copy(sig.PublicKey[:], prvKey.Public().(ed25519.PublicKey))
+	copy(sig.Signature[:], ed25519.Sign(prvKey, msg))
+	return sig, nil
+}
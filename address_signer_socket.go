@@ -0,0 +1,327 @@
+package iota
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// The default time a UnixSocketSigner waits for a signature.
+	DefaultUnixSocketSignerTimeout = 10 * time.Second
+
+	// The maximum size of a request to an AddressSignerServer, which fits the hex encoding of a full message.
+	addressSignerMaxRequestSize = 1 << 17
+	// The maximum size of a response of an AddressSignerServer.
+	addressSignerMaxResponseSize = 4096
+
+	// The error code of responses to requests for addresses the server holds no key of.
+	addressSignerCodeUnknownAddress = "unknown_address"
+	// The error code of responses to malformed requests.
+	addressSignerCodeInvalidRequest = "invalid_request"
+	// The error code of responses to requests the signer failed to sign.
+	addressSignerCodeSignerFailed = "signer_failed"
+)
+
+var (
+	ErrAddressSignerServerClosed = errors.New("address signer server closed")
+)
+
+// addressSignerRequest is a request to sign a message, sent as a single line of JSON.
+type addressSignerRequest struct {
+	// The hex encoded serialized address.
+	Address string `json:"address"`
+	// The hex encoded message.
+	Message string `json:"message"`
+}
+
+// addressSignerResponse is the response to an addressSignerRequest, sent as a single line of JSON.
+type addressSignerResponse struct {
+	// The hex encoded serialized Ed25519Signature.
+	Signature string `json:"signature,omitempty"`
+	// The code of the error if the request failed.
+	Code string `json:"code,omitempty"`
+	// The message of the error if the request failed.
+	Error string `json:"error,omitempty"`
+}
+
+// AddressSignerServer serves an AddressSigner to other processes over Unix sockets. It stands in for a separate
+// signing service: clients talk to it through a UnixSocketSigner and never see the keys.
+type AddressSignerServer struct {
+	signer AddressSigner
+
+	mu        sync.Mutex
+	closed    bool
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	wg        sync.WaitGroup
+}
+
+// NewAddressSignerServer creates a new AddressSignerServer signing with the given signer.
+func NewAddressSignerServer(signer AddressSigner) *AddressSignerServer {
+	return &AddressSignerServer{
+		signer:    signer,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+}
+
+// ListenAndServe listens on the Unix socket at the given path and serves connections to it until the server is closed.
+// A stale socket left at the path is replaced, any other file at the path is left alone and an error wrapping
+// os.ErrExist is returned. The socket is only accessible by the current user.
+func (s *AddressSignerServer) ListenAndServe(path string) error {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return fmt.Errorf("%w: %s is not a socket", os.ErrExist, path)
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+
+	// the socket is created within a directory only the current user can access and linked to the path
+	// once its permissions are restricted, so that nobody else can connect in between.
+	// Unlike renaming it, linking fails instead of replacing a file created at the path meanwhile.
+	dir, err := ioutil.TempDir(filepath.Dir(path), ".signer")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	tmpPath := filepath.Join(dir, "signer.sock")
+	listener, err := net.Listen("unix", tmpPath)
+	if err != nil {
+		return err
+	}
+	// the listener can't unlink the socket at the path it is linked to
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(tmpPath, 0600); err != nil {
+		listener.Close()
+		return err
+	}
+	if err := os.Link(tmpPath, path); err != nil {
+		listener.Close()
+		return err
+	}
+	defer os.Remove(path)
+	_ = os.RemoveAll(dir)
+	return s.Serve(listener)
+}
+
+// Serve serves the connections accepted by the given listener until the server is closed,
+// after which it returns ErrAddressSignerServerClosed.
+func (s *AddressSignerServer) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		listener.Close()
+		return ErrAddressSignerServerClosed
+	}
+	s.listeners[listener] = struct{}{}
+	s.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			delete(s.listeners, listener)
+			s.mu.Unlock()
+			if closed {
+				return ErrAddressSignerServerClosed
+			}
+			listener.Close()
+			return err
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			continue
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go func() {
+			defer s.wg.Done()
+			s.serveConn(conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// Close stops the listeners, closes all connections and waits for their handlers to return.
+func (s *AddressSignerServer) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for listener := range s.listeners {
+		listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return nil
+}
+
+// serveConn answers the requests of the given connection until it is closed.
+func (s *AddressSignerServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), addressSignerMaxRequestSize)
+	encoder := json.NewEncoder(conn)
+	for scanner.Scan() {
+		if err := encoder.Encode(s.handle(scanner.Bytes())); err != nil {
+			return
+		}
+	}
+}
+
+func (s *AddressSignerServer) handle(line []byte) *addressSignerResponse {
+	invalid := func(err error) *addressSignerResponse {
+		return &addressSignerResponse{Code: addressSignerCodeInvalidRequest, Error: err.Error()}
+	}
+
+	req := &addressSignerRequest{}
+	if err := json.Unmarshal(line, req); err != nil {
+		return invalid(err)
+	}
+	addrData, err := hex.DecodeString(req.Address)
+	if err != nil {
+		return invalid(fmt.Errorf("address: %w", err))
+	}
+	addr, _, err := DeserializeObject(addrData, DeSeriModePerformValidation, TypeDenotationByte, AddressSelector)
+	if err != nil {
+		return invalid(fmt.Errorf("address: %w", err))
+	}
+	msg, err := hex.DecodeString(req.Message)
+	if err != nil {
+		return invalid(fmt.Errorf("message: %w", err))
+	}
+
+	sig, err := s.signer.Sign(addr, msg)
+	switch {
+	case errors.Is(err, ErrAddressSignerUnknownAddress):
+		return &addressSignerResponse{Code: addressSignerCodeUnknownAddress, Error: err.Error()}
+	case err != nil:
+		return &addressSignerResponse{Code: addressSignerCodeSignerFailed, Error: err.Error()}
+	}
+	sigData, err := sig.Serialize(DeSeriModePerformValidation)
+	if err != nil {
+		return &addressSignerResponse{Code: addressSignerCodeSignerFailed, Error: err.Error()}
+	}
+	return &addressSignerResponse{Signature: hex.EncodeToString(sigData)}
+}
+
+// UnixSocketSigner is an AddressSigner which lets an AddressSignerServer sign over a Unix socket.
+// It keeps its connection open across calls and reconnects once it broke. It is safe for concurrent use.
+type UnixSocketSigner struct {
+	path    string
+	timeout time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewUnixSocketSigner creates a new UnixSocketSigner talking to the server listening on the Unix socket at the given path.
+// If timeout is zero, DefaultUnixSocketSignerTimeout is used.
+func NewUnixSocketSigner(path string, timeout time.Duration) *UnixSocketSigner {
+	if timeout == 0 {
+		timeout = DefaultUnixSocketSignerTimeout
+	}
+	return &UnixSocketSigner{path: path, timeout: timeout}
+}
+
+// Sign lets the server sign the given message with the key owning the given address.
+func (u *UnixSocketSigner) Sign(addr Serializable, msg []byte) (*Ed25519Signature, error) {
+	addrData, err := addr.Serialize(DeSeriModePerformValidation)
+	if err != nil {
+		return nil, err
+	}
+	reqData, err := json.Marshal(&addressSignerRequest{Address: hex.EncodeToString(addrData), Message: hex.EncodeToString(msg)})
+	if err != nil {
+		return nil, err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	line, err := u.roundTrip(append(reqData, '\n'))
+	if err != nil {
+		// the connection is in an unknown state, so the next call uses a new one
+		u.closeConn()
+		return nil, fmt.Errorf("%w: %v", ErrAddressSignerRemote, err)
+	}
+
+	res := &addressSignerResponse{}
+	if err := json.Unmarshal(line, res); err != nil {
+		return nil, fmt.Errorf("%w: invalid response: %v", ErrAddressSignerRemote, err)
+	}
+	switch res.Code {
+	case "":
+	case addressSignerCodeUnknownAddress:
+		return nil, fmt.Errorf("%w: %s", ErrAddressSignerUnknownAddress, res.Error)
+	default:
+		return nil, fmt.Errorf("%w: %s: %s", ErrAddressSignerRemote, res.Code, res.Error)
+	}
+
+	sigData, err := hex.DecodeString(res.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid signature: %v", ErrAddressSignerRemote, err)
+	}
+	sig, _, err := DeserializeObject(sigData, DeSeriModePerformValidation, TypeDenotationByte, SignatureSelector)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid signature: %v", ErrAddressSignerRemote, err)
+	}
+	edSig, ok := sig.(*Ed25519Signature)
+	if !ok {
+		return nil, fmt.Errorf("%w: signature is %T", ErrAddressSignerRemote, sig)
+	}
+	return edSig, nil
+}
+
+// roundTrip sends the given request line and reads the response line, connecting first if needed.
+func (u *UnixSocketSigner) roundTrip(req []byte) ([]byte, error) {
+	if u.conn == nil {
+		conn, err := net.DialTimeout("unix", u.path, u.timeout)
+		if err != nil {
+			return nil, err
+		}
+		u.conn = conn
+		u.reader = bufio.NewReaderSize(conn, addressSignerMaxResponseSize)
+	}
+	if err := u.conn.SetDeadline(time.Now().Add(u.timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := u.conn.Write(req); err != nil {
+		return nil, err
+	}
+	return u.reader.ReadSlice('\n')
+}
+
+func (u *UnixSocketSigner) closeConn() {
+	if u.conn != nil {
+		u.conn.Close()
+		u.conn, u.reader = nil, nil
+	}
+}
+
+// Close closes the connection to the server.
+func (u *UnixSocketSigner) Close() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.closeConn()
+	return nil
+}
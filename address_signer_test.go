@@ -0,0 +1,200 @@
+package iota_test
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/luca-moser/iota"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// forgingSigner returns signatures which don't verify.
+type forgingSigner struct {
+	iota.AddressSigner
+}
+
+func (f forgingSigner) Sign(addr iota.Serializable, msg []byte) (*iota.Ed25519Signature, error) {
+	sig, err := f.AddressSigner.Sign(addr, msg)
+	if err != nil {
+		return nil, err
+	}
+	sig.Signature[0] ^= 0xff
+	return sig, nil
+}
+
+// serveAddressSigner serves the given signer on a Unix socket and returns a UnixSocketSigner talking to it.
+func serveAddressSigner(t *testing.T, signer iota.AddressSigner) (*iota.AddressSignerServer, *iota.UnixSocketSigner) {
+	path := filepath.Join(t.TempDir(), "signer.sock")
+	server := iota.NewAddressSignerServer(signer)
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.ListenAndServe(path) }()
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("unix", path)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}, time.Second, 10*time.Millisecond)
+
+	// the socket is only accessible by the current user and nothing is left of its creation
+	info, err := os.Lstat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	entries, err := ioutil.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	client := iota.NewUnixSocketSigner(path, 0)
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+		assert.True(t, errors.Is(<-serveErr, iota.ErrAddressSignerServerClosed))
+	})
+	return server, client
+}
+
+func TestInMemoryKeyring(t *testing.T) {
+	prvKey := randEd25519PrivateKey()
+	keyring, err := iota.NewInMemoryKeyring(prvKey)
+	require.NoError(t, err)
+	addr := iota.AddressFromEd25519PubKey(prvKey.Public().(ed25519.PublicKey))
+
+	sig, err := keyring.Sign(&addr, []byte("message"))
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(sig.PublicKey[:], []byte("message"), sig.Signature[:]))
+
+	otherAddr, _ := randEd25519Addr()
+	_, err = keyring.Sign(otherAddr, []byte("message"))
+	assert.True(t, errors.Is(err, iota.ErrAddressSignerUnknownAddress))
+
+	otherKey := randEd25519PrivateKey()
+	require.NoError(t, keyring.Add(otherKey))
+	assert.Len(t, keyring.Addresses(), 2)
+	assert.Contains(t, keyring.Addresses(), addr)
+
+	assert.True(t, errors.Is(keyring.Add(ed25519.PrivateKey{1, 2, 3}), iota.ErrTransactionBuilderInvalidPrivateKey))
+}
+
+func TestUnixSocketSigner(t *testing.T) {
+	prvKey := randEd25519PrivateKey()
+	keyring, err := iota.NewInMemoryKeyring(prvKey)
+	require.NoError(t, err)
+	server, client := serveAddressSigner(t, keyring)
+	addr := iota.AddressFromEd25519PubKey(prvKey.Public().(ed25519.PublicKey))
+
+	for _, msg := range []string{"first", "second"} {
+		sig, err := client.Sign(&addr, []byte(msg))
+		require.NoError(t, err)
+		expected, err := keyring.Sign(&addr, []byte(msg))
+		require.NoError(t, err)
+		assert.Equal(t, expected, sig)
+	}
+
+	otherAddr, _ := randEd25519Addr()
+	_, err = client.Sign(otherAddr, []byte("message"))
+	assert.True(t, errors.Is(err, iota.ErrAddressSignerUnknownAddress))
+
+	// the client reports a closed server as remote failure
+	require.NoError(t, server.Close())
+	_, err = client.Sign(&addr, []byte("message"))
+	assert.True(t, errors.Is(err, iota.ErrAddressSignerRemote))
+}
+
+func TestAddressSignerServer_ListenAndServeExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signer.sock")
+	require.NoError(t, ioutil.WriteFile(path, []byte("data"), 0600))
+
+	keyring, err := iota.NewInMemoryKeyring()
+	require.NoError(t, err)
+	err = iota.NewAddressSignerServer(keyring).ListenAndServe(path)
+	assert.True(t, errors.Is(err, os.ErrExist))
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), data)
+}
+
+func TestTransactionBuilder_BuildWithSigner(t *testing.T) {
+	prvKey1, prvKey2 := randEd25519PrivateKey(), randEd25519PrivateKey()
+	addr1 := iota.AddressFromEd25519PubKey(prvKey1.Public().(ed25519.PublicKey))
+	addr2 := iota.AddressFromEd25519PubKey(prvKey2.Public().(ed25519.PublicKey))
+	keyring, err := iota.NewInMemoryKeyring(prvKey1, prvKey2)
+	require.NoError(t, err)
+	_, client := serveAddressSigner(t, keyring)
+
+	input1, _ := randUTXOInput()
+	input2, _ := randUTXOInput()
+	input3, _ := randUTXOInput()
+	outputAddr, _ := randEd25519Addr()
+	builder := iota.NewTransactionBuilder().
+		AddInput(&iota.ToBeSignedUTXOInput{Input: input1, Amount: 10, Address: &addr1}).
+		AddInput(&iota.ToBeSignedUTXOInput{Input: input2, Amount: 20, Address: &addr2}).
+		AddInput(&iota.ToBeSignedUTXOInput{Input: input3, Amount: 30, Address: &addr1}).
+		AddOutput(&iota.SigLockedSingleDeposit{Address: outputAddr, Amount: 60})
+
+	payload, err := builder.BuildWithSigner(client)
+	require.NoError(t, err)
+
+	utxos := map[[iota.TransactionIDLength]byte]*iota.UnspentOutput{
+		input1.TransactionID: {Input: input1, Address: &addr1, Amount: 10},
+		input2.TransactionID: {Input: input2, Address: &addr2, Amount: 20},
+		input3.TransactionID: {Input: input3, Address: &addr1, Amount: 30},
+	}
+	reason, err := payload.SemanticallyValid(func(input *iota.UTXOInput) (*iota.UnspentOutput, bool, error) {
+		return utxos[input.TransactionID], false, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, iota.ConflictNone, reason)
+
+	var sigBlocks int
+	for _, block := range payload.UnlockBlocks {
+		if _, ok := block.(*iota.SignatureUnlockBlock); ok {
+			sigBlocks++
+		}
+	}
+	assert.Equal(t, 2, sigBlocks)
+
+	_, err = builder.BuildWithSigner(forgingSigner{keyring})
+	assert.True(t, errors.Is(err, iota.ErrTransactionBuilderInvalidSignature))
+
+	otherKeyring, err := iota.NewInMemoryKeyring(prvKey1)
+	require.NoError(t, err)
+	_, err = builder.BuildWithSigner(otherKeyring)
+	assert.True(t, errors.Is(err, iota.ErrAddressSignerUnknownAddress))
+
+	_, err = iota.NewTransactionBuilder().
+		AddInput(&iota.ToBeSignedUTXOInput{Input: input1, Amount: 10, PrivateKey: prvKey1}).
+		AddOutput(&iota.SigLockedSingleDeposit{Address: outputAddr, Amount: 10}).
+		BuildWithSigner(keyring)
+	assert.True(t, errors.Is(err, iota.ErrTransactionBuilderNoInputAddress))
+}
+
+func TestPartiallySignedTransaction_SignWith(t *testing.T) {
+	prvKey1, prvKey2 := randEd25519PrivateKey(), randEd25519PrivateKey()
+	pst := testPST(t, prvKey1, prvKey2)
+
+	keyring, err := iota.NewInMemoryKeyring(prvKey2)
+	require.NoError(t, err)
+	_, client := serveAddressSigner(t, keyring)
+
+	signed, err := pst.SignWith(client)
+	require.NoError(t, err)
+	assert.Equal(t, 1, signed)
+	assert.Equal(t, []int{0, 2}, pst.MissingSignatures())
+
+	_, err = pst.SignWith(forgingSigner{keyring})
+	assert.True(t, errors.Is(err, iota.ErrPSTInvalidSignature))
+
+	require.NoError(t, keyring.Add(prvKey1))
+	signed, err = pst.SignWith(client)
+	require.NoError(t, err)
+	assert.Equal(t, 3, signed)
+	assert.True(t, pst.Complete())
+}
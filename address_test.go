@@ -1,11 +1,13 @@
 package iota_test
 
 import (
+	"crypto/ed25519"
 	"errors"
 	"testing"
 
 	"github.com/luca-moser/iota"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/blake2b"
 )
 
 func TestWOTSAddress_Deserialize(t *testing.T) {
@@ -125,3 +127,10 @@ func TestEd25519Address_Serialize(t *testing.T) {
 		})
 	}
 }
+
+func TestAddressFromEd25519PubKey(t *testing.T) {
+	prvKey := randEd25519PrivateKey()
+	pubKey := prvKey.Public().(ed25519.PublicKey)
+	addr := iota.AddressFromEd25519PubKey(pubKey)
+	assert.Equal(t, iota.Ed25519Address(blake2b.Sum256(pubKey)), addr)
+}
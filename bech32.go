@@ -0,0 +1,199 @@
+package iota
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// NetworkPrefix denotes the human-readable part of Bech32 encoded addresses, which names the network
+// the address belongs to.
+type NetworkPrefix string
+
+const (
+	// The human-readable part of addresses of the main network.
+	PrefixMainnet NetworkPrefix = "iota"
+	// The human-readable part of addresses of the test network.
+	PrefixTestnet NetworkPrefix = "atoi"
+
+	// The maximum length of a Bech32 string.
+	Bech32MaxLength = 90
+	// The length of the checksum of a Bech32 string.
+	bech32ChecksumLength = 6
+	// The separator between the human-readable part and the data part of a Bech32 string.
+	bech32Separator = '1'
+	// The characters of the data part of a Bech32 string, indexed by the 5 bit value they encode.
+	bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+var (
+	ErrBech32Invalid         = errors.New("invalid Bech32 string")
+	ErrBech32InvalidChecksum = errors.New("invalid Bech32 checksum")
+)
+
+var bech32Generator = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+func bech32Polymod(values []byte) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= bech32Generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	return expanded
+}
+
+// convertBits regroups the given data from groups of fromBits to groups of toBits.
+// If pad is false, the input must not contain superfluous or non-zero padding bits.
+func convertBits(data []byte, fromBits uint, toBits uint, pad bool) ([]byte, error) {
+	var acc uint32
+	var bits uint
+	maxV := uint32(1)<<toBits - 1
+	out := make([]byte, 0, len(data)*int(fromBits)/int(toBits)+1)
+	for _, b := range data {
+		if uint32(b)>>fromBits != 0 {
+			return nil, fmt.Errorf("%w: value %d exceeds %d bits", ErrBech32Invalid, b, fromBits)
+		}
+		acc = acc<<fromBits | uint32(b)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			out = append(out, byte(acc>>bits&maxV))
+		}
+	}
+	switch {
+	case pad && bits > 0:
+		out = append(out, byte(acc<<(toBits-bits)&maxV))
+	case !pad && (bits >= fromBits || acc<<(toBits-bits)&maxV != 0):
+		return nil, fmt.Errorf("%w: invalid padding", ErrBech32Invalid)
+	}
+	return out, nil
+}
+
+// Bech32Encode encodes the given bytes with the given human-readable part as a Bech32 string as defined in BIP-173.
+func Bech32Encode(hrp string, data []byte) (string, error) {
+	if len(hrp) == 0 {
+		return "", fmt.Errorf("%w: empty human-readable part", ErrBech32Invalid)
+	}
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 || (hrp[i] >= 'A' && hrp[i] <= 'Z') {
+			return "", fmt.Errorf("%w: invalid character in human-readable part", ErrBech32Invalid)
+		}
+	}
+	values, err := convertBits(data, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	if len(hrp)+1+len(values)+bech32ChecksumLength > Bech32MaxLength {
+		return "", fmt.Errorf("%w: exceeds %d characters", ErrBech32Invalid, Bech32MaxLength)
+	}
+
+	polymod := bech32Polymod(append(append(bech32HRPExpand(hrp), values...), make([]byte, bech32ChecksumLength)...)) ^ 1
+	var b strings.Builder
+	b.Grow(len(hrp) + 1 + len(values) + bech32ChecksumLength)
+	b.WriteString(hrp)
+	b.WriteByte(bech32Separator)
+	for _, v := range values {
+		b.WriteByte(bech32Charset[v])
+	}
+	for i := 0; i < bech32ChecksumLength; i++ {
+		b.WriteByte(bech32Charset[polymod>>uint(5*(5-i))&31])
+	}
+	return b.String(), nil
+}
+
+// Bech32Decode decodes the given Bech32 string into its human-readable part and the bytes it encodes.
+func Bech32Decode(s string) (string, []byte, error) {
+	if len(s) > Bech32MaxLength {
+		return "", nil, fmt.Errorf("%w: exceeds %d characters", ErrBech32Invalid, Bech32MaxLength)
+	}
+	lower, upper := strings.ToLower(s), strings.ToUpper(s)
+	if s != lower && s != upper {
+		return "", nil, fmt.Errorf("%w: mixed case", ErrBech32Invalid)
+	}
+	s = lower
+
+	sepIdx := strings.LastIndexByte(s, bech32Separator)
+	if sepIdx < 1 || sepIdx+1+bech32ChecksumLength > len(s) {
+		return "", nil, fmt.Errorf("%w: invalid separator position", ErrBech32Invalid)
+	}
+	hrp := s[:sepIdx]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, fmt.Errorf("%w: invalid character in human-readable part", ErrBech32Invalid)
+		}
+	}
+	values := make([]byte, 0, len(s)-sepIdx-1)
+	for i := sepIdx + 1; i < len(s); i++ {
+		v := strings.IndexByte(bech32Charset, s[i])
+		if v == -1 {
+			return "", nil, fmt.Errorf("%w: invalid character '%c' in data part", ErrBech32Invalid, s[i])
+		}
+		values = append(values, byte(v))
+	}
+	if bech32Polymod(append(bech32HRPExpand(hrp), values...)) != 1 {
+		return "", nil, ErrBech32InvalidChecksum
+	}
+
+	data, err := convertBits(values[:len(values)-bech32ChecksumLength], 5, 8, false)
+	if err != nil {
+		return "", nil, err
+	}
+	return hrp, data, nil
+}
+
+// AddressToBech32 encodes the given address with the given network prefix as a Bech32 string.
+// The encoded data is the serialized form of the address, consisting of its type byte followed by the address bytes.
+func AddressToBech32(prefix NetworkPrefix, addr Serializable) (string, error) {
+	addrData, err := addr.Serialize(DeSeriModePerformValidation)
+	if err != nil {
+		return "", err
+	}
+	return Bech32Encode(string(prefix), addrData)
+}
+
+// ParseBech32Address parses the given Bech32 string into its network prefix and address.
+func ParseBech32Address(s string) (NetworkPrefix, Serializable, error) {
+	hrp, data, err := Bech32Decode(s)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(data) == 0 {
+		return "", nil, fmt.Errorf("%w: no address data", ErrBech32Invalid)
+	}
+	addr, err := AddressSelector(uint32(data[0]))
+	if err != nil {
+		return "", nil, err
+	}
+	bytesRead, err := addr.Deserialize(data, DeSeriModePerformValidation)
+	if err != nil {
+		return "", nil, err
+	}
+	if bytesRead != len(data) {
+		return "", nil, fmt.Errorf("%w: address is %d bytes but the data %d", ErrDeserializationNotAllConsumed, bytesRead, len(data))
+	}
+	return NetworkPrefix(hrp), addr, nil
+}
+
+// Bech32 returns the Bech32 encoded form of the address for the given network.
+// An empty string is returned if the prefix is not a valid human-readable part.
+func (edAddr *Ed25519Address) Bech32(prefix NetworkPrefix) string {
+	s, _ := AddressToBech32(prefix, edAddr)
+	return s
+}
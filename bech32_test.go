@@ -0,0 +1,92 @@
+package iota_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBech32Decode(t *testing.T) {
+	// test vectors from BIP-173
+	tests := []struct {
+		name string
+		s    string
+		hrp  string
+		data string
+		err  error
+	}{
+		{"uppercase", "A12UEL5L", "a", "", nil},
+		{"lowercase", "a12uel5l", "a", "", nil},
+		{"all characters", "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", "abcdef", "00443214c74254b635cf84653a56d7c675be77df", nil},
+		{"long", "split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w", "split", "c5f38b70305f519bf66d85fb6cf03058f3dde463ecd7918f2dc743918f2d", nil},
+		{"separator in hrp", "?1ezyfcl", "?", "", nil},
+		{"empty hrp", "1pzry9x0s0muk", "", "", iota.ErrBech32Invalid},
+		{"no separator", "pzry9x0s0muk", "", "", iota.ErrBech32Invalid},
+		{"invalid character", "x1b4n0q5v", "", "", iota.ErrBech32Invalid},
+		{"too short checksum", "li1dgmt3", "", "", iota.ErrBech32Invalid},
+		{"mixed case", "A12uEL5L", "", "", iota.ErrBech32Invalid},
+		{"invalid checksum", "a12uel5m", "", "", iota.ErrBech32InvalidChecksum},
+		{"too long", "an84characterslonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1569pvx", "", "", iota.ErrBech32Invalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hrp, data, err := iota.Bech32Decode(tt.s)
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err), err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.hrp, hrp)
+			assert.Equal(t, tt.data, hex.EncodeToString(data))
+
+			encoded, err := iota.Bech32Encode(hrp, data)
+			require.NoError(t, err)
+			assert.Equal(t, strings.ToLower(tt.s), encoded)
+		})
+	}
+}
+
+func TestBech32Encode_Invalid(t *testing.T) {
+	_, err := iota.Bech32Encode("", []byte{1})
+	assert.True(t, errors.Is(err, iota.ErrBech32Invalid))
+	_, err = iota.Bech32Encode("IOTA", []byte{1})
+	assert.True(t, errors.Is(err, iota.ErrBech32Invalid))
+	_, err = iota.Bech32Encode("iota", make([]byte, 60))
+	assert.True(t, errors.Is(err, iota.ErrBech32Invalid))
+}
+
+func TestParseBech32Address(t *testing.T) {
+	edAddr, _ := randEd25519Addr()
+
+	s := edAddr.Bech32(iota.PrefixMainnet)
+	assert.True(t, strings.HasPrefix(s, "iota1"), s)
+	prefix, addr, err := iota.ParseBech32Address(s)
+	require.NoError(t, err)
+	assert.Equal(t, iota.PrefixMainnet, prefix)
+	assert.Equal(t, edAddr, addr)
+
+	prefix, addr, err = iota.ParseBech32Address(strings.ToUpper(edAddr.Bech32(iota.PrefixTestnet)))
+	require.NoError(t, err)
+	assert.Equal(t, iota.PrefixTestnet, prefix)
+	assert.Equal(t, edAddr, addr)
+
+	unknownType, err := iota.Bech32Encode("iota", append([]byte{9}, edAddr[:]...))
+	require.NoError(t, err)
+	_, _, err = iota.ParseBech32Address(unknownType)
+	assert.True(t, errors.Is(err, iota.ErrUnknownAddrType))
+
+	truncated, err := iota.Bech32Encode("iota", []byte{iota.AddressEd25519, 1, 2})
+	require.NoError(t, err)
+	_, _, err = iota.ParseBech32Address(truncated)
+	assert.True(t, errors.Is(err, iota.ErrDeserializationNotEnoughData))
+
+	trailing, err := iota.Bech32Encode("iota", append(append([]byte{iota.AddressEd25519}, edAddr[:]...), 0))
+	require.NoError(t, err)
+	_, _, err = iota.ParseBech32Address(trailing)
+	assert.True(t, errors.Is(err, iota.ErrDeserializationNotAllConsumed))
+}
@@ -0,0 +1,153 @@
+package iota
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// The amount of words in a BIP-39 wordlist.
+	BIP39WordListSize = 2048
+	// The amount of bits a BIP-39 word encodes.
+	bip39BitsPerWord = 11
+
+	// The minimum amount of entropy bits of a mnemonic.
+	MnemonicMinEntropyBits = 128
+	// The maximum amount of entropy bits of a mnemonic.
+	MnemonicMaxEntropyBits = 256
+	// The default amount of entropy bits of generated mnemonics, which encode as 24 words.
+	MnemonicDefaultEntropyBits = 256
+
+	// The amount of PBKDF2 iterations deriving the seed of a mnemonic.
+	mnemonicSeedIterations = 2048
+	// The length of the seed derived from a mnemonic.
+	MnemonicSeedLength = 64
+)
+
+var (
+	ErrInvalidEntropyLength = errors.New("invalid entropy length")
+	ErrInvalidMnemonic      = errors.New("invalid mnemonic")
+	ErrMnemonicChecksum     = errors.New("invalid mnemonic checksum")
+)
+
+// bip39EnglishWordIndex maps the words of the English wordlist to their index.
+var bip39EnglishWordIndex = func() map[string]int {
+	index := make(map[string]int, BIP39WordListSize)
+	for i, word := range bip39EnglishWords {
+		index[word] = i
+	}
+	return index
+}()
+
+func checkEntropyBits(bits int) error {
+	if bits < MnemonicMinEntropyBits || bits > MnemonicMaxEntropyBits || bits%32 != 0 {
+		return fmt.Errorf("%w: got %d bits but must be between %d and %d bits and a multiple of 32 bits", ErrInvalidEntropyLength, bits, MnemonicMinEntropyBits, MnemonicMaxEntropyBits)
+	}
+	return nil
+}
+
+// NewMnemonic generates a mnemonic of the English wordlist encoding the given amount of random entropy bits.
+func NewMnemonic(entropyBits int) (string, error) {
+	if err := checkEntropyBits(entropyBits); err != nil {
+		return "", err
+	}
+	entropy := make([]byte, entropyBits/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", err
+	}
+	return MnemonicFromEntropy(entropy)
+}
+
+// MnemonicFromEntropy encodes the given entropy as a mnemonic of the English wordlist as defined in BIP-39:
+// the entropy is followed by the first len(entropy)/4 bits of its SHA-256 hash as checksum,
+// and every 11 bits of the result denote a word.
+func MnemonicFromEntropy(entropy []byte) (string, error) {
+	if err := checkEntropyBits(len(entropy) * 8); err != nil {
+		return "", err
+	}
+	checksum := sha256.Sum256(entropy)
+	data := append(append(make([]byte, 0, len(entropy)+1), entropy...), checksum[0])
+
+	wordCount := (len(entropy)*8 + len(entropy)/4) / bip39BitsPerWord
+	words := make([]string, wordCount)
+	for i := range words {
+		words[i] = bip39EnglishWords[readBits(data, i*bip39BitsPerWord, bip39BitsPerWord)]
+	}
+	return strings.Join(words, " "), nil
+}
+
+// readBits reads the given amount of bits starting at the given bit offset of data as big-endian integer.
+func readBits(data []byte, offset int, count int) int {
+	var value int
+	for i := offset; i < offset+count; i++ {
+		value = value<<1 | int(data[i/8]>>(7-uint(i%8))&1)
+	}
+	return value
+}
+
+// EntropyFromMnemonic decodes the entropy encoded by the given mnemonic of the English wordlist and verifies its checksum.
+// Words are separated by whitespace and matched case-insensitively.
+func EntropyFromMnemonic(mnemonic string) ([]byte, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	totalBits := len(words) * bip39BitsPerWord
+	// the checksum is one bit for every 32 bits of entropy
+	entropyBits := totalBits * 32 / 33
+	if len(words) == 0 || len(words)%3 != 0 || checkEntropyBits(entropyBits) != nil {
+		return nil, fmt.Errorf("%w: %d words, must be 12, 15, 18, 21 or 24", ErrInvalidMnemonic, len(words))
+	}
+
+	data := make([]byte, (totalBits+7)/8)
+	for i, word := range words {
+		value, has := bip39EnglishWordIndex[word]
+		if !has {
+			return nil, fmt.Errorf("%w: word %d '%s' is not in the wordlist", ErrInvalidMnemonic, i+1, word)
+		}
+		for bit := 0; bit < bip39BitsPerWord; bit++ {
+			if value&(1<<uint(bip39BitsPerWord-1-bit)) != 0 {
+				pos := i*bip39BitsPerWord + bit
+				data[pos/8] |= 1 << (7 - uint(pos%8))
+			}
+		}
+	}
+
+	entropy := data[:entropyBits/8]
+	checksumBits := totalBits - entropyBits
+	checksum := sha256.Sum256(entropy)
+	if readBits(data, entropyBits, checksumBits) != readBits(checksum[:], 0, checksumBits) {
+		return nil, ErrMnemonicChecksum
+	}
+	return entropy, nil
+}
+
+// ValidateMnemonic checks whether the given mnemonic consists of words of the English wordlist and has a valid checksum.
+func ValidateMnemonic(mnemonic string) error {
+	_, err := EntropyFromMnemonic(mnemonic)
+	return err
+}
+
+// MnemonicToSeed validates the given mnemonic and derives its seed with PBKDF2-HMAC-SHA512
+// salted by the optional passphrase, as defined in BIP-39. BIP-39 requires the passphrase to be NFKD normalized,
+// which callers must ensure for passphrases containing non-ASCII characters.
+func MnemonicToSeed(mnemonic string, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	normalized := strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+	return pbkdf2.Key([]byte(normalized), []byte("mnemonic"+passphrase), mnemonicSeedIterations, MnemonicSeedLength, sha512.New), nil
+}
+
+// Ed25519MasterKeyFromMnemonic derives the SLIP-10 Ed25519 master key of the seed of the given mnemonic and passphrase,
+// from which the keys of addresses are derived.
+func Ed25519MasterKeyFromMnemonic(mnemonic string, passphrase string) (*ExtendedEd25519Key, error) {
+	seed, err := MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewEd25519MasterKey(seed)
+}
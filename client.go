@@ -0,0 +1,413 @@
+package iota
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// The route for the node's info.
+	NodeAPIRouteInfo = "/api/v1/info"
+	// The route to submit messages to and to find messages by their indexation index.
+	NodeAPIRouteMessages = "/api/v1/messages"
+	// The route for outputs, followed by the hex encoded output ID.
+	NodeAPIRouteOutputs = "/api/v1/outputs"
+	// The route for addresses, followed by the hex encoded serialized address.
+	NodeAPIRouteAddresses = "/api/v1/addresses"
+	// The route streaming the node's events via Server-Sent Events or WebSocket.
+	NodeAPIRouteEvents = "/api/v1/events"
+
+	// The suffix of the message route returning the serialized message.
+	NodeAPIRouteMessageRawSuffix = "/raw"
+	// The suffix of the message route returning the message's metadata.
+	NodeAPIRouteMessageMetadataSuffix = "/metadata"
+	// The suffix of the address route returning the IDs of the address' outputs.
+	NodeAPIRouteAddressOutputsSuffix = "/outputs"
+
+	// The query parameter to find messages by their indexation index.
+	NodeAPIQueryParameterIndex = "index"
+
+	// The MIME type of JSON request and response bodies.
+	MIMEApplicationJSON = "application/json"
+	// The MIME type of binary request and response bodies.
+	MIMEApplicationOctetStream = "application/octet-stream"
+)
+
+var (
+	ErrHTTPBadRequest          = errors.New("bad request")
+	ErrHTTPNotFound            = errors.New("not found")
+	ErrHTTPInternalServerError = errors.New("internal server error")
+	ErrHTTPNotImplemented      = errors.New("operation not implemented")
+	ErrHTTPServiceUnavailable  = errors.New("service unavailable")
+	ErrHTTPUnknownError        = errors.New("unknown error")
+
+	httpStatusErrs = map[int]error{
+		http.StatusBadRequest:          ErrHTTPBadRequest,
+		http.StatusNotFound:            ErrHTTPNotFound,
+		http.StatusInternalServerError: ErrHTTPInternalServerError,
+		http.StatusNotImplemented:      ErrHTTPNotImplemented,
+		http.StatusServiceUnavailable:  ErrHTTPServiceUnavailable,
+	}
+)
+
+// HTTPOkResponseEnvelope wraps the data of a successful response.
+type HTTPOkResponseEnvelope struct {
+	// The response data.
+	Data interface{} `json:"data"`
+}
+
+// HTTPErrorResponseEnvelope wraps the error of a failed response.
+type HTTPErrorResponseEnvelope struct {
+	Error struct {
+		// A machine readable error code.
+		Code string `json:"code"`
+		// A human readable description of the error.
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// HTTPError is the error a node responded with.
+// It wraps the ErrHTTP error matching its status code, ErrHTTPUnknownError for unmapped status codes.
+type HTTPError struct {
+	// The HTTP status code of the response.
+	StatusCode int
+	// The error code from the response, if any.
+	Code string
+	// The error message from the response, if any.
+	Message string
+}
+
+func (e *HTTPError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("node responded with status %d: %s", e.StatusCode, e.Unwrap())
+	}
+	return fmt.Sprintf("node responded with status %d: %s: %s", e.StatusCode, e.Unwrap(), e.Message)
+}
+
+func (e *HTTPError) Unwrap() error {
+	if err, has := httpStatusErrs[e.StatusCode]; has {
+		return err
+	}
+	return ErrHTTPUnknownError
+}
+
+// NodeInfoResponse defines the response of the node info route.
+type NodeInfoResponse struct {
+	// The name of the node software.
+	Name string `json:"name"`
+	// The version of the node software.
+	Version string `json:"version"`
+	// Whether the node is healthy.
+	IsHealthy bool `json:"is_healthy"`
+	// The ID of the network the node participates in.
+	NetworkID uint64 `json:"network_id"`
+	// The index of the latest known milestone.
+	LatestMilestoneIndex uint64 `json:"latest_milestone_index"`
+	// The index of the latest solid milestone.
+	SolidMilestoneIndex uint64 `json:"solid_milestone_index"`
+	// The index of the milestone below which the node pruned its data.
+	PruningIndex uint64 `json:"pruning_index"`
+}
+
+// MessageIDResponse defines the response of submitting a message.
+type MessageIDResponse struct {
+	// The hex encoded ID of the message.
+	MessageID string `json:"message_id"`
+}
+
+// MessageMetadataResponse defines the response of the message metadata route.
+type MessageMetadataResponse struct {
+	// The hex encoded ID of the message.
+	MessageID string `json:"message_id"`
+	// The hex encoded ID of the first parent.
+	Parent1 string `json:"parent_1"`
+	// The hex encoded ID of the second parent.
+	Parent2 string `json:"parent_2"`
+	// Whether the message is solid.
+	Solid bool `json:"is_solid"`
+	// The index of the milestone which referenced the message, if any.
+	ReferencedByMilestoneIndex *uint64 `json:"referenced_by_milestone_index,omitempty"`
+	// The ledger inclusion state of the message's transaction, if the message is referenced.
+	LedgerInclusionState string `json:"ledger_inclusion_state,omitempty"`
+	// The reason why the message's transaction conflicts, if it does.
+	ConflictReason ConflictReason `json:"conflict_reason,omitempty"`
+}
+
+// MessageIDsByIndexResponse defines the response of finding messages by their indexation index.
+type MessageIDsByIndexResponse struct {
+	// The index the messages were searched by.
+	Index string `json:"index"`
+	// The maximum amount of results the node returns.
+	MaxResults int `json:"max_results"`
+	// The amount of results.
+	Count int `json:"count"`
+	// The hex encoded IDs of the found messages.
+	MessageIDs []string `json:"message_ids"`
+}
+
+// OutputResponse defines the response of the output route.
+type OutputResponse struct {
+	// The hex encoded ID of the message which contains the transaction creating the output, if the node tracks it.
+	MessageID string `json:"message_id,omitempty"`
+	// The hex encoded ID of the transaction creating the output.
+	TransactionID string `json:"transaction_id"`
+	// The index of the output within the transaction.
+	OutputIndex uint16 `json:"output_index"`
+	// Whether the output is spent.
+	Spent bool `json:"is_spent"`
+	// The output in its JSON form.
+	RawOutput json.RawMessage `json:"output"`
+}
+
+// Output decodes the output of the response.
+func (o *OutputResponse) Output() (Serializable, error) {
+	return DeserializeObjectFromJSON(o.RawOutput, OutputSelector)
+}
+
+// AddressBalanceResponse defines the response of the address route.
+type AddressBalanceResponse struct {
+	// The hex encoded serialized address.
+	Address string `json:"address"`
+	// The maximum amount of results the node returns from list routes, which doesn't limit the balance.
+	MaxResults int `json:"max_results"`
+	// The amount of outputs summed up.
+	Count int `json:"count"`
+	// The balance of the address.
+	Balance uint64 `json:"balance"`
+}
+
+// AddressOutputsResponse defines the response of the address outputs route.
+type AddressOutputsResponse struct {
+	// The hex encoded serialized address.
+	Address string `json:"address"`
+	// The maximum amount of results the node returns.
+	MaxResults int `json:"max_results"`
+	// The amount of results.
+	Count int `json:"count"`
+	// The hex encoded IDs of the unspent outputs, see UTXOInput.OutputIDHex.
+	OutputIDs []string `json:"output_ids"`
+}
+
+// NodeAPIClient is a client for the HTTP REST API of a node.
+type NodeAPIClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewNodeAPIClient creates a new NodeAPIClient for the node at the given base URL.
+// If httpClient is nil, http.DefaultClient is used.
+func NewNodeAPIClient(baseURL string, httpClient *http.Client) *NodeAPIClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &NodeAPIClient{baseURL: strings.TrimSuffix(baseURL, "/"), httpClient: httpClient}
+}
+
+// Info returns the info of the node.
+func (api *NodeAPIClient) Info(ctx context.Context) (*NodeInfoResponse, error) {
+	res := &NodeInfoResponse{}
+	if err := api.doJSON(ctx, http.MethodGet, NodeAPIRouteInfo, nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// SubmitMessage submits the given message in its JSON form and returns its ID.
+// Nodes which do PoW do it for messages whose nonce is zero, the returned ID then reflects the nonce they found.
+func (api *NodeAPIClient) SubmitMessage(ctx context.Context, msg *Message) (MessageID, error) {
+	res := &MessageIDResponse{}
+	if err := api.doJSON(ctx, http.MethodPost, NodeAPIRouteMessages, msg, res); err != nil {
+		return MessageID{}, err
+	}
+	return MessageIDFromHex(res.MessageID)
+}
+
+// SubmitMessageBinary submits the given message in its serialized form and returns its ID.
+// Like with SubmitMessage, nodes which do PoW do it for messages whose nonce is zero.
+func (api *NodeAPIClient) SubmitMessageBinary(ctx context.Context, msg *Message) (MessageID, error) {
+	data, err := msg.Serialize(DeSeriModePerformValidation)
+	if err != nil {
+		return MessageID{}, err
+	}
+	httpRes, err := api.do(ctx, http.MethodPost, NodeAPIRouteMessages, MIMEApplicationOctetStream, bytes.NewReader(data), MIMEApplicationJSON)
+	if err != nil {
+		return MessageID{}, err
+	}
+	defer httpRes.Body.Close()
+
+	res := &MessageIDResponse{}
+	if err := decodeOkResponse(httpRes.Body, res); err != nil {
+		return MessageID{}, err
+	}
+	return MessageIDFromHex(res.MessageID)
+}
+
+// MessageByMessageID returns the message with the given ID.
+func (api *NodeAPIClient) MessageByMessageID(ctx context.Context, id MessageID) (*Message, error) {
+	msg := &Message{}
+	if err := api.doJSON(ctx, http.MethodGet, NodeAPIRouteMessages+"/"+hex.EncodeToString(id[:]), nil, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// MessageBytesByMessageID returns the serialized form of the message with the given ID.
+func (api *NodeAPIClient) MessageBytesByMessageID(ctx context.Context, id MessageID) ([]byte, error) {
+	route := NodeAPIRouteMessages + "/" + hex.EncodeToString(id[:]) + NodeAPIRouteMessageRawSuffix
+	httpRes, err := api.do(ctx, http.MethodGet, route, "", nil, MIMEApplicationOctetStream)
+	if err != nil {
+		return nil, err
+	}
+	defer httpRes.Body.Close()
+	return ioutil.ReadAll(httpRes.Body)
+}
+
+// MessageMetadataByMessageID returns the metadata of the message with the given ID.
+func (api *NodeAPIClient) MessageMetadataByMessageID(ctx context.Context, id MessageID) (*MessageMetadataResponse, error) {
+	res := &MessageMetadataResponse{}
+	route := NodeAPIRouteMessages + "/" + hex.EncodeToString(id[:]) + NodeAPIRouteMessageMetadataSuffix
+	if err := api.doJSON(ctx, http.MethodGet, route, nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// MessageIDsByIndex returns the IDs of the messages carrying an IndexationPayload with the given index.
+func (api *NodeAPIClient) MessageIDsByIndex(ctx context.Context, index string) ([]MessageID, error) {
+	res := &MessageIDsByIndexResponse{}
+	route := NodeAPIRouteMessages + "?" + url.Values{NodeAPIQueryParameterIndex: {index}}.Encode()
+	if err := api.doJSON(ctx, http.MethodGet, route, nil, res); err != nil {
+		return nil, err
+	}
+	ids := make([]MessageID, len(res.MessageIDs))
+	for i, idHex := range res.MessageIDs {
+		id, err := MessageIDFromHex(idHex)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// OutputByUTXOInput returns the output referenced by the given UTXOInput.
+func (api *NodeAPIClient) OutputByUTXOInput(ctx context.Context, input *UTXOInput) (*OutputResponse, error) {
+	res := &OutputResponse{}
+	if err := api.doJSON(ctx, http.MethodGet, NodeAPIRouteOutputs+"/"+input.OutputIDHex(), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// BalanceByAddress returns the balance of the given address.
+func (api *NodeAPIClient) BalanceByAddress(ctx context.Context, addr Serializable) (*AddressBalanceResponse, error) {
+	route, err := addressRoute(addr)
+	if err != nil {
+		return nil, err
+	}
+	res := &AddressBalanceResponse{}
+	if err := api.doJSON(ctx, http.MethodGet, route, nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// OutputIDsByAddress returns UTXOInputs referencing the unspent outputs of the given address.
+func (api *NodeAPIClient) OutputIDsByAddress(ctx context.Context, addr Serializable) ([]*UTXOInput, error) {
+	route, err := addressRoute(addr)
+	if err != nil {
+		return nil, err
+	}
+	res := &AddressOutputsResponse{}
+	if err := api.doJSON(ctx, http.MethodGet, route+NodeAPIRouteAddressOutputsSuffix, nil, res); err != nil {
+		return nil, err
+	}
+	inputs := make([]*UTXOInput, len(res.OutputIDs))
+	for i, outputID := range res.OutputIDs {
+		input, err := UTXOInputFromOutputIDHex(outputID)
+		if err != nil {
+			return nil, err
+		}
+		inputs[i] = input
+	}
+	return inputs, nil
+}
+
+// addressRoute returns the route of the given address.
+func addressRoute(addr Serializable) (string, error) {
+	addrData, err := addr.Serialize(DeSeriModePerformValidation)
+	if err != nil {
+		return "", fmt.Errorf("unable to serialize address: %w", err)
+	}
+	return NodeAPIRouteAddresses + "/" + hex.EncodeToString(addrData), nil
+}
+
+// doJSON executes a request with the given object in its JSON form as the body, if any,
+// and decodes the data of the response into res.
+func (api *NodeAPIClient) doJSON(ctx context.Context, method string, route string, req interface{}, res interface{}) error {
+	var body io.Reader
+	var contentType string
+	if req != nil {
+		data, err := json.Marshal(req)
+		if err != nil {
+			return fmt.Errorf("unable to encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+		contentType = MIMEApplicationJSON
+	}
+
+	httpRes, err := api.do(ctx, method, route, contentType, body, MIMEApplicationJSON)
+	if err != nil {
+		return err
+	}
+	defer httpRes.Body.Close()
+	return decodeOkResponse(httpRes.Body, res)
+}
+
+// do executes a request and returns the response if it has a success status code.
+// Otherwise the response's error envelope is decoded into an HTTPError.
+func (api *NodeAPIClient) do(ctx context.Context, method string, route string, contentType string, body io.Reader, accept string) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, method, api.baseURL+route, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	httpReq.Header.Set("Accept", accept)
+
+	httpRes, err := api.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if httpRes.StatusCode >= http.StatusOK && httpRes.StatusCode < http.StatusMultipleChoices {
+		return httpRes, nil
+	}
+	defer httpRes.Body.Close()
+
+	httpErr := &HTTPError{StatusCode: httpRes.StatusCode}
+	errRes := &HTTPErrorResponseEnvelope{}
+	// the body of an error response isn't necessarily an error envelope, e.g. if it stems from a proxy
+	if err := json.NewDecoder(httpRes.Body).Decode(errRes); err == nil {
+		httpErr.Code = errRes.Error.Code
+		httpErr.Message = errRes.Error.Message
+	}
+	return nil, httpErr
+}
+
+// decodeOkResponse decodes the data of the success envelope read from r into res.
+func decodeOkResponse(r io.Reader, res interface{}) error {
+	if err := json.NewDecoder(r).Decode(&HTTPOkResponseEnvelope{Data: res}); err != nil {
+		return fmt.Errorf("unable to decode response: %w", err)
+	}
+	return nil
+}
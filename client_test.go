@@ -0,0 +1,283 @@
+package iota_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// standInNode is an in-memory stand-in for the REST API of a node.
+type standInNode struct {
+	t        *testing.T
+	url      string
+	mu       sync.Mutex
+	messages map[iota.MessageID]*iota.Message
+	outputs  map[string]*iota.SigLockedSingleDeposit
+}
+
+func newStandInNode(t *testing.T) (*standInNode, *iota.NodeAPIClient) {
+	node := &standInNode{t: t, messages: make(map[iota.MessageID]*iota.Message), outputs: make(map[string]*iota.SigLockedSingleDeposit)}
+	srv := httptest.NewServer(node)
+	t.Cleanup(srv.Close)
+	node.url = srv.URL
+	return node, iota.NewNodeAPIClient(srv.URL+"/", srv.Client())
+}
+
+func (n *standInNode) writeOk(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", iota.MIMEApplicationJSON)
+	require.NoError(n.t, json.NewEncoder(w).Encode(&iota.HTTPOkResponseEnvelope{Data: data}))
+}
+
+func (n *standInNode) writeErr(w http.ResponseWriter, status int, msg string) {
+	errRes := &iota.HTTPErrorResponseEnvelope{}
+	errRes.Error.Code = http.StatusText(status)
+	errRes.Error.Message = msg
+	w.Header().Set("Content-Type", iota.MIMEApplicationJSON)
+	w.WriteHeader(status)
+	require.NoError(n.t, json.NewEncoder(w).Encode(errRes))
+}
+
+func (n *standInNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	path := r.URL.Path
+	switch {
+	case path == iota.NodeAPIRouteInfo:
+		n.writeOk(w, &iota.NodeInfoResponse{Name: "stand-in", Version: "1.0.0", IsHealthy: true, NetworkID: 1337, LatestMilestoneIndex: 10, SolidMilestoneIndex: 9})
+
+	case path == iota.NodeAPIRouteMessages && r.Method == http.MethodPost:
+		msg := &iota.Message{}
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(n.t, err)
+		switch r.Header.Get("Content-Type") {
+		case iota.MIMEApplicationJSON:
+			err = json.Unmarshal(body, msg)
+		case iota.MIMEApplicationOctetStream:
+			_, err = msg.Deserialize(body, iota.DeSeriModePerformValidation)
+		default:
+			n.writeErr(w, http.StatusBadRequest, "unsupported content type")
+			return
+		}
+		if err != nil {
+			n.writeErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		id, err := msg.ID()
+		require.NoError(n.t, err)
+		n.messages[id] = msg
+		n.writeOk(w, &iota.MessageIDResponse{MessageID: hex.EncodeToString(id[:])})
+
+	case path == iota.NodeAPIRouteMessages && r.Method == http.MethodGet:
+		index := r.URL.Query().Get(iota.NodeAPIQueryParameterIndex)
+		res := &iota.MessageIDsByIndexResponse{Index: index, MaxResults: 1000, MessageIDs: []string{}}
+		for id, msg := range n.messages {
+			if payload, ok := msg.Payload.(*iota.IndexationPayload); ok && payload.Index == index {
+				res.MessageIDs = append(res.MessageIDs, hex.EncodeToString(id[:]))
+			}
+		}
+		res.Count = len(res.MessageIDs)
+		n.writeOk(w, res)
+
+	case strings.HasPrefix(path, iota.NodeAPIRouteMessages+"/"):
+		rest := strings.TrimPrefix(path, iota.NodeAPIRouteMessages+"/")
+		id, err := iota.MessageIDFromHex(strings.SplitN(rest, "/", 2)[0])
+		if err != nil {
+			n.writeErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		msg, has := n.messages[id]
+		if !has {
+			n.writeErr(w, http.StatusNotFound, "message not found")
+			return
+		}
+		switch {
+		case strings.HasSuffix(rest, iota.NodeAPIRouteMessageRawSuffix):
+			data, err := msg.Serialize(iota.DeSeriModePerformValidation)
+			require.NoError(n.t, err)
+			w.Header().Set("Content-Type", iota.MIMEApplicationOctetStream)
+			_, _ = w.Write(data)
+		case strings.HasSuffix(rest, iota.NodeAPIRouteMessageMetadataSuffix):
+			msIndex := uint64(5)
+			n.writeOk(w, &iota.MessageMetadataResponse{
+				MessageID: hex.EncodeToString(id[:]), Parent1: hex.EncodeToString(msg.Parent1[:]), Parent2: hex.EncodeToString(msg.Parent2[:]),
+				Solid: true, ReferencedByMilestoneIndex: &msIndex,
+				LedgerInclusionState: iota.LedgerInclusionConflicting.String(), ConflictReason: iota.ConflictInputUTXONotFound,
+			})
+		default:
+			n.writeOk(w, msg)
+		}
+
+	case strings.HasPrefix(path, iota.NodeAPIRouteOutputs+"/"):
+		outputID := strings.TrimPrefix(path, iota.NodeAPIRouteOutputs+"/")
+		output, has := n.outputs[outputID]
+		if !has {
+			n.writeErr(w, http.StatusNotFound, "output not found")
+			return
+		}
+		input, err := iota.UTXOInputFromOutputIDHex(outputID)
+		require.NoError(n.t, err)
+		outputJSON, err := json.Marshal(output)
+		require.NoError(n.t, err)
+		n.writeOk(w, &iota.OutputResponse{
+			MessageID: strings.Repeat("00", iota.MessageHashLength), TransactionID: hex.EncodeToString(input.TransactionID[:]),
+			OutputIndex: input.TransactionOutputIndex, RawOutput: outputJSON,
+		})
+
+	case strings.HasPrefix(path, iota.NodeAPIRouteAddresses+"/"):
+		rest := strings.TrimPrefix(path, iota.NodeAPIRouteAddresses+"/")
+		addrHex := strings.TrimSuffix(rest, iota.NodeAPIRouteAddressOutputsSuffix)
+		var outputIDs []string
+		var balance uint64
+		for outputID, output := range n.outputs {
+			outputAddr, err := output.Address.Serialize(iota.DeSeriModeNoValidation)
+			require.NoError(n.t, err)
+			if hex.EncodeToString(outputAddr) == addrHex {
+				outputIDs = append(outputIDs, outputID)
+				balance += output.Amount
+			}
+		}
+		if strings.HasSuffix(rest, iota.NodeAPIRouteAddressOutputsSuffix) {
+			n.writeOk(w, &iota.AddressOutputsResponse{Address: addrHex, MaxResults: 1000, Count: len(outputIDs), OutputIDs: outputIDs})
+			return
+		}
+		n.writeOk(w, &iota.AddressBalanceResponse{Address: addrHex, MaxResults: 1000, Count: len(outputIDs), Balance: balance})
+
+	default:
+		n.writeErr(w, http.StatusNotImplemented, "route not implemented")
+	}
+}
+
+func TestNodeAPIClient_Info(t *testing.T) {
+	_, client := newStandInNode(t)
+	info, err := client.Info(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &iota.NodeInfoResponse{Name: "stand-in", Version: "1.0.0", IsHealthy: true, NetworkID: 1337, LatestMilestoneIndex: 10, SolidMilestoneIndex: 9}, info)
+}
+
+func TestNodeAPIClient_Messages(t *testing.T) {
+	_, client := newStandInNode(t)
+	ctx := context.Background()
+
+	jsonMsg, _ := randMessage(iota.SignedTransactionPayloadID)
+	binaryMsg, binaryMsgData := randMessage(iota.IndexationPayloadID)
+
+	jsonMsgID, err := client.SubmitMessage(ctx, jsonMsg)
+	require.NoError(t, err)
+	expectedJSONMsgID, err := jsonMsg.ID()
+	require.NoError(t, err)
+	assert.Equal(t, expectedJSONMsgID, jsonMsgID)
+
+	binaryMsgID, err := client.SubmitMessageBinary(ctx, binaryMsg)
+	require.NoError(t, err)
+
+	fetched, err := client.MessageByMessageID(ctx, jsonMsgID)
+	require.NoError(t, err)
+	assert.EqualValues(t, jsonMsg, fetched)
+
+	fetchedData, err := client.MessageBytesByMessageID(ctx, binaryMsgID)
+	require.NoError(t, err)
+	assert.Equal(t, binaryMsgData, fetchedData)
+
+	metadata, err := client.MessageMetadataByMessageID(ctx, jsonMsgID)
+	require.NoError(t, err)
+	assert.True(t, metadata.Solid)
+	require.NotNil(t, metadata.ReferencedByMilestoneIndex)
+	assert.EqualValues(t, 5, *metadata.ReferencedByMilestoneIndex)
+	assert.Equal(t, iota.ConflictInputUTXONotFound, metadata.ConflictReason)
+
+	ids, err := client.MessageIDsByIndex(ctx, binaryMsg.Payload.(*iota.IndexationPayload).Index)
+	require.NoError(t, err)
+	assert.Equal(t, []iota.MessageID{binaryMsgID}, ids)
+}
+
+func TestNodeAPIClient_Outputs(t *testing.T) {
+	node, client := newStandInNode(t)
+	ctx := context.Background()
+
+	addr, _ := randEd25519Addr()
+	otherAddr, _ := randEd25519Addr()
+	input1, _ := randUTXOInput()
+	input2, _ := randUTXOInput()
+	input3, _ := randUTXOInput()
+	node.outputs[input1.OutputIDHex()] = &iota.SigLockedSingleDeposit{Address: addr, Amount: 100}
+	node.outputs[input2.OutputIDHex()] = &iota.SigLockedSingleDeposit{Address: addr, Amount: 50}
+	node.outputs[input3.OutputIDHex()] = &iota.SigLockedSingleDeposit{Address: otherAddr, Amount: 1}
+
+	outputRes, err := client.OutputByUTXOInput(ctx, input1)
+	require.NoError(t, err)
+	assert.Equal(t, input1.TransactionOutputIndex, outputRes.OutputIndex)
+	output, err := outputRes.Output()
+	require.NoError(t, err)
+	assert.EqualValues(t, node.outputs[input1.OutputIDHex()], output)
+
+	balance, err := client.BalanceByAddress(ctx, addr)
+	require.NoError(t, err)
+	assert.EqualValues(t, 150, balance.Balance)
+	assert.Equal(t, 2, balance.Count)
+
+	inputs, err := client.OutputIDsByAddress(ctx, addr)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*iota.UTXOInput{input1, input2}, inputs)
+}
+
+func TestNodeAPIClient_Errors(t *testing.T) {
+	node, client := newStandInNode(t)
+	ctx := context.Background()
+
+	_, err := client.MessageByMessageID(ctx, iota.MessageID{1})
+	assert.True(t, errors.Is(err, iota.ErrHTTPNotFound))
+	var httpErr *iota.HTTPError
+	require.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+	assert.Equal(t, "message not found", httpErr.Message)
+
+	input, _ := randUTXOInput()
+	_, err = client.OutputByUTXOInput(ctx, input)
+	assert.True(t, errors.Is(err, iota.ErrHTTPNotFound))
+
+	// error responses without an error envelope, e.g. from a proxy
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+	}))
+	defer proxy.Close()
+	_, err = iota.NewNodeAPIClient(proxy.URL, nil).Info(ctx)
+	assert.True(t, errors.Is(err, iota.ErrHTTPUnknownError))
+	require.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusBadGateway, httpErr.StatusCode)
+	assert.Empty(t, httpErr.Message)
+
+	unknown := iota.NewNodeAPIClient(node.url+"/unknown", nil)
+	_, err = unknown.Info(ctx)
+	assert.True(t, errors.Is(err, iota.ErrHTTPNotImplemented))
+}
+
+func TestHTTPError_Unwrap(t *testing.T) {
+	tests := []struct {
+		status int
+		err    error
+	}{
+		{http.StatusBadRequest, iota.ErrHTTPBadRequest},
+		{http.StatusNotFound, iota.ErrHTTPNotFound},
+		{http.StatusInternalServerError, iota.ErrHTTPInternalServerError},
+		{http.StatusNotImplemented, iota.ErrHTTPNotImplemented},
+		{http.StatusServiceUnavailable, iota.ErrHTTPServiceUnavailable},
+		{http.StatusTeapot, iota.ErrHTTPUnknownError},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			assert.True(t, errors.Is(&iota.HTTPError{StatusCode: tt.status}, tt.err))
+		})
+	}
+}
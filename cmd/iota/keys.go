@@ -0,0 +1,492 @@
+package main
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/luca-moser/iota"
+	"golang.org/x/crypto/scrypt"
+)
+
+func init() {
+	register(keyGenCmd)
+	register(keyDeriveCmd)
+	register(addressCmd)
+	register(signCmd)
+}
+
+var keyGenCmd = &command{
+	name:    "key-gen",
+	args:    "[-passphrase-file file] [-show-seed] <key file>",
+	summary: "generates an Ed25519 seed and writes it to a passphrase encrypted key file",
+	run:     runKeyGen,
+}
+
+var keyDeriveCmd = &command{
+	name:    "key-derive",
+	args:    "[-path path] [-count n] [-prefix hrp] [-passphrase-file file] <key file>",
+	summary: "derives the keys of a key file along a path and prints their addresses in hex and Bech32",
+	run:     runKeyDerive,
+}
+
+var addressCmd = &command{
+	name:    "address",
+	args:    "[-prefix hrp] [-pubkey] <hex|bech32>",
+	summary: "converts an Ed25519 address or public key between hex and Bech32",
+	run:     runAddress,
+}
+
+var signCmd = &command{
+	name:    "sign",
+	args:    "[-path path] [-passphrase-file file] [-out file] [-hex] <key file> <transaction file>",
+	summary: "signs the hex, binary or JSON unsigned transaction in a file with a key derived from a key file",
+	run:     runSign,
+}
+
+const (
+	// The derivation path of the first address of the first account.
+	defaultDerivationPath = "m/44'/4218'/0'/0'/0'"
+	// The length of generated seeds.
+	seedLength = 32
+
+	keyFileVersion = 1
+	keyFileKDF     = "scrypt"
+	keyFileCipher  = "aes-256-gcm"
+	keyFileSaltLen = 32
+	// The maximum scrypt parameters accepted from key files, bounding the memory and time a key file can make the CLI spend.
+	keyFileMaxScryptN = 1 << 20
+	keyFileMaxScryptR = 8
+	keyFileMaxScryptP = 16
+)
+
+var (
+	// The scrypt cost of generated key files.
+	keyFileScryptN = 1 << 15
+
+	errInvalidKeyFile    = errors.New("invalid key file")
+	errWrongPassphrase   = errors.New("wrong passphrase or corrupted key file")
+	errMissingPassphrase = errors.New("no passphrase given")
+)
+
+// keyFile is the JSON form of a seed encrypted with a key derived from a passphrase.
+type keyFile struct {
+	Version    int                 `json:"version"`
+	KDF        keyFileKDFParams    `json:"kdf"`
+	Cipher     keyFileCipherParams `json:"cipher"`
+	Ciphertext string              `json:"ciphertext"`
+}
+
+// keyFileKDFParams are the parameters of the scrypt key derivation of a key file.
+type keyFileKDFParams struct {
+	Name string `json:"name"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt string `json:"salt"`
+}
+
+// keyFileCipherParams are the parameters of the AES-GCM encryption of a key file.
+type keyFileCipherParams struct {
+	Name  string `json:"name"`
+	Nonce string `json:"nonce"`
+}
+
+// newKeyCipher derives the key of the given salt and passphrase and returns the AEAD using it.
+func newKeyCipher(passphrase []byte, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, n, r, p, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptSeed encrypts the given seed with a key derived from the given passphrase.
+func encryptSeed(seed []byte, passphrase []byte) (*keyFile, error) {
+	salt := make([]byte, keyFileSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	kdf := keyFileKDFParams{Name: keyFileKDF, N: keyFileScryptN, R: 8, P: 1, Salt: hex.EncodeToString(salt)}
+	aead, err := newKeyCipher(passphrase, salt, kdf.N, kdf.R, kdf.P)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &keyFile{
+		Version:    keyFileVersion,
+		KDF:        kdf,
+		Cipher:     keyFileCipherParams{Name: keyFileCipher, Nonce: hex.EncodeToString(nonce)},
+		Ciphertext: hex.EncodeToString(aead.Seal(nil, nonce, seed, nil)),
+	}, nil
+}
+
+// decryptSeed decrypts the seed of the key file with a key derived from the given passphrase.
+func (kf *keyFile) decryptSeed(passphrase []byte) ([]byte, error) {
+	switch {
+	case kf.Version != keyFileVersion:
+		return nil, fmt.Errorf("%w: unsupported version %d", errInvalidKeyFile, kf.Version)
+	case kf.KDF.Name != keyFileKDF:
+		return nil, fmt.Errorf("%w: unsupported key derivation function '%s'", errInvalidKeyFile, kf.KDF.Name)
+	case kf.Cipher.Name != keyFileCipher:
+		return nil, fmt.Errorf("%w: unsupported cipher '%s'", errInvalidKeyFile, kf.Cipher.Name)
+	case kf.KDF.N > keyFileMaxScryptN || kf.KDF.R > keyFileMaxScryptR || kf.KDF.P > keyFileMaxScryptP:
+		return nil, fmt.Errorf("%w: scrypt parameters exceed n=%d, r=%d, p=%d", errInvalidKeyFile, keyFileMaxScryptN, keyFileMaxScryptR, keyFileMaxScryptP)
+	}
+	salt, err := hex.DecodeString(kf.KDF.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: salt: %v", errInvalidKeyFile, err)
+	}
+	nonce, err := hex.DecodeString(kf.Cipher.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: nonce: %v", errInvalidKeyFile, err)
+	}
+	ciphertext, err := hex.DecodeString(kf.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ciphertext: %v", errInvalidKeyFile, err)
+	}
+	aead, err := newKeyCipher(passphrase, salt, kf.KDF.N, kf.KDF.R, kf.KDF.P)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidKeyFile, err)
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%w: nonce must be %d bytes", errInvalidKeyFile, aead.NonceSize())
+	}
+	seed, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errWrongPassphrase
+	}
+	return seed, nil
+}
+
+// writeKeyFile writes the given key file readable only by the user. Existing files are never overwritten.
+func writeKeyFile(path string, kf *keyFile) error {
+	data, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// readSeed reads the key file at the given path and decrypts its seed with the passphrase read from the given file or stdin.
+func readSeed(env *env, path string, passphraseFile string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	kf := &keyFile{}
+	if err := json.Unmarshal(data, kf); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidKeyFile, err)
+	}
+	passphrase, err := readPassphrase(env, passphraseFile)
+	if err != nil {
+		return nil, err
+	}
+	return kf.decryptSeed(passphrase)
+}
+
+// readPassphrase reads the passphrase from the first line of the given file, or of stdin if the file is empty.
+func readPassphrase(env *env, file string) ([]byte, error) {
+	var r io.Reader = env.stdin
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	passphrase := strings.TrimRight(line, "\r\n")
+	if passphrase == "" {
+		return nil, errMissingPassphrase
+	}
+	return []byte(passphrase), nil
+}
+
+// derivedKey is a key derived from a seed, as printed by the key-derive command.
+type derivedKey struct {
+	Path      string `json:"path"`
+	PublicKey string `json:"public_key"`
+	Address   string `json:"address"`
+	Bech32    string `json:"bech32"`
+}
+
+func newDerivedKey(path iota.DerivationPath, key *iota.ExtendedEd25519Key, prefix iota.NetworkPrefix) (*derivedKey, error) {
+	pubKey := key.PublicKey()
+	addr := key.Address()
+	bech32, err := iota.AddressToBech32(prefix, &addr)
+	if err != nil {
+		return nil, err
+	}
+	return &derivedKey{
+		Path:      path.String(),
+		PublicKey: hex.EncodeToString(pubKey),
+		Address:   hex.EncodeToString(addr[:]),
+		Bech32:    bech32,
+	}, nil
+}
+
+func runKeyGen(env *env, cmd *command, args []string) error {
+	fs := newFlagSet(env, cmd)
+	passphraseFile := fs.String("passphrase-file", "", "the file to read the passphrase from, the first line of stdin if omitted")
+	showSeed := fs.Bool("show-seed", false, "whether to print the generated seed hex encoded")
+	if err := parseFileArgs(fs, args, 1); err != nil {
+		return err
+	}
+	passphrase, err := readPassphrase(env, *passphraseFile)
+	if err != nil {
+		return err
+	}
+
+	seed := make([]byte, seedLength)
+	if _, err := rand.Read(seed); err != nil {
+		return err
+	}
+	kf, err := encryptSeed(seed, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := writeKeyFile(fs.Arg(0), kf); err != nil {
+		return fmt.Errorf("unable to write key file: %w", err)
+	}
+	fmt.Fprintf(env.stderr, "key file written to %s\n", fs.Arg(0))
+	if *showSeed {
+		_, err = fmt.Fprintln(env.stdout, hex.EncodeToString(seed))
+	}
+	return err
+}
+
+func runKeyDerive(env *env, cmd *command, args []string) error {
+	fs := newFlagSet(env, cmd)
+	path := fs.String("path", defaultDerivationPath, "the derivation path of the key, all indices must be hardened")
+	count := fs.Int("count", 1, "the amount of keys to derive by incrementing the last index of the path")
+	prefix := fs.String("prefix", string(iota.PrefixMainnet), "the network prefix of the Bech32 addresses")
+	passphraseFile := fs.String("passphrase-file", "", "the file to read the passphrase from, the first line of stdin if omitted")
+	if err := parseFileArgs(fs, args, 1); err != nil {
+		return err
+	}
+	derivationPath, err := iota.ParseDerivationPath(*path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if len(derivationPath) == 0 {
+		return fmt.Errorf("%w: the path must contain at least one index", errUsage)
+	}
+	last := derivationPath[len(derivationPath)-1] - iota.HardenedIndex
+	if *count < 1 || uint64(last)+uint64(*count) > uint64(iota.HardenedIndex) {
+		return fmt.Errorf("%w: invalid count %d", errUsage, *count)
+	}
+	seed, err := readSeed(env, fs.Arg(0), *passphraseFile)
+	if err != nil {
+		return err
+	}
+	master, err := iota.NewEd25519MasterKey(seed)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]*derivedKey, 0, *count)
+	for i := 0; i < *count; i++ {
+		derivationPath[len(derivationPath)-1] = (last + uint32(i)) | iota.HardenedIndex
+		extendedKey, err := master.Derive(derivationPath)
+		if err != nil {
+			return err
+		}
+		key, err := newDerivedKey(derivationPath, extendedKey, iota.NetworkPrefix(*prefix))
+		if err != nil {
+			return fmt.Errorf("%w: %v", errUsage, err)
+		}
+		keys = append(keys, key)
+	}
+	out, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutput(env, "", append(out, '\n'))
+}
+
+// convertedAddress is the output of the address command.
+type convertedAddress struct {
+	Address string `json:"address"`
+	Bech32  string `json:"bech32"`
+}
+
+func runAddress(env *env, cmd *command, args []string) error {
+	fs := newFlagSet(env, cmd)
+	prefix := fs.String("prefix", string(iota.PrefixMainnet), "the network prefix of the Bech32 address if given in hex")
+	pubKey := fs.Bool("pubkey", false, "whether the hex argument is an Ed25519 public key instead of an address")
+	if err := parseFileArgs(fs, args, 1); err != nil {
+		return err
+	}
+	arg := fs.Arg(0)
+
+	var addr iota.Ed25519Address
+	if data, err := hex.DecodeString(strings.TrimPrefix(arg, "0x")); err == nil {
+		switch {
+		case *pubKey && len(data) == ed25519.PublicKeySize:
+			addr = iota.AddressFromEd25519PubKey(data)
+		case !*pubKey && len(data) == iota.Ed25519AddressBytesLength:
+			copy(addr[:], data)
+		default:
+			return fmt.Errorf("%w: expected %d hex encoded bytes", errUsage, iota.Ed25519AddressBytesLength)
+		}
+	} else {
+		if *pubKey {
+			return fmt.Errorf("%w: public keys must be hex encoded", errUsage)
+		}
+		networkPrefix, parsed, err := iota.ParseBech32Address(arg)
+		if err != nil {
+			return err
+		}
+		edAddr, ok := parsed.(*iota.Ed25519Address)
+		if !ok {
+			return fmt.Errorf("%w: not an Ed25519 address", iota.ErrUnknownAddrType)
+		}
+		addr = *edAddr
+		*prefix = string(networkPrefix)
+	}
+
+	bech32, err := iota.AddressToBech32(iota.NetworkPrefix(*prefix), &addr)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	out, err := json.MarshalIndent(&convertedAddress{Address: hex.EncodeToString(addr[:]), Bech32: bech32}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutput(env, "", append(out, '\n'))
+}
+
+// parseUnsignedTransaction parses and validates the unsigned transaction in the given hex, binary or JSON input
+// and returns it together with its serialized form, which is the data to sign.
+func parseUnsignedTransaction(input []byte) (tx *iota.UnsignedTransaction, data []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			tx, data, err = nil, nil, fmt.Errorf("%w: malformed transaction: %v", iota.ErrDeserializationNotEnoughData, r)
+		}
+	}()
+
+	format, data := detectFormat(input)
+	if format == formatJSON {
+		jsonTx := &iota.UnsignedTransaction{}
+		if err := json.Unmarshal(data, jsonTx); err != nil {
+			return nil, nil, err
+		}
+		if data, err = jsonTx.Serialize(iota.DeSeriModePerformValidation); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	tx = &iota.UnsignedTransaction{}
+	bytesRead, err := tx.Deserialize(data, iota.DeSeriModePerformValidation)
+	if err != nil {
+		return nil, nil, err
+	}
+	if bytesRead != len(data) {
+		return nil, nil, fmt.Errorf("%w: transaction is %d bytes but the data %d", iota.ErrDeserializationNotAllConsumed, bytesRead, len(data))
+	}
+	if err := tx.SyntacticallyValid(); err != nil {
+		return nil, nil, err
+	}
+	return tx, data, nil
+}
+
+// describeTransaction writes what the given transaction deposits, so that it can be reviewed before it is signed.
+func describeTransaction(w io.Writer, tx *iota.UnsignedTransaction) {
+	fmt.Fprintf(w, "signing transaction with %d input(s) and %d output(s)\n", len(tx.Inputs), len(tx.Outputs))
+	for _, output := range tx.Outputs {
+		deposit, ok := output.(*iota.SigLockedSingleDeposit)
+		if !ok {
+			continue
+		}
+		addr, err := iota.AddressToBech32(iota.PrefixMainnet, deposit.Address)
+		if err != nil {
+			addrData, _ := deposit.Address.Serialize(iota.DeSeriModeNoValidation)
+			addr = hex.EncodeToString(addrData)
+		}
+		fmt.Fprintf(w, "  deposit %d to %s\n", deposit.Amount, addr)
+	}
+}
+
+func runSign(env *env, cmd *command, args []string) error {
+	fs := newFlagSet(env, cmd)
+	path := fs.String("path", defaultDerivationPath, "the derivation path of the signing key, all indices must be hardened")
+	passphraseFile := fs.String("passphrase-file", "", "the file to read the passphrase from, the first line of stdin if omitted")
+	out := fs.String("out", "", "the file to write the signature unlock block to, stdout if omitted")
+	hexOut := fs.Bool("hex", false, "whether to write the serialized signature unlock block hex encoded instead of as JSON")
+	if err := parseFileArgs(fs, args, 2); err != nil {
+		return err
+	}
+	derivationPath, err := iota.ParseDerivationPath(*path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	input, err := ioutil.ReadFile(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	tx, txData, err := parseUnsignedTransaction(input)
+	if err != nil {
+		return fmt.Errorf("invalid transaction: %w", err)
+	}
+	seed, err := readSeed(env, fs.Arg(0), *passphraseFile)
+	if err != nil {
+		return err
+	}
+
+	prvKey, err := iota.DeriveEd25519Key(seed, derivationPath)
+	if err != nil {
+		return err
+	}
+
+	describeTransaction(env.stderr, tx)
+	edSig := &iota.Ed25519Signature{}
+	copy(edSig.PublicKey[:], prvKey.Public().(ed25519.PublicKey))
+	copy(edSig.Signature[:], ed25519.Sign(prvKey, txData))
+	unlockBlock := &iota.SignatureUnlockBlock{Signature: edSig}
+
+	var data []byte
+	if *hexOut {
+		blockData, err := unlockBlock.Serialize(iota.DeSeriModePerformValidation)
+		if err != nil {
+			return err
+		}
+		data = []byte(hex.EncodeToString(blockData) + "\n")
+	} else {
+		if data, err = json.MarshalIndent(unlockBlock, "", "  "); err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	}
+	return writeOutput(env, *out, data)
+}
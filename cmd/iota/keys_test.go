@@ -0,0 +1,187 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	// keeps the key files generated by the tests cheap to decrypt
+	keyFileScryptN = 1 << 10
+}
+
+// deriveTestKey derives the private key of the given seed along the given path.
+func deriveTestKey(t *testing.T, seed []byte, path string) ed25519.PrivateKey {
+	derivationPath, err := iota.ParseDerivationPath(path)
+	require.NoError(t, err)
+	prvKey, err := iota.DeriveEd25519Key(seed, derivationPath)
+	require.NoError(t, err)
+	return prvKey
+}
+
+// genKeyFile generates a key file protected by the given passphrase and returns its path and seed.
+func genKeyFile(t *testing.T, passphrase string) (string, []byte) {
+	path := filepath.Join(t.TempDir(), "seed.key")
+	code, stdout, stderr := runCmd([]byte(passphrase+"\n"), "key-gen", "-show-seed", path)
+	require.Equal(t, exitOk, code, stderr)
+	seed, err := hex.DecodeString(strings.TrimSpace(stdout))
+	require.NoError(t, err)
+	require.Len(t, seed, seedLength)
+	return path, seed
+}
+
+func TestKeyGen(t *testing.T) {
+	path, seed := genKeyFile(t, "secret")
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), hex.EncodeToString(seed))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	kf := &keyFile{}
+	require.NoError(t, json.Unmarshal(data, kf))
+	decrypted, err := kf.decryptSeed([]byte("secret"))
+	require.NoError(t, err)
+	assert.Equal(t, seed, decrypted)
+	_, err = kf.decryptSeed([]byte("wrong"))
+	assert.True(t, errors.Is(err, errWrongPassphrase))
+
+	// the seed is only printed on request and existing key files are never overwritten
+	passphraseFile := filepath.Join(t.TempDir(), "passphrase")
+	require.NoError(t, ioutil.WriteFile(passphraseFile, []byte("secret\n"), 0600))
+	other := filepath.Join(t.TempDir(), "other.key")
+	code, stdout, stderr := runCmd(nil, "key-gen", "-passphrase-file", passphraseFile, other)
+	require.Equal(t, exitOk, code, stderr)
+	assert.Empty(t, stdout)
+	code, _, stderr = runCmd([]byte("secret\n"), "key-gen", path)
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, stderr, "unable to write key file")
+
+	code, _, stderr = runCmd(nil, "key-gen", filepath.Join(t.TempDir(), "empty.key"))
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, stderr, errMissingPassphrase.Error())
+}
+
+func TestKeyDerive(t *testing.T) {
+	path, seed := genKeyFile(t, "secret")
+
+	code, stdout, stderr := runCmd([]byte("secret\n"), "key-derive", "-path", "m/44'/4218'/1'/0'/5'", "-count", "2", "-prefix", "atoi", path)
+	require.Equal(t, exitOk, code, stderr)
+	var keys []derivedKey
+	require.NoError(t, json.Unmarshal([]byte(stdout), &keys))
+	require.Len(t, keys, 2)
+
+	for i, expectedPath := range []string{"m/44'/4218'/1'/0'/5'", "m/44'/4218'/1'/0'/6'"} {
+		pubKey := deriveTestKey(t, seed, expectedPath).Public().(ed25519.PublicKey)
+		addr := iota.AddressFromEd25519PubKey(pubKey)
+
+		assert.Equal(t, expectedPath, keys[i].Path)
+		assert.Equal(t, hex.EncodeToString(pubKey), keys[i].PublicKey)
+		assert.Equal(t, hex.EncodeToString(addr[:]), keys[i].Address)
+		assert.Equal(t, addr.Bech32(iota.PrefixTestnet), keys[i].Bech32)
+	}
+
+	code, _, stderr = runCmd([]byte("wrong\n"), "key-derive", path)
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, stderr, errWrongPassphrase.Error())
+
+	code, _, _ = runCmd([]byte("secret\n"), "key-derive", "-path", "m/44'/0", path)
+	assert.Equal(t, exitUsage, code)
+	code, _, _ = runCmd([]byte("secret\n"), "key-derive", "-path", "m/2147483647'", "-count", "2", path)
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestAddress(t *testing.T) {
+	pubKey, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	addr := iota.AddressFromEd25519PubKey(pubKey)
+	addrHex := hex.EncodeToString(addr[:])
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected convertedAddress
+	}{
+		{"hex address", []string{addrHex}, convertedAddress{addrHex, addr.Bech32(iota.PrefixMainnet)}},
+		{"public key", []string{"-pubkey", "-prefix", "atoi", hex.EncodeToString(pubKey)}, convertedAddress{addrHex, addr.Bech32(iota.PrefixTestnet)}},
+		{"bech32", []string{addr.Bech32(iota.PrefixTestnet)}, convertedAddress{addrHex, addr.Bech32(iota.PrefixTestnet)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCmd(nil, append([]string{"address"}, tt.args...)...)
+			require.Equal(t, exitOk, code, stderr)
+			converted := convertedAddress{}
+			require.NoError(t, json.Unmarshal([]byte(stdout), &converted))
+			assert.Equal(t, tt.expected, converted)
+		})
+	}
+
+	code, _, _ := runCmd(nil, "address", "abcd")
+	assert.Equal(t, exitUsage, code)
+	code, _, stderr := runCmd(nil, "address", "iota1qqqqqqqq")
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, stderr, iota.ErrBech32InvalidChecksum.Error())
+}
+
+func TestSign(t *testing.T) {
+	keyPath, seed := genKeyFile(t, "secret")
+	unsignedTx := signedTransactionMessage(100).Payload.(*iota.SignedTransactionPayload).Transaction.(*iota.UnsignedTransaction)
+	txData, err := unsignedTx.Serialize(iota.DeSeriModePerformValidation)
+	require.NoError(t, err)
+	txJSON, err := json.Marshal(unsignedTx)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	binaryTx, hexTx, jsonTx := filepath.Join(dir, "tx.bin"), filepath.Join(dir, "tx.hex"), filepath.Join(dir, "tx.json")
+	require.NoError(t, ioutil.WriteFile(binaryTx, txData, 0644))
+	require.NoError(t, ioutil.WriteFile(hexTx, []byte(hex.EncodeToString(txData)+"\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(jsonTx, txJSON, 0644))
+
+	pubKey := deriveTestKey(t, seed, "m/44'/4218'/0'/0'/3'").Public().(ed25519.PublicKey)
+
+	for _, txFile := range []string{binaryTx, hexTx, jsonTx} {
+		code, stdout, stderr := runCmd([]byte("secret\n"), "sign", "-path", "m/44'/4218'/0'/0'/3'", keyPath, txFile)
+		require.Equal(t, exitOk, code, stderr)
+		assert.Contains(t, stderr, "signing transaction with 1 input(s) and 1 output(s)")
+		assert.Contains(t, stderr, "deposit 100 to iota1")
+
+		unlockBlock := &iota.SignatureUnlockBlock{}
+		require.NoError(t, json.Unmarshal([]byte(stdout), unlockBlock))
+		edSig := unlockBlock.Signature.(*iota.Ed25519Signature)
+		assert.Equal(t, []byte(pubKey), edSig.PublicKey[:])
+		assert.True(t, ed25519.Verify(pubKey, txData, edSig.Signature[:]))
+	}
+
+	out := filepath.Join(dir, "unlock_block.hex")
+	code, _, stderr := runCmd([]byte("secret\n"), "sign", "-hex", "-out", out, keyPath, binaryTx)
+	require.Equal(t, exitOk, code, stderr)
+	written, err := ioutil.ReadFile(out)
+	require.NoError(t, err)
+	blockData, err := hex.DecodeString(strings.TrimSpace(string(written)))
+	require.NoError(t, err)
+	unlockBlock := &iota.SignatureUnlockBlock{}
+	_, err = unlockBlock.Deserialize(blockData, iota.DeSeriModePerformValidation)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(binaryTx, txData[:len(txData)-3], 0644))
+	code, stdout, stderr := runCmd([]byte("secret\n"), "sign", keyPath, binaryTx)
+	assert.Equal(t, exitFailure, code)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "invalid transaction")
+
+	code, _, _ = runCmd([]byte("secret\n"), "sign", keyPath)
+	assert.Equal(t, exitUsage, code)
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/luca-moser/iota"
+)
+
+// field is a field of a serialized object.
+type field struct {
+	// The path of the field, named after the object's JSON form.
+	Path string `json:"field"`
+	// The offset of the field within the serialized object.
+	Offset int `json:"offset"`
+	// The length of the field.
+	Length int `json:"length"`
+	// The hex encoded bytes of the field.
+	Bytes string `json:"bytes"`
+}
+
+// layout walks the fields of a serialized object in the order they are serialized.
+type layout struct {
+	data   []byte
+	offset int
+	fields []field
+}
+
+// add adds the field with the given path and length at the current offset.
+func (l *layout) add(path string, length int) error {
+	if l.offset+length > len(l.data) {
+		return fmt.Errorf("field %s at offset %d with length %d exceeds the data of %d bytes", path, l.offset, length, len(l.data))
+	}
+	l.fields = append(l.fields, field{
+		Path:   path,
+		Offset: l.offset,
+		Length: length,
+		Bytes:  hex.EncodeToString(l.data[l.offset : l.offset+length]),
+	})
+	l.offset += length
+	return nil
+}
+
+// addAll adds the given fields in order, stopping at the first error.
+func (l *layout) addAll(fields ...func() error) error {
+	for _, f := range fields {
+		if err := f(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *layout) fixed(path string, length int) func() error {
+	return func() error { return l.add(path, length) }
+}
+
+// messageLayout returns the fields of the given serialized message, which must have been deserialized into msg.
+func messageLayout(data []byte, msg *iota.Message) ([]field, error) {
+	l := &layout{data: data}
+	err := l.addAll(
+		l.fixed("version", iota.MessageVersionByteSize),
+		l.fixed("parent_1", iota.MessageHashLength),
+		l.fixed("parent_2", iota.MessageHashLength),
+		func() error { return l.payload("payload", msg.Payload) },
+		l.fixed("nonce", iota.UInt64ByteSize),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if l.offset != len(data) {
+		return nil, fmt.Errorf("fields cover %d bytes but the message has %d", l.offset, len(data))
+	}
+	return l.fields, nil
+}
+
+func (l *layout) payload(path string, payload iota.Serializable) error {
+	if err := l.add(path+"_length", iota.PayloadLengthByteSize); err != nil {
+		return err
+	}
+	switch p := payload.(type) {
+	case nil:
+		return nil
+	case *iota.IndexationPayload:
+		return l.addAll(
+			l.fixed(path+".type", iota.TypeDenotationByteSize),
+			l.fixed(path+".index_length", iota.UInt16ByteSize),
+			l.fixed(path+".index", len(p.Index)),
+			l.fixed(path+".data_length", iota.ByteArrayLengthByteSize),
+			l.fixed(path+".data", len(p.Data)),
+		)
+	case *iota.MilestonePayload:
+		return l.addAll(
+			l.fixed(path+".type", iota.TypeDenotationByteSize),
+			l.fixed(path+".index", iota.UInt64ByteSize),
+			l.fixed(path+".timestamp", iota.UInt64ByteSize),
+			l.fixed(path+".inclusion_merkle_proof", iota.MilestoneInclusionMerkleProofLength),
+			l.fixed(path+".signature", iota.MilestoneSignatureLength),
+		)
+	case *iota.SignedTransactionPayload:
+		if err := l.add(path+".type", iota.TypeDenotationByteSize); err != nil {
+			return err
+		}
+		if err := l.transaction(path+".transaction", p.Transaction); err != nil {
+			return err
+		}
+		if err := l.add(path+".unlock_blocks_count", iota.StructArrayLengthByteSize); err != nil {
+			return err
+		}
+		for i, block := range p.UnlockBlocks {
+			if err := l.unlockBlock(fmt.Sprintf("%s.unlock_blocks[%d]", path, i), block); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown payload type %T at %s", payload, path)
+	}
+}
+
+func (l *layout) transaction(path string, tx iota.Serializable) error {
+	unsignedTx, ok := tx.(*iota.UnsignedTransaction)
+	if !ok {
+		return fmt.Errorf("unknown transaction type %T at %s", tx, path)
+	}
+	if err := l.add(path+".type", iota.TypeDenotationByteSize); err != nil {
+		return err
+	}
+	if err := l.add(path+".inputs_count", iota.StructArrayLengthByteSize); err != nil {
+		return err
+	}
+	for i, input := range unsignedTx.Inputs {
+		if _, ok := input.(*iota.UTXOInput); !ok {
+			return fmt.Errorf("unknown input type %T at %s.inputs[%d]", input, path, i)
+		}
+		inputPath := fmt.Sprintf("%s.inputs[%d]", path, i)
+		if err := l.addAll(
+			l.fixed(inputPath+".type", iota.SmallTypeDenotationByteSize),
+			l.fixed(inputPath+".transaction_id", iota.TransactionIDLength),
+			l.fixed(inputPath+".transaction_output_index", iota.UInt16ByteSize),
+		); err != nil {
+			return err
+		}
+	}
+	if err := l.add(path+".outputs_count", iota.StructArrayLengthByteSize); err != nil {
+		return err
+	}
+	for i, output := range unsignedTx.Outputs {
+		deposit, ok := output.(*iota.SigLockedSingleDeposit)
+		if !ok {
+			return fmt.Errorf("unknown output type %T at %s.outputs[%d]", output, path, i)
+		}
+		outputPath := fmt.Sprintf("%s.outputs[%d]", path, i)
+		if err := l.addAll(
+			l.fixed(outputPath+".type", iota.SmallTypeDenotationByteSize),
+			func() error { return l.address(outputPath+".address", deposit.Address) },
+			l.fixed(outputPath+".amount", iota.UInt64ByteSize),
+		); err != nil {
+			return err
+		}
+	}
+	return l.payload(path+".payload", unsignedTx.Payload)
+}
+
+func (l *layout) address(path string, addr iota.Serializable) error {
+	var addrLength int
+	switch addr.(type) {
+	case *iota.WOTSAddress:
+		addrLength = iota.WOTSAddressBytesLength
+	case *iota.Ed25519Address:
+		addrLength = iota.Ed25519AddressBytesLength
+	default:
+		return fmt.Errorf("unknown address type %T at %s", addr, path)
+	}
+	return l.addAll(
+		l.fixed(path+".type", iota.SmallTypeDenotationByteSize),
+		l.fixed(path+".address", addrLength),
+	)
+}
+
+func (l *layout) unlockBlock(path string, block iota.Serializable) error {
+	switch b := block.(type) {
+	case *iota.SignatureUnlockBlock:
+		if _, ok := b.Signature.(*iota.Ed25519Signature); !ok {
+			return fmt.Errorf("unknown signature type %T at %s.signature", b.Signature, path)
+		}
+		return l.addAll(
+			l.fixed(path+".type", iota.SmallTypeDenotationByteSize),
+			l.fixed(path+".signature.type", iota.TypeDenotationByteSize),
+			l.fixed(path+".signature.public_key", ed25519.PublicKeySize),
+			l.fixed(path+".signature.signature", ed25519.SignatureSize),
+		)
+	case *iota.ReferenceUnlockBlock:
+		return l.addAll(
+			l.fixed(path+".type", iota.SmallTypeDenotationByteSize),
+			l.fixed(path+".reference", iota.UInt16ByteSize),
+		)
+	default:
+		return fmt.Errorf("unknown unlock block type %T at %s", block, path)
+	}
+}
@@ -0,0 +1,185 @@
+// Command iota is a toolbox to inspect, convert and validate the binary objects of the iota package.
+//
+// Usage:
+//
+//	iota <command> [flags] [args]
+//
+// Run 'iota help' for the list of commands and 'iota <command> -h' for the flags of a command.
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	// The exit code of successful runs.
+	exitOk = 0
+	// The exit code of failed runs or of runs which found the inspected object to be invalid.
+	exitFailure = 1
+	// The exit code of runs with invalid arguments.
+	exitUsage = 2
+)
+
+var (
+	// errUsage is returned by commands which were invoked with invalid arguments.
+	errUsage = errors.New("invalid usage")
+	// errInvalid is returned by commands which found the inspected object to be invalid, after reporting why.
+	errInvalid = errors.New("invalid object")
+)
+
+// env holds the streams a command runs with.
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// command is a subcommand of the CLI.
+type command struct {
+	// The name of the command.
+	name string
+	// The arguments of the command, shown in the usage.
+	args string
+	// A one line description of the command.
+	summary string
+	// Runs the command with the given arguments, which exclude the command's name.
+	run func(env *env, cmd *command, args []string) error
+}
+
+// commands holds the subcommands of the CLI by their name.
+var commands = map[string]*command{}
+
+func register(cmd *command) {
+	commands[cmd.name] = cmd
+}
+
+func main() {
+	os.Exit(run(&env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}, os.Args[1:]))
+}
+
+// run runs the command denoted by the given arguments and returns the exit code.
+func run(env *env, args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(env.stderr)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOk
+	}
+
+	cmd, has := commands[args[0]]
+	if !has {
+		fmt.Fprintf(env.stderr, "unknown command '%s'\n\n", args[0])
+		printUsage(env.stderr)
+		return exitUsage
+	}
+
+	err := cmd.run(env, cmd, args[1:])
+	switch {
+	case err == nil:
+		return exitOk
+	case errors.Is(err, flag.ErrHelp):
+		return exitOk
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(env.stderr, "%s\nusage: iota %s %s\n", err, cmd.name, cmd.args)
+		return exitUsage
+	case errors.Is(err, errInvalid):
+		return exitFailure
+	default:
+		fmt.Fprintf(env.stderr, "error: %s\n", err)
+		return exitFailure
+	}
+}
+
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "usage: iota <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-20s %s\n", name, commands[name].summary)
+	}
+}
+
+// newFlagSet creates a flag set for the given command whose errors are returned instead of exiting.
+func newFlagSet(env *env, cmd *command) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(env.stderr, "usage: iota %s %s\n\n%s\n", cmd.name, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses the given arguments into the given flag set and checks that at most maxArgs positional arguments remain.
+func parseFlags(fs *flag.FlagSet, args []string, maxArgs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if fs.NArg() > maxArgs {
+		return fmt.Errorf("%w: too many arguments", errUsage)
+	}
+	return nil
+}
+
+// readInput reads the input of a command: the given argument if not empty, otherwise the given file
+// or stdin if the file is empty or "-".
+func readInput(env *env, arg string, file string) ([]byte, error) {
+	if arg != "" {
+		return []byte(arg), nil
+	}
+	if file == "" || file == "-" {
+		return ioutil.ReadAll(env.stdin)
+	}
+	return ioutil.ReadFile(file)
+}
+
+// writeOutput writes the given data to the given file or stdout if the file is empty or "-".
+func writeOutput(env *env, file string, data []byte) error {
+	if file == "" || file == "-" {
+		_, err := env.stdout.Write(data)
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+// inputFormat is the detected format of an input.
+type inputFormat int
+
+const (
+	formatBinary inputFormat = iota
+	formatHex
+	formatJSON
+)
+
+// detectFormat detects whether the given input is JSON, hex or binary and returns the bytes of binary and hex inputs.
+// Hex inputs may be surrounded by whitespace and carry a 0x prefix.
+func detectFormat(input []byte) (inputFormat, []byte) {
+	trimmed := bytes.TrimSpace(input)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return formatJSON, trimmed
+	}
+	hexInput := strings.TrimPrefix(string(trimmed), "0x")
+	if data, err := hex.DecodeString(hexInput); err == nil && len(hexInput) > 0 {
+		return formatHex, data
+	}
+	return formatBinary, input
+}
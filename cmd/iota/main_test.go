@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runCmd runs the CLI with the given stdin and arguments and returns the exit code, stdout and stderr.
+func runCmd(stdin []byte, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(&env{stdin: bytes.NewReader(stdin), stdout: &stdout, stderr: &stderr}, args)
+	return code, stdout.String(), stderr.String()
+}
+
+func signedTransactionMessage(amount uint64) *iota.Message {
+	return &iota.Message{
+		Parent1: iota.MessageID{1},
+		Parent2: iota.MessageID{2},
+		Payload: &iota.SignedTransactionPayload{
+			Transaction: &iota.UnsignedTransaction{
+				Inputs:  iota.Serializables{&iota.UTXOInput{TransactionID: [iota.TransactionIDLength]byte{3}, TransactionOutputIndex: 1}},
+				Outputs: iota.Serializables{&iota.SigLockedSingleDeposit{Address: &iota.Ed25519Address{4}, Amount: amount}},
+				Payload: &iota.IndexationPayload{Index: "index", Data: []byte{5}},
+			},
+			UnlockBlocks: iota.Serializables{
+				&iota.SignatureUnlockBlock{Signature: &iota.Ed25519Signature{PublicKey: [32]byte{6}, Signature: [64]byte{7}}},
+			},
+		},
+		Nonce: 8,
+	}
+}
+
+func serialize(t *testing.T, msg *iota.Message) []byte {
+	data, err := msg.Serialize(iota.DeSeriModeNoValidation)
+	require.NoError(t, err)
+	return data
+}
+
+func TestDecode(t *testing.T) {
+	msg := signedTransactionMessage(100)
+	data := serialize(t, msg)
+	id, err := msg.ID()
+	require.NoError(t, err)
+
+	for name, input := range map[string][]string{
+		"hex argument": {"decode", hex.EncodeToString(data)},
+		"binary stdin": {"decode"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			code, stdout, stderr := runCmd(data, input...)
+			require.Equal(t, exitOk, code, stderr)
+			assert.Empty(t, stderr)
+
+			decoded := &struct {
+				MessageID string          `json:"message_id"`
+				Size      int             `json:"size"`
+				Message   json.RawMessage `json:"message"`
+				Fields    []field         `json:"fields"`
+			}{}
+			require.NoError(t, json.Unmarshal([]byte(stdout), decoded))
+			assert.Equal(t, hex.EncodeToString(id[:]), decoded.MessageID)
+			assert.Equal(t, len(data), decoded.Size)
+			decodedMsg := &iota.Message{}
+			require.NoError(t, json.Unmarshal(decoded.Message, decodedMsg))
+			assert.Equal(t, msg, decodedMsg)
+
+			// the fields are contiguous and cover the entire message
+			var offset int
+			fieldsByPath := make(map[string]field)
+			for _, f := range decoded.Fields {
+				assert.Equal(t, offset, f.Offset, f.Path)
+				assert.Equal(t, hex.EncodeToString(data[f.Offset:f.Offset+f.Length]), f.Bytes, f.Path)
+				offset += f.Length
+				fieldsByPath[f.Path] = f
+			}
+			assert.Equal(t, len(data), offset)
+
+			txID := fieldsByPath["payload.transaction.inputs[0].transaction_id"]
+			assert.Equal(t, 1+32+32+4+4+4+2+1, txID.Offset)
+			assert.Equal(t, hex.EncodeToString(msg.Payload.(*iota.SignedTransactionPayload).Transaction.(*iota.UnsignedTransaction).Inputs[0].(*iota.UTXOInput).TransactionID[:]), txID.Bytes)
+			assert.Equal(t, "696e646578", fieldsByPath["payload.transaction.payload.index"].Bytes)
+			assert.Equal(t, 64, fieldsByPath["payload.unlock_blocks[0].signature.signature"].Length)
+			assert.Equal(t, "nonce", decoded.Fields[len(decoded.Fields)-1].Path)
+		})
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	// invalid messages are decoded with a warning
+	data := serialize(t, signedTransactionMessage(0))
+	code, stdout, stderr := runCmd(nil, "decode", hex.EncodeToString(data))
+	assert.Equal(t, exitOk, code)
+	assert.NotEmpty(t, stdout)
+	assert.Contains(t, stderr, "warning: message is invalid")
+
+	// truncated messages can't be decoded
+	code, stdout, stderr = runCmd(nil, "decode", hex.EncodeToString(data[:len(data)-20]))
+	assert.Equal(t, exitFailure, code)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "error:")
+
+	code, _, _ = runCmd([]byte(`{"version": 1}`), "decode")
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestEncode(t *testing.T) {
+	msg := signedTransactionMessage(100)
+	data := serialize(t, msg)
+	msgJSON, err := json.Marshal(msg)
+	require.NoError(t, err)
+
+	code, stdout, stderr := runCmd(msgJSON, "encode")
+	require.Equal(t, exitOk, code, stderr)
+	assert.Equal(t, data, []byte(stdout))
+
+	code, stdout, _ = runCmd(msgJSON, "encode", "-hex")
+	require.Equal(t, exitOk, code)
+	assert.Equal(t, hex.EncodeToString(data)+"\n", stdout)
+
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "msg.json"), filepath.Join(dir, "msg.bin")
+	require.NoError(t, ioutil.WriteFile(in, msgJSON, 0644))
+	code, _, _ = runCmd(nil, "encode", "-in", in, "-out", out)
+	require.Equal(t, exitOk, code)
+	written, err := ioutil.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, data, written)
+
+	invalidJSON, err := json.Marshal(signedTransactionMessage(0))
+	require.NoError(t, err)
+	code, stdout, stderr = runCmd(invalidJSON, "encode")
+	assert.Equal(t, exitFailure, code)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, iota.ErrDepositAmountMustBeGreaterThanZero.Error())
+}
+
+func TestID(t *testing.T) {
+	msg := signedTransactionMessage(100)
+	id, err := msg.ID()
+	require.NoError(t, err)
+	msgJSON, err := json.Marshal(msg)
+	require.NoError(t, err)
+
+	for _, input := range [][]byte{serialize(t, msg), []byte("0x" + hex.EncodeToString(serialize(t, msg)) + "\n"), msgJSON} {
+		code, stdout, stderr := runCmd(input, "id")
+		require.Equal(t, exitOk, code, stderr)
+		assert.Equal(t, hex.EncodeToString(id[:])+"\n", stdout)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := serialize(t, signedTransactionMessage(100))
+	withPoW := signedTransactionMessage(100)
+	withPoW.Nonce = 0
+	require.NoError(t, iota.DoPoW(context.Background(), withPoW, 8, 1))
+
+	unordered := signedTransactionMessage(100)
+	unsignedTx := unordered.Payload.(*iota.SignedTransactionPayload).Transaction.(*iota.UnsignedTransaction)
+	unsignedTx.Inputs = append(unsignedTx.Inputs, &iota.UTXOInput{TransactionID: [iota.TransactionIDLength]byte{1}})
+	unordered.Payload.(*iota.SignedTransactionPayload).UnlockBlocks = append(unordered.Payload.(*iota.SignedTransactionPayload).UnlockBlocks, &iota.ReferenceUnlockBlock{Reference: 0})
+
+	unknownPayload := append([]byte(nil), valid...)
+	unknownPayload[1+32+32+4] = 9
+
+	tests := []struct {
+		name     string
+		args     []string
+		input    []byte
+		code     int
+		expected string
+	}{
+		{"valid", nil, valid, exitOk, "valid: "},
+		{"valid with PoW", []string{"-pow", "8"}, serialize(t, withPoW), exitOk, "valid: "},
+		{"insufficient PoW", []string{"-pow", "200"}, valid, exitFailure, "invalid: violates ErrInsufficientPoW"},
+		{"zero deposit", nil, serialize(t, signedTransactionMessage(0)), exitFailure, "invalid: violates ErrDepositAmountMustBeGreaterThanZero"},
+		{"inputs order", nil, serialize(t, unordered), exitFailure, "invalid: violates ErrInputsOrderViolatesLexicalOrder"},
+		{"unknown payload", nil, unknownPayload, exitFailure, "invalid: violates ErrUnknownPayloadType"},
+		{"truncated", nil, valid[:40], exitFailure, "invalid: violates ErrDeserializationNotEnoughData"},
+		{"trailing bytes", nil, append(append([]byte(nil), valid...), 0), exitFailure, "invalid: violates ErrDeserializationNotAllConsumed"},
+		{"invalid JSON", nil, []byte(`{"version": 2}`), exitFailure, "invalid: violates ErrDeserializationTypeMismatch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCmd(tt.input, append([]string{"validate"}, tt.args...)...)
+			assert.Equal(t, tt.code, code, stderr)
+			assert.True(t, strings.HasPrefix(stdout, tt.expected), stdout)
+		})
+	}
+}
+
+func TestUsage(t *testing.T) {
+	code, _, stderr := runCmd(nil)
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "decode")
+
+	code, _, _ = runCmd(nil, "help")
+	assert.Equal(t, exitOk, code)
+
+	code, _, stderr = runCmd(nil, "unknown")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "unknown command")
+
+	code, _, stderr = runCmd(nil, "id", "a", "b")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "usage: iota id")
+
+	code, _, _ = runCmd(nil, "decode", "-unknown")
+	assert.Equal(t, exitUsage, code)
+
+	code, _, _ = runCmd(nil, "decode", "-h")
+	assert.Equal(t, exitOk, code)
+}
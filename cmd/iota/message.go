@@ -0,0 +1,277 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/luca-moser/iota"
+)
+
+func init() {
+	register(decodeCmd)
+	register(encodeCmd)
+	register(idCmd)
+	register(validateCmd)
+}
+
+var decodeCmd = &command{
+	name:    "decode",
+	args:    "[-in file] [hex]",
+	summary: "decodes a hex or binary message into JSON showing the byte offsets of its fields",
+	run:     runDecode,
+}
+
+var encodeCmd = &command{
+	name:    "encode",
+	args:    "[-in file] [-out file] [-hex]",
+	summary: "encodes a JSON message into its validated binary form",
+	run:     runEncode,
+}
+
+var idCmd = &command{
+	name:    "id",
+	args:    "[-in file] [hex]",
+	summary: "computes the ID of a hex, binary or JSON message",
+	run:     runID,
+}
+
+var validateCmd = &command{
+	name:    "validate",
+	args:    "[-in file] [-pow bits] [hex]",
+	summary: "validates a hex, binary or JSON message and reports the rule it violates",
+	run:     runValidate,
+}
+
+// rule is a validation rule denoted by the error the iota package returns when it is violated.
+type rule struct {
+	name string
+	err  error
+}
+
+// rules holds the validation rules of messages. Specific rules come before generic ones.
+var rules = []rule{
+	{"ErrInsufficientPoW", iota.ErrInsufficientPoW},
+	{"ErrMinInputsNotReached", iota.ErrMinInputsNotReached},
+	{"ErrMaxInputsExceeded", iota.ErrMaxInputsExceeded},
+	{"ErrMinOutputsNotReached", iota.ErrMinOutputsNotReached},
+	{"ErrMaxOutputsExceeded", iota.ErrMaxOutputsExceeded},
+	{"ErrUnlockBlocksMustMatchInputCount", iota.ErrUnlockBlocksMustMatchInputCount},
+	{"ErrSigUnlockBlocksNotUnique", iota.ErrSigUnlockBlocksNotUnique},
+	{"ErrRefUnlockBlockInvalidRef", iota.ErrRefUnlockBlockInvalidRef},
+	{"ErrRefUTXOIndexInvalid", iota.ErrRefUTXOIndexInvalid},
+	{"ErrInputsOrderViolatesLexicalOrder", iota.ErrInputsOrderViolatesLexicalOrder},
+	{"ErrOutputsOrderViolatesLexicalOrder", iota.ErrOutputsOrderViolatesLexicalOrder},
+	{"ErrInputUTXORefsNotUnique", iota.ErrInputUTXORefsNotUnique},
+	{"ErrOutputAddrNotUnique", iota.ErrOutputAddrNotUnique},
+	{"ErrOutputsSumExceedsTotalSupply", iota.ErrOutputsSumExceedsTotalSupply},
+	{"ErrOutputDepositsMoreThanTotalSupply", iota.ErrOutputDepositsMoreThanTotalSupply},
+	{"ErrDepositAmountMustBeGreaterThanZero", iota.ErrDepositAmountMustBeGreaterThanZero},
+	{"ErrUnknownPayloadType", iota.ErrUnknownPayloadType},
+	{"ErrUnknownAddrType", iota.ErrUnknownAddrType},
+	{"ErrUnknownInputType", iota.ErrUnknownInputType},
+	{"ErrUnknownOutputType", iota.ErrUnknownOutputType},
+	{"ErrUnknownTransactionType", iota.ErrUnknownTransactionType},
+	{"ErrUnknownUnlockBlockType", iota.ErrUnknownUnlockBlockType},
+	{"ErrUnknownSignatureType", iota.ErrUnknownSignatureType},
+	{"ErrDeserializationTypeMismatch", iota.ErrDeserializationTypeMismatch},
+	{"ErrDeserializationNotEnoughData", iota.ErrDeserializationNotEnoughData},
+	{"ErrDeserializationNotAllConsumed", iota.ErrDeserializationNotAllConsumed},
+	{"ErrInvalidJSON", iota.ErrInvalidJSON},
+	{"ErrInvalidBytes", iota.ErrInvalidBytes},
+}
+
+// violatedRule returns the rule the given error denotes.
+func violatedRule(err error) (rule, bool) {
+	for _, r := range rules {
+		if errors.Is(err, r.err) {
+			return r, true
+		}
+	}
+	return rule{}, false
+}
+
+// deserializeMessage deserializes the given data into a message. Malformed data makes the deserialization
+// without validation panic, which is returned as an error.
+func deserializeMessage(data []byte, deSeriMode iota.DeSerializationMode) (msg *iota.Message, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg, err = nil, fmt.Errorf("%w: malformed message: %v", iota.ErrDeserializationNotEnoughData, r)
+		}
+	}()
+	msg = &iota.Message{}
+	bytesRead, err := msg.Deserialize(data, deSeriMode)
+	if err != nil {
+		return nil, err
+	}
+	if bytesRead != len(data) {
+		return nil, fmt.Errorf("%w: message is %d bytes but the data %d", iota.ErrDeserializationNotAllConsumed, bytesRead, len(data))
+	}
+	return msg, nil
+}
+
+// parseMessage parses a message from the given hex, binary or JSON input, validating it if requested.
+// Messages given as JSON are validated by serializing them and deserializing the result.
+func parseMessage(input []byte, validate bool) (*iota.Message, error) {
+	deSeriMode := iota.DeSeriModeNoValidation
+	if validate {
+		deSeriMode = iota.DeSeriModePerformValidation
+	}
+
+	format, data := detectFormat(input)
+	if format != formatJSON {
+		return deserializeMessage(data, deSeriMode)
+	}
+
+	msg := &iota.Message{}
+	if err := json.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	if !validate {
+		return msg, nil
+	}
+	msgData, err := msg.Serialize(deSeriMode)
+	if err != nil {
+		return nil, err
+	}
+	return deserializeMessage(msgData, deSeriMode)
+}
+
+// decodedMessage is the output of the decode command.
+type decodedMessage struct {
+	MessageID string        `json:"message_id"`
+	Size      int           `json:"size"`
+	Message   *iota.Message `json:"message"`
+	Fields    []field       `json:"fields"`
+}
+
+func runDecode(env *env, cmd *command, args []string) error {
+	fs := newFlagSet(env, cmd)
+	in := fs.String("in", "", "the file to read the message from, stdin if omitted")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	input, err := readInput(env, fs.Arg(0), *in)
+	if err != nil {
+		return err
+	}
+
+	format, data := detectFormat(input)
+	if format == formatJSON {
+		return fmt.Errorf("%w: decode takes hex or binary messages, use encode for JSON", errUsage)
+	}
+	// decoded without validation, so that invalid messages can be inspected too
+	msg, err := deserializeMessage(data, iota.DeSeriModeNoValidation)
+	if err != nil {
+		return err
+	}
+	if _, err := deserializeMessage(data, iota.DeSeriModePerformValidation); err != nil {
+		fmt.Fprintf(env.stderr, "warning: message is invalid: %s\n", err)
+	}
+	fields, err := messageLayout(data, msg)
+	if err != nil {
+		return fmt.Errorf("unable to lay out message fields: %w", err)
+	}
+	id, err := msg.ID()
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(&decodedMessage{
+		MessageID: hex.EncodeToString(id[:]),
+		Size:      len(data),
+		Message:   msg,
+		Fields:    fields,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutput(env, "", append(out, '\n'))
+}
+
+func runEncode(env *env, cmd *command, args []string) error {
+	fs := newFlagSet(env, cmd)
+	in := fs.String("in", "", "the file to read the JSON message from, stdin if omitted")
+	out := fs.String("out", "", "the file to write the binary message to, stdout if omitted")
+	hexOut := fs.Bool("hex", false, "whether to write the message hex encoded")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	input, err := readInput(env, "", *in)
+	if err != nil {
+		return err
+	}
+	if format, _ := detectFormat(input); format != formatJSON {
+		return fmt.Errorf("%w: encode takes JSON messages", errUsage)
+	}
+
+	msg, err := parseMessage(input, true)
+	if err != nil {
+		return fmt.Errorf("invalid message: %w", err)
+	}
+	data, err := msg.Serialize(iota.DeSeriModePerformValidation)
+	if err != nil {
+		return err
+	}
+	if *hexOut {
+		data = []byte(hex.EncodeToString(data) + "\n")
+	}
+	return writeOutput(env, *out, data)
+}
+
+func runID(env *env, cmd *command, args []string) error {
+	fs := newFlagSet(env, cmd)
+	in := fs.String("in", "", "the file to read the message from, stdin if omitted")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	input, err := readInput(env, fs.Arg(0), *in)
+	if err != nil {
+		return err
+	}
+	msg, err := parseMessage(input, false)
+	if err != nil {
+		return err
+	}
+	id, err := msg.ID()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(env.stdout, hex.EncodeToString(id[:]))
+	return err
+}
+
+func runValidate(env *env, cmd *command, args []string) error {
+	fs := newFlagSet(env, cmd)
+	in := fs.String("in", "", "the file to read the message from, stdin if omitted")
+	powBits := fs.Int("pow", 0, "the amount of leading zero bits the message ID must have")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	input, err := readInput(env, fs.Arg(0), *in)
+	if err != nil {
+		return err
+	}
+
+	msg, err := parseMessage(input, true)
+	if err == nil && *powBits > 0 {
+		err = iota.CheckPoW(msg, *powBits)
+	}
+	if err != nil {
+		if r, ok := violatedRule(err); ok {
+			fmt.Fprintf(env.stdout, "invalid: violates %s (%s)\n", r.name, r.err)
+		} else {
+			fmt.Fprintln(env.stdout, "invalid")
+		}
+		fmt.Fprintf(env.stdout, "error: %s\n", err)
+		return errInvalid
+	}
+
+	id, err := msg.ID()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(env.stdout, "valid: %s\n", hex.EncodeToString(id[:]))
+	return err
+}
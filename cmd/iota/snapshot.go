@@ -0,0 +1,477 @@
+package main
+
+import (
+	"bufio"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/luca-moser/iota"
+)
+
+func init() {
+	register(snapshotInfoCmd)
+	register(snapshotVerifyCmd)
+	register(snapshotDumpCmd)
+	register(snapshotConvertCmd)
+}
+
+var snapshotInfoCmd = &command{
+	name:    "snapshot-info",
+	args:    "[-compressed] <file>",
+	summary: "prints the header of a local snapshot and counts its solid entry points and outputs",
+	run:     runSnapshotInfo,
+}
+
+var snapshotVerifyCmd = &command{
+	name:    "snapshot-verify",
+	args:    "[-compressed] <file>",
+	summary: "verifies that the outputs of a local snapshot hold the token supply and are unique",
+	run:     runSnapshotVerify,
+}
+
+var snapshotDumpCmd = &command{
+	name:    "snapshot-dump",
+	args:    "[-compressed] [-format json|csv] [-out file] <file>",
+	summary: "dumps the unspent outputs of a local snapshot as JSON or CSV",
+	run:     runSnapshotDump,
+}
+
+var snapshotConvertCmd = &command{
+	name:    "snapshot-convert",
+	args:    "[-compressed] [-compress] [-level n] <in> <out>",
+	summary: "re-encodes a local snapshot with or without compression",
+	run:     runSnapshotConvert,
+}
+
+const (
+	snapshotDumpFormatJSON = "json"
+	snapshotDumpFormatCSV  = "csv"
+	// The amount of invalid outputs the verify command lists.
+	maxReportedDuplicates = 10
+)
+
+// snapshotFlags holds the flags shared by the snapshot commands.
+type snapshotFlags struct {
+	compressed *bool
+}
+
+func addSnapshotFlags(fs *flag.FlagSet) *snapshotFlags {
+	return &snapshotFlags{compressed: fs.Bool("compressed", false, "whether the snapshot's solid entry points and outputs are zlib compressed")}
+}
+
+// compressionReaderInit returns the iota.CompressionReaderInitFunc matching the flags.
+func (f *snapshotFlags) compressionReaderInit() iota.CompressionReaderInitFunc {
+	if *f.compressed {
+		return func(reader io.Reader) (io.Reader, error) {
+			return zlib.NewReader(reader)
+		}
+	}
+	return func(reader io.Reader) (io.Reader, error) {
+		return reader, nil
+	}
+}
+
+// readSnapshot streams the local snapshot in the given file into the given consumers, nil consumers are skipped.
+func readSnapshot(file string, flags *snapshotFlags, headerConsumer iota.LSHeaderConsumerFunc,
+	sepConsumer iota.LSSEPConsumerFunc, utxoConsumer iota.LSUTXOConsumerFunc) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if headerConsumer == nil {
+		headerConsumer = func(*iota.LSFileHeader) error { return nil }
+	}
+	if sepConsumer == nil {
+		sepConsumer = func([iota.SolidEntryPointHashLength]byte) error { return nil }
+	}
+	if utxoConsumer == nil {
+		utxoConsumer = func(*iota.LSTransactionUnspentOutputs) error { return nil }
+	}
+	if err := iota.StreamLocalSnapshotDataFrom(bufio.NewReader(f), flags.compressionReaderInit(), headerConsumer, sepConsumer, utxoConsumer); err != nil {
+		return fmt.Errorf("unable to read local snapshot %s: %w", file, err)
+	}
+	return nil
+}
+
+// parseFileArgs parses the given arguments and checks that exactly the given amount of file arguments remain.
+func parseFileArgs(fs *flag.FlagSet, args []string, count int) error {
+	if err := parseFlags(fs, args, count); err != nil {
+		return err
+	}
+	if fs.NArg() != count {
+		return fmt.Errorf("%w: expected %d file argument(s)", errUsage, count)
+	}
+	return nil
+}
+
+// snapshotInfo is the output of the snapshot-info command.
+type snapshotInfo struct {
+	Version          byte   `json:"version"`
+	MilestoneIndex   uint64 `json:"milestone_index"`
+	MilestoneHash    string `json:"milestone_hash"`
+	Timestamp        uint64 `json:"timestamp"`
+	Time             string `json:"time"`
+	SolidEntryPoints uint64 `json:"solid_entry_points"`
+	Transactions     uint64 `json:"transactions"`
+	Outputs          uint64 `json:"outputs"`
+}
+
+func runSnapshotInfo(env *env, cmd *command, args []string) error {
+	fs := newFlagSet(env, cmd)
+	flags := addSnapshotFlags(fs)
+	if err := parseFileArgs(fs, args, 1); err != nil {
+		return err
+	}
+
+	info := &snapshotInfo{}
+	err := readSnapshot(fs.Arg(0), flags,
+		func(header *iota.LSFileHeader) error {
+			info.Version = header.Version
+			info.MilestoneIndex = header.MilestoneIndex
+			info.MilestoneHash = hex.EncodeToString(header.MilestoneHash[:])
+			info.Timestamp = header.Timestamp
+			info.Time = time.Unix(int64(header.Timestamp), 0).UTC().Format(time.RFC3339)
+			return nil
+		},
+		func([iota.SolidEntryPointHashLength]byte) error {
+			info.SolidEntryPoints++
+			return nil
+		},
+		func(utxo *iota.LSTransactionUnspentOutputs) error {
+			info.Transactions++
+			info.Outputs += uint64(len(utxo.UnspentOutputs))
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutput(env, "", append(out, '\n'))
+}
+
+// outputKey identifies an output of a local snapshot by its transaction hash and index.
+type outputKey [iota.TransactionIDLength + iota.UInt16ByteSize]byte
+
+func runSnapshotVerify(env *env, cmd *command, args []string) error {
+	fs := newFlagSet(env, cmd)
+	flags := addSnapshotFlags(fs)
+	if err := parseFileArgs(fs, args, 1); err != nil {
+		return err
+	}
+
+	var seps, outputs, balance uint64
+	var overflow bool
+	seen := make(map[outputKey]int)
+	var duplicates []outputKey
+	err := readSnapshot(fs.Arg(0), flags, nil,
+		func([iota.SolidEntryPointHashLength]byte) error {
+			seps++
+			return nil
+		},
+		func(utxo *iota.LSTransactionUnspentOutputs) error {
+			for _, output := range utxo.UnspentOutputs {
+				outputs++
+				if balance+output.Value < balance {
+					overflow = true
+				}
+				balance += output.Value
+
+				var key outputKey
+				copy(key[:], utxo.TransactionHash[:])
+				binary.LittleEndian.PutUint16(key[iota.TransactionIDLength:], output.Index)
+				seen[key]++
+				if seen[key] == 2 {
+					duplicates = append(duplicates, key)
+				}
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.stdout, "solid entry points: %d\n", seps)
+	fmt.Fprintf(env.stdout, "outputs: %d\n", outputs)
+	fmt.Fprintf(env.stdout, "balance: %d\n", balance)
+
+	var invalid bool
+	switch {
+	case overflow:
+		invalid = true
+		fmt.Fprintf(env.stdout, "invalid: the balance overflows, the token supply is %d\n", uint64(iota.TokenSupply))
+	case balance != iota.TokenSupply:
+		invalid = true
+		fmt.Fprintf(env.stdout, "invalid: the balance differs from the token supply of %d\n", uint64(iota.TokenSupply))
+	}
+	for i, key := range duplicates {
+		invalid = true
+		if i == maxReportedDuplicates {
+			fmt.Fprintf(env.stdout, "invalid: %d more outputs are contained multiple times\n", len(duplicates)-maxReportedDuplicates)
+			break
+		}
+		index := binary.LittleEndian.Uint16(key[iota.TransactionIDLength:])
+		fmt.Fprintf(env.stdout, "invalid: output %d of transaction %s is contained %d times\n",
+			index, hex.EncodeToString(key[:iota.TransactionIDLength]), seen[key])
+	}
+	if invalid {
+		return errInvalid
+	}
+	_, err = fmt.Fprintln(env.stdout, "ok")
+	return err
+}
+
+// snapshotOutput is the JSON form of an output dumped by the snapshot-dump command.
+type snapshotOutput struct {
+	TransactionHash string         `json:"transaction_hash"`
+	Index           uint16         `json:"index"`
+	Address         json.Marshaler `json:"address"`
+	Value           uint64         `json:"value"`
+}
+
+// addressTypeAndHex returns the type and the hex encoded bytes without the type of the given address.
+func addressTypeAndHex(addr iota.Serializable) (byte, string, error) {
+	data, err := addr.Serialize(iota.DeSeriModeNoValidation)
+	if err != nil {
+		return 0, "", err
+	}
+	return data[0], hex.EncodeToString(data[iota.SmallTypeDenotationByteSize:]), nil
+}
+
+func runSnapshotDump(env *env, cmd *command, args []string) error {
+	fs := newFlagSet(env, cmd)
+	flags := addSnapshotFlags(fs)
+	format := fs.String("format", snapshotDumpFormatJSON, "the format of the dump, json or csv")
+	out := fs.String("out", "", "the file to write the dump to, stdout if omitted")
+	if err := parseFileArgs(fs, args, 1); err != nil {
+		return err
+	}
+	if *format != snapshotDumpFormatJSON && *format != snapshotDumpFormatCSV {
+		return fmt.Errorf("%w: unknown format '%s'", errUsage, *format)
+	}
+
+	var w io.Writer = env.stdout
+	if *out != "" && *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+
+	var dumpOutput func(txHash string, output *iota.LSUnspentOutput) error
+	var finish func() error
+	switch *format {
+	case snapshotDumpFormatJSON:
+		// the outputs are streamed as a JSON array with one output per line
+		first := true
+		if _, err := bw.WriteString("["); err != nil {
+			return err
+		}
+		dumpOutput = func(txHash string, output *iota.LSUnspentOutput) error {
+			addr, ok := output.Address.(json.Marshaler)
+			if !ok {
+				return fmt.Errorf("%w: %T", iota.ErrUnknownAddrType, output.Address)
+			}
+			data, err := json.Marshal(&snapshotOutput{TransactionHash: txHash, Index: output.Index, Address: addr, Value: output.Value})
+			if err != nil {
+				return err
+			}
+			sep := ",\n  "
+			if first {
+				sep, first = "\n  ", false
+			}
+			if _, err := bw.WriteString(sep); err != nil {
+				return err
+			}
+			_, err = bw.Write(data)
+			return err
+		}
+		finish = func() error {
+			if !first {
+				if _, err := bw.WriteString("\n"); err != nil {
+					return err
+				}
+			}
+			_, err := bw.WriteString("]\n")
+			return err
+		}
+	case snapshotDumpFormatCSV:
+		csvWriter := csv.NewWriter(bw)
+		if err := csvWriter.Write([]string{"transaction_hash", "index", "address_type", "address", "value"}); err != nil {
+			return err
+		}
+		dumpOutput = func(txHash string, output *iota.LSUnspentOutput) error {
+			addrType, addrHex, err := addressTypeAndHex(output.Address)
+			if err != nil {
+				return err
+			}
+			return csvWriter.Write([]string{
+				txHash,
+				strconv.FormatUint(uint64(output.Index), 10),
+				strconv.FormatUint(uint64(addrType), 10),
+				addrHex,
+				strconv.FormatUint(output.Value, 10),
+			})
+		}
+		finish = func() error {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	}
+
+	err := readSnapshot(fs.Arg(0), flags, nil, nil, func(utxo *iota.LSTransactionUnspentOutputs) error {
+		txHash := hex.EncodeToString(utxo.TransactionHash[:])
+		for _, output := range utxo.UnspentOutputs {
+			if err := dumpOutput(txHash, output); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := finish(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+var errConversionAborted = errors.New("conversion aborted")
+
+func runSnapshotConvert(env *env, cmd *command, args []string) error {
+	fs := newFlagSet(env, cmd)
+	flags := addSnapshotFlags(fs)
+	compress := fs.Bool("compress", false, "whether to zlib compress the solid entry points and outputs of the written snapshot")
+	level := fs.Int("level", zlib.BestCompression, "the zlib compression level")
+	if err := parseFileArgs(fs, args, 2); err != nil {
+		return err
+	}
+	in, out := fs.Arg(0), fs.Arg(1)
+	if in == out {
+		return fmt.Errorf("%w: the snapshot can't be converted in place", errUsage)
+	}
+
+	outFile, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if err := convertSnapshot(in, flags, outFile, *compress, *level); err != nil {
+		_ = outFile.Close()
+		_ = os.Remove(out)
+		return err
+	}
+	return outFile.Close()
+}
+
+// convertSnapshot streams the local snapshot in the given file into the given writer. The reader pushes
+// the snapshot's elements into consumers while the writer pulls them from iterators, so both run
+// concurrently, connected by channels.
+func convertSnapshot(in string, flags *snapshotFlags, out io.WriteSeeker, compress bool, level int) error {
+	headerC := make(chan *iota.LSFileHeader, 1)
+	sepC := make(chan [iota.SolidEntryPointHashLength]byte, 1024)
+	utxoC := make(chan *iota.LSTransactionUnspentOutputs, 1024)
+	readErrC := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		sepsClosed := false
+		closeSEPs := func() {
+			if !sepsClosed {
+				sepsClosed = true
+				close(sepC)
+			}
+		}
+		err := readSnapshot(in, flags,
+			func(header *iota.LSFileHeader) error {
+				headerC <- header
+				return nil
+			},
+			func(sep [iota.SolidEntryPointHashLength]byte) error {
+				select {
+				case sepC <- sep:
+					return nil
+				case <-done:
+					return errConversionAborted
+				}
+			},
+			func(utxo *iota.LSTransactionUnspentOutputs) error {
+				closeSEPs()
+				select {
+				case utxoC <- utxo:
+					return nil
+				case <-done:
+					return errConversionAborted
+				}
+			},
+		)
+		closeSEPs()
+		close(utxoC)
+		readErrC <- err
+	}()
+
+	var header *iota.LSFileHeader
+	select {
+	case header = <-headerC:
+	case err := <-readErrC:
+		if err == nil {
+			err = fmt.Errorf("%w: local snapshot has no header", iota.ErrDeserializationNotEnoughData)
+		}
+		return err
+	}
+
+	var compWriter iota.WriteFlusher
+	if compress {
+		zlibWriter, err := zlib.NewWriterLevel(out, level)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errUsage, err)
+		}
+		// the zlib stream must be terminated, which Flush alone doesn't do
+		compWriter = &closingFlusher{zlibWriter}
+	}
+
+	sepIter := func() *[iota.SolidEntryPointHashLength]byte {
+		sep, ok := <-sepC
+		if !ok {
+			return nil
+		}
+		return &sep
+	}
+	utxoIter := func() *iota.LSTransactionUnspentOutputs {
+		return <-utxoC
+	}
+	if err := iota.StreamLocalSnapshotDataTo(out, compWriter, header, sepIter, utxoIter); err != nil {
+		return err
+	}
+	return <-readErrC
+}
+
+// closingFlusher is a zlib writer whose Flush terminates the stream.
+type closingFlusher struct {
+	*zlib.Writer
+}
+
+func (c *closingFlusher) Flush() error {
+	return c.Writer.Close()
+}
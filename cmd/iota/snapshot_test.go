@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSnapshotHeader = &iota.LSFileHeader{
+	Version:        iota.LSFormatVersion,
+	MilestoneIndex: 1337,
+	MilestoneHash:  [iota.MilestoneHashLength]byte{1},
+	Timestamp:      1600000000,
+}
+
+func testSnapshotUTXOs() []*iota.LSTransactionUnspentOutputs {
+	return []*iota.LSTransactionUnspentOutputs{
+		{TransactionHash: [32]byte{1}, UnspentOutputs: []*iota.LSUnspentOutput{
+			{Index: 0, Address: &iota.Ed25519Address{1}, Value: iota.TokenSupply - 300},
+			{Index: 1, Address: &iota.Ed25519Address{2}, Value: 100},
+		}},
+		{TransactionHash: [32]byte{2}, UnspentOutputs: []*iota.LSUnspentOutput{
+			{Index: 3, Address: &iota.WOTSAddress{3}, Value: 200},
+		}},
+	}
+}
+
+// writeTestSnapshot writes a local snapshot with the given outputs and two solid entry points to a temporary file.
+func writeTestSnapshot(t *testing.T, utxos []*iota.LSTransactionUnspentOutputs, compress bool) string {
+	path := filepath.Join(t.TempDir(), "snapshot.bin")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var compWriter iota.WriteFlusher
+	if compress {
+		zlibWriter := zlib.NewWriter(f)
+		compWriter = &closingFlusher{zlibWriter}
+	}
+	seps := [][iota.SolidEntryPointHashLength]byte{{1}, {2}}
+	require.NoError(t, iota.StreamLocalSnapshotDataTo(f, compWriter, testSnapshotHeader,
+		func() *[iota.SolidEntryPointHashLength]byte {
+			if len(seps) == 0 {
+				return nil
+			}
+			sep := seps[0]
+			seps = seps[1:]
+			return &sep
+		},
+		func() *iota.LSTransactionUnspentOutputs {
+			if len(utxos) == 0 {
+				return nil
+			}
+			utxo := utxos[0]
+			utxos = utxos[1:]
+			return utxo
+		},
+	))
+	return path
+}
+
+func TestSnapshotInfo(t *testing.T) {
+	for _, compressed := range []bool{false, true} {
+		path := writeTestSnapshot(t, testSnapshotUTXOs(), compressed)
+		args := []string{"snapshot-info", path}
+		if compressed {
+			args = []string{"snapshot-info", "-compressed", path}
+		}
+		code, stdout, stderr := runCmd(nil, args...)
+		require.Equal(t, exitOk, code, stderr)
+		assert.JSONEq(t, `{
+			"version": 1,
+			"milestone_index": 1337,
+			"milestone_hash": "0100000000000000000000000000000000000000000000000000000000000000",
+			"timestamp": 1600000000,
+			"time": "2020-09-13T12:26:40Z",
+			"solid_entry_points": 2,
+			"transactions": 2,
+			"outputs": 3
+		}`, stdout)
+	}
+
+	code, _, _ := runCmd(nil, "snapshot-info")
+	assert.Equal(t, exitUsage, code)
+	code, _, stderr := runCmd(nil, "snapshot-info", filepath.Join(t.TempDir(), "missing.bin"))
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, stderr, "error:")
+}
+
+func TestSnapshotVerify(t *testing.T) {
+	code, stdout, stderr := runCmd(nil, "snapshot-verify", writeTestSnapshot(t, testSnapshotUTXOs(), false))
+	require.Equal(t, exitOk, code, stderr)
+	assert.Equal(t, "solid entry points: 2\noutputs: 3\nbalance: 2779530283277761\nok\n", stdout)
+
+	utxos := testSnapshotUTXOs()
+	utxos[1].UnspentOutputs = append(utxos[1].UnspentOutputs, &iota.LSUnspentOutput{Index: 3, Address: &iota.Ed25519Address{4}, Value: 1})
+	code, stdout, _ = runCmd(nil, "snapshot-verify", writeTestSnapshot(t, utxos, false))
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, stdout, "invalid: the balance differs from the token supply")
+	assert.Contains(t, stdout, "invalid: output 3 of transaction 0200000000000000000000000000000000000000000000000000000000000000 is contained 2 times")
+	assert.NotContains(t, stdout, "\nok\n")
+
+	// the compression must be given correctly
+	code, _, stderr = runCmd(nil, "snapshot-verify", "-compressed", writeTestSnapshot(t, testSnapshotUTXOs(), false))
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, stderr, "unable to read local snapshot")
+}
+
+func TestSnapshotDump(t *testing.T) {
+	path := writeTestSnapshot(t, testSnapshotUTXOs(), true)
+
+	code, stdout, stderr := runCmd(nil, "snapshot-dump", "-compressed", path)
+	require.Equal(t, exitOk, code, stderr)
+	var outputs []struct {
+		TransactionHash string          `json:"transaction_hash"`
+		Index           uint16          `json:"index"`
+		Address         json.RawMessage `json:"address"`
+		Value           uint64          `json:"value"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &outputs))
+	require.Len(t, outputs, 3)
+	assert.Equal(t, "0200000000000000000000000000000000000000000000000000000000000000", outputs[2].TransactionHash)
+	assert.EqualValues(t, 3, outputs[2].Index)
+	assert.EqualValues(t, 200, outputs[2].Value)
+	addr, err := iota.DeserializeObjectFromJSON(outputs[2].Address, iota.AddressSelector)
+	require.NoError(t, err)
+	assert.Equal(t, &iota.WOTSAddress{3}, addr)
+
+	out := filepath.Join(t.TempDir(), "dump.csv")
+	code, stdout, stderr = runCmd(nil, "snapshot-dump", "-compressed", "-format", "csv", "-out", out, path)
+	require.Equal(t, exitOk, code, stderr)
+	assert.Empty(t, stdout)
+	data, err := ioutil.ReadFile(out)
+	require.NoError(t, err)
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, []string{"transaction_hash", "index", "address_type", "address", "value"}, records[0])
+	assert.Equal(t, []string{"0100000000000000000000000000000000000000000000000000000000000000", "1", "1", "02" + strings.Repeat("0", 62), "100"}, records[2])
+
+	code, stdout, _ = runCmd(nil, "snapshot-dump", writeTestSnapshot(t, nil, false))
+	require.Equal(t, exitOk, code)
+	assert.Equal(t, "[]\n", stdout)
+
+	code, _, _ = runCmd(nil, "snapshot-dump", "-format", "xml", path)
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestSnapshotConvert(t *testing.T) {
+	original := writeTestSnapshot(t, testSnapshotUTXOs(), false)
+	dir := t.TempDir()
+	compressed, uncompressed := filepath.Join(dir, "compressed.bin"), filepath.Join(dir, "uncompressed.bin")
+
+	code, _, stderr := runCmd(nil, "snapshot-convert", "-compress", original, compressed)
+	require.Equal(t, exitOk, code, stderr)
+	code, stdout, stderr := runCmd(nil, "snapshot-verify", "-compressed", compressed)
+	require.Equal(t, exitOk, code, stderr)
+	assert.True(t, strings.HasSuffix(stdout, "ok\n"))
+
+	code, _, stderr = runCmd(nil, "snapshot-convert", "-compressed", compressed, uncompressed)
+	require.Equal(t, exitOk, code, stderr)
+	originalData, err := ioutil.ReadFile(original)
+	require.NoError(t, err)
+	convertedData, err := ioutil.ReadFile(uncompressed)
+	require.NoError(t, err)
+	assert.Equal(t, originalData, convertedData)
+
+	// a failed conversion leaves no output behind
+	truncated := filepath.Join(dir, "truncated.bin")
+	require.NoError(t, ioutil.WriteFile(truncated, originalData[:len(originalData)-10], 0644))
+	failed := filepath.Join(dir, "failed.bin")
+	code, _, stderr = runCmd(nil, "snapshot-convert", truncated, failed)
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, stderr, "unable to read local snapshot")
+	_, err = os.Stat(failed)
+	assert.True(t, os.IsNotExist(err))
+
+	code, _, _ = runCmd(nil, "snapshot-convert", original, original)
+	assert.Equal(t, exitUsage, code)
+}
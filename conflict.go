@@ -0,0 +1,80 @@
+package iota
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ConflictReason defines the reason why a transaction is conflicting with the ledger state.
+type ConflictReason byte
+
+const (
+	// Denotes that the transaction is not conflicting.
+	ConflictNone ConflictReason = iota
+	// Denotes that a referenced UTXO was already spent.
+	ConflictInputUTXOAlreadySpent
+	// Denotes that a referenced UTXO doesn't exist.
+	ConflictInputUTXONotFound
+	// Denotes that the sum of the inputs and outputs is not equal.
+	ConflictInputOutputSumMismatch
+	// Denotes that an unlock block's signature is invalid.
+	ConflictInvalidSignature
+	// Denotes that an unlock block's signer doesn't own the address of the UTXO it unlocks.
+	ConflictSignerAddressMismatch
+	// Denotes that the transaction is syntactically invalid or contains inputs, outputs, unlock blocks or addresses
+	// which can't be semantically validated.
+	ConflictUnsupported
+)
+
+var (
+	ErrInputUTXOAlreadySpent  = errors.New("referenced UTXO was already spent")
+	ErrInputUTXONotFound      = errors.New("referenced UTXO doesn't exist")
+	ErrInputOutputSumMismatch = errors.New("the sum of the inputs and outputs doesn't match")
+	ErrInvalidSignature       = errors.New("invalid signature")
+	ErrSignerAddressMismatch  = errors.New("the signer doesn't own the address of the referenced UTXO")
+	ErrSemanticsUnsupported   = errors.New("transaction can't be semantically validated")
+
+	conflictReasonErrs = map[ConflictReason]error{
+		ConflictInputUTXOAlreadySpent:  ErrInputUTXOAlreadySpent,
+		ConflictInputUTXONotFound:      ErrInputUTXONotFound,
+		ConflictInputOutputSumMismatch: ErrInputOutputSumMismatch,
+		ConflictInvalidSignature:       ErrInvalidSignature,
+		ConflictSignerAddressMismatch:  ErrSignerAddressMismatch,
+		ConflictUnsupported:            ErrSemanticsUnsupported,
+	}
+)
+
+// Err returns the error corresponding to the conflict reason or nil for ConflictNone.
+func (c ConflictReason) Err() error {
+	return conflictReasonErrs[c]
+}
+
+func (c ConflictReason) String() string {
+	switch c {
+	case ConflictNone:
+		return "none"
+	case ConflictInputUTXOAlreadySpent:
+		return "input UTXO already spent"
+	case ConflictInputUTXONotFound:
+		return "input UTXO not found"
+	case ConflictInputOutputSumMismatch:
+		return "input/output sum mismatch"
+	case ConflictInvalidSignature:
+		return "invalid signature"
+	case ConflictSignerAddressMismatch:
+		return "signer address mismatch"
+	case ConflictUnsupported:
+		return "unsupported"
+	default:
+		return fmt.Sprintf("unknown conflict reason %d", byte(c))
+	}
+}
+
+// UTXOLookupFunc looks up the output referenced by the given input and returns whether it was already spent.
+// A nil output without an error signals that the referenced output doesn't exist.
+type UTXOLookupFunc func(input *UTXOInput) (output *UnspentOutput, spent bool, err error)
+
+// conflict returns the given conflict reason together with its error annotated by the given format.
+func conflict(reason ConflictReason, format string, args ...interface{}) (ConflictReason, error) {
+	return reason, fmt.Errorf("%w: %s", reason.Err(), fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,15 @@
+package iota_test
+
+import (
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConflictReason(t *testing.T) {
+	assert.Nil(t, iota.ConflictNone.Err())
+	assert.Equal(t, iota.ErrInputUTXONotFound, iota.ConflictInputUTXONotFound.Err())
+	assert.Equal(t, "invalid signature", iota.ConflictInvalidSignature.String())
+	assert.Equal(t, "unknown conflict reason 200", iota.ConflictReason(200).String())
+}
@@ -0,0 +1,154 @@
+package iota
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// VerifyEd25519 reports whether the given signature over msg is valid under the validation rules of crypto/ed25519,
+// which are the rules signature unlock blocks are verified by.
+func VerifyEd25519(sig *Ed25519Signature, msg []byte) bool {
+	return ed25519.Verify(sig.PublicKey[:], msg, sig.Signature[:])
+}
+
+type ed25519Entry struct {
+	sig *Ed25519Signature
+	msg []byte
+}
+
+// Ed25519ParallelVerifier verifies many Ed25519 signatures by spreading them over GOMAXPROCS goroutines.
+// Every signature is verified on its own by VerifyEd25519, so there is no speedup on a single core
+// but neither are signatures accepted which crypto/ed25519 rejects, as they could be by batch verification.
+type Ed25519ParallelVerifier struct {
+	entries []ed25519Entry
+}
+
+// NewEd25519ParallelVerifier creates a new empty Ed25519ParallelVerifier.
+func NewEd25519ParallelVerifier() *Ed25519ParallelVerifier {
+	return &Ed25519ParallelVerifier{}
+}
+
+// Add adds the given signature over msg to the verifier and returns its index.
+// msg must not be modified until the signatures are verified.
+func (v *Ed25519ParallelVerifier) Add(sig *Ed25519Signature, msg []byte) int {
+	v.entries = append(v.entries, ed25519Entry{sig: sig, msg: msg})
+	return len(v.entries) - 1
+}
+
+// Len returns the amount of added signatures.
+func (v *Ed25519ParallelVerifier) Len() int {
+	return len(v.entries)
+}
+
+// Verify verifies the added signatures and returns the indices of the invalid ones in ascending order,
+// nil if all are valid.
+func (v *Ed25519ParallelVerifier) Verify() []int {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(v.entries) {
+		workers = len(v.entries)
+	}
+
+	valid := make([]bool, len(v.entries))
+	verify := func(first int) {
+		for i := first; i < len(v.entries); i += workers {
+			valid[i] = VerifyEd25519(v.entries[i].sig, v.entries[i].msg)
+		}
+	}
+	if workers <= 1 {
+		// goroutines only add overhead on a single core
+		verify(0)
+	} else {
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(first int) {
+				defer wg.Done()
+				verify(first)
+			}(w)
+		}
+		wg.Wait()
+	}
+
+	var invalid []int
+	for i := range valid {
+		if !valid[i] {
+			invalid = append(invalid, i)
+		}
+	}
+	return invalid
+}
+
+// addSignatures adds the signatures of the signature unlock blocks to the given verifier
+// and returns the indices of their unlock blocks in the order they were added.
+// Nothing is added if the payload holds anything but an UnsignedTransaction and Ed25519Signatures.
+func (s *SignedTransactionPayload) addSignatures(verifier *Ed25519ParallelVerifier) ([]int, error) {
+	unsignedTx, ok := s.Transaction.(*UnsignedTransaction)
+	if !ok {
+		return nil, fmt.Errorf("%w: transaction is %T", ErrSemanticsUnsupported, s.Transaction)
+	}
+
+	var blocks []int
+	var sigs []*Ed25519Signature
+	for i, block := range s.UnlockBlocks {
+		sigBlock, ok := block.(*SignatureUnlockBlock)
+		if !ok {
+			continue
+		}
+		edSig, ok := sigBlock.Signature.(*Ed25519Signature)
+		if !ok {
+			return nil, fmt.Errorf("%w: unlock block %d holds %T", ErrSemanticsUnsupported, i, sigBlock.Signature)
+		}
+		blocks = append(blocks, i)
+		sigs = append(sigs, edSig)
+	}
+
+	unsignedTxData, err := unsignedTx.Serialize(DeSeriModeNoValidation)
+	if err != nil {
+		return nil, fmt.Errorf("unable to serialize unsigned transaction: %w", err)
+	}
+	for _, sig := range sigs {
+		verifier.Add(sig, unsignedTxData)
+	}
+	return blocks, nil
+}
+
+// VerifySignatures verifies the signatures of all signature unlock blocks in parallel. The returned error wraps
+// ErrInvalidSignature and names the first unlock block holding an invalid signature. It neither checks the signers
+// against the addresses of the referenced UTXOs nor the reference unlock blocks, which SemanticallyValid does.
+func (s *SignedTransactionPayload) VerifySignatures() error {
+	return VerifySignedTransactionPayloads(s)[0]
+}
+
+// VerifySignedTransactionPayloads verifies the signatures of the signature unlock blocks of all given payloads
+// in parallel, for example to filter incoming payloads before checking them against the ledger state.
+// It returns the error of every payload by its index, which is nil for payloads whose signatures are valid.
+// The errors of payloads holding invalid signatures wrap ErrInvalidSignature and name the first invalid unlock block.
+func VerifySignedTransactionPayloads(payloads ...*SignedTransactionPayload) []error {
+	errs := make([]error, len(payloads))
+	verifier := NewEd25519ParallelVerifier()
+	// the payload and unlock block index of every added signature
+	type origin struct {
+		payload int
+		block   int
+	}
+	var origins []origin
+	for i, payload := range payloads {
+		blocks, err := payload.addSignatures(verifier)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		for _, block := range blocks {
+			origins = append(origins, origin{payload: i, block: block})
+		}
+	}
+
+	for _, index := range verifier.Verify() {
+		if o := origins[index]; errs[o.payload] == nil {
+			errs[o.payload] = fmt.Errorf("%w: unlock block %d", ErrInvalidSignature, o.block)
+		}
+	}
+	return errs
+}
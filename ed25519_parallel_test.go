@@ -0,0 +1,95 @@
+package iota_test
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEd25519ParallelVerifier(t *testing.T) {
+	sign := func(msg string) (*iota.Ed25519Signature, []byte) {
+		prvKey := randEd25519PrivateKey()
+		sig := &iota.Ed25519Signature{}
+		copy(sig.PublicKey[:], prvKey.Public().(ed25519.PublicKey))
+		copy(sig.Signature[:], ed25519.Sign(prvKey, []byte(msg)))
+		return sig, []byte(msg)
+	}
+
+	tests := []struct {
+		name    string
+		count   int
+		corrupt []int
+	}{
+		{"empty", 0, nil},
+		{"single", 1, nil},
+		{"single invalid", 1, []int{0}},
+		{"many", 20, nil},
+		{"one invalid", 20, []int{13}},
+		{"several invalid", 20, []int{0, 7, 19}},
+	}
+	// a single core verifies inline, more spread the signatures over goroutines
+	for _, procs := range []int{1, 4} {
+		prevProcs := runtime.GOMAXPROCS(procs)
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s with %d procs", tt.name, procs), func(t *testing.T) {
+				verifier := iota.NewEd25519ParallelVerifier()
+				for i := 0; i < tt.count; i++ {
+					sig, msg := sign(string(rune('a' + i)))
+					for _, index := range tt.corrupt {
+						if index == i {
+							sig.Signature[5] ^= 0xff
+						}
+					}
+					assert.Equal(t, i, verifier.Add(sig, msg))
+				}
+				assert.Equal(t, tt.count, verifier.Len())
+				assert.Equal(t, tt.corrupt, verifier.Verify())
+			})
+		}
+		runtime.GOMAXPROCS(prevProcs)
+	}
+
+	sig, msg := sign("message")
+	assert.True(t, iota.VerifyEd25519(sig, msg))
+	assert.False(t, iota.VerifyEd25519(sig, []byte("other")))
+}
+
+func TestVerifySignedTransactionPayloads(t *testing.T) {
+	build := func(keys ...ed25519.PrivateKey) *iota.SignedTransactionPayload {
+		builder := iota.NewTransactionBuilder()
+		for _, key := range keys {
+			input, _ := randUTXOInput()
+			builder.AddInput(&iota.ToBeSignedUTXOInput{Input: input, Amount: 10, PrivateKey: key})
+		}
+		outputAddr, _ := randEd25519Addr()
+		payload, err := builder.AddOutput(&iota.SigLockedSingleDeposit{Address: outputAddr, Amount: uint64(10 * len(keys))}).Build()
+		require.NoError(t, err)
+		return payload
+	}
+	prvKey := randEd25519PrivateKey()
+
+	valid := build(randEd25519PrivateKey(), prvKey, prvKey)
+	require.NoError(t, valid.VerifySignatures())
+
+	invalid := build(randEd25519PrivateKey(), randEd25519PrivateKey(), randEd25519PrivateKey())
+	invalidBlock := invalid.UnlockBlocks[2].(*iota.SignatureUnlockBlock)
+	invalidBlock.Signature.(*iota.Ed25519Signature).Signature[0] ^= 0xff
+	assert.True(t, errors.Is(invalid.VerifySignatures(), iota.ErrInvalidSignature))
+
+	unsupported := build(randEd25519PrivateKey())
+	unsupported.UnlockBlocks[0] = &iota.SignatureUnlockBlock{Signature: &iota.WOTSSignature{}}
+
+	errs := iota.VerifySignedTransactionPayloads(valid, invalid, unsupported, build(prvKey))
+	require.Len(t, errs, 4)
+	assert.NoError(t, errs[0])
+	assert.True(t, errors.Is(errs[1], iota.ErrInvalidSignature))
+	assert.Contains(t, errs[1].Error(), "unlock block 2")
+	assert.True(t, errors.Is(errs[2], iota.ErrSemanticsUnsupported))
+	assert.NoError(t, errs[3])
+}
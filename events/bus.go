@@ -0,0 +1,200 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/luca-moser/iota"
+)
+
+const (
+	// The default amount of events buffered per subscription.
+	DefaultSubscriptionBufferSize = 256
+)
+
+var (
+	ErrUnknownTopic = errors.New("unknown topic")
+)
+
+// Filter defines which events a subscription receives.
+// Empty criteria match everything.
+type Filter struct {
+	// The topics to receive.
+	Topics []Topic
+	// The indices of the IndexationPayloads a message must carry, directly or within its transaction, applies to message topics.
+	Indices []string
+	// The addresses an output must deposit to, applies to output topics.
+	Addresses []iota.Serializable
+}
+
+// compiledFilter is a Filter prepared for matching.
+type compiledFilter struct {
+	topics    map[Topic]struct{}
+	indices   map[string]struct{}
+	addresses map[string]struct{}
+}
+
+func (f *Filter) compile() (*compiledFilter, error) {
+	cf := &compiledFilter{}
+	if len(f.Topics) > 0 {
+		cf.topics = make(map[Topic]struct{}, len(f.Topics))
+		for _, topic := range f.Topics {
+			if !isTopic(topic) {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownTopic, topic)
+			}
+			cf.topics[topic] = struct{}{}
+		}
+	}
+	if len(f.Indices) > 0 {
+		cf.indices = make(map[string]struct{}, len(f.Indices))
+		for _, index := range f.Indices {
+			cf.indices[index] = struct{}{}
+		}
+	}
+	if len(f.Addresses) > 0 {
+		cf.addresses = make(map[string]struct{}, len(f.Addresses))
+		for _, addr := range f.Addresses {
+			key, err := addressKey(addr)
+			if err != nil {
+				return nil, err
+			}
+			cf.addresses[key] = struct{}{}
+		}
+	}
+	return cf, nil
+}
+
+func (cf *compiledFilter) matches(event Event) bool {
+	if cf.topics != nil {
+		if _, has := cf.topics[event.Topic()]; !has {
+			return false
+		}
+	}
+	switch e := event.(type) {
+	case *MessageEvent:
+		if cf.indices == nil {
+			return true
+		}
+		indexation, ok := e.Message.IndexationPayload()
+		if !ok {
+			return false
+		}
+		_, has := cf.indices[indexation.Index]
+		return has
+	case *OutputEvent:
+		if cf.addresses == nil {
+			return true
+		}
+		key, err := addressKey(e.Output.Address)
+		if err != nil {
+			return false
+		}
+		_, has := cf.addresses[key]
+		return has
+	}
+	return true
+}
+
+func isTopic(topic Topic) bool {
+	for _, t := range Topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+func addressKey(addr iota.Serializable) (string, error) {
+	data, err := addr.Serialize(iota.DeSeriModeNoValidation)
+	if err != nil {
+		return "", fmt.Errorf("unable to serialize address: %w", err)
+	}
+	return string(data), nil
+}
+
+// Subscription receives the events of a Bus which match its filter.
+type Subscription struct {
+	// C receives the events. It is closed once the subscription is closed.
+	C       <-chan Event
+	c       chan Event
+	bus     *Bus
+	filter  *compiledFilter
+	dropped uint64
+}
+
+// Dropped returns the amount of events which were dropped because the subscription's buffer was full.
+func (s *Subscription) Dropped() uint64 {
+	s.bus.mu.RLock()
+	defer s.bus.mu.RUnlock()
+	return s.dropped
+}
+
+// Close removes the subscription from its bus and closes its channel.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if _, has := s.bus.subs[s]; !has {
+		return
+	}
+	delete(s.bus.subs, s)
+	close(s.c)
+}
+
+// Bus distributes published events to its subscriptions.
+// Publishing never blocks: events for a subscription whose buffer is full are dropped.
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+// NewBus creates a new Bus.
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscribe creates a Subscription receiving the events matching the given filter, buffering up to the given
+// amount of events. DefaultSubscriptionBufferSize is used if the buffer size is not positive.
+func (b *Bus) Subscribe(filter Filter, bufferSize int) (*Subscription, error) {
+	cf, err := filter.compile()
+	if err != nil {
+		return nil, err
+	}
+	if bufferSize <= 0 {
+		bufferSize = DefaultSubscriptionBufferSize
+	}
+	c := make(chan Event, bufferSize)
+	sub := &Subscription{C: c, c: c, bus: b, filter: cf}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Publish delivers the given event to all subscriptions whose filter matches it.
+func (b *Bus) Publish(event Event) {
+	// the write lock guards the drop counters and excludes concurrent closes
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		if !sub.filter.matches(event) {
+			continue
+		}
+		select {
+		case sub.c <- event:
+		default:
+			sub.dropped++
+		}
+	}
+}
+
+// Close closes all subscriptions of the bus.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		delete(b.subs, sub)
+		close(sub.c)
+	}
+}
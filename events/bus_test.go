@@ -0,0 +1,222 @@
+package events_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/luca-moser/iota/events"
+	"github.com/luca-moser/iota/ledger"
+	"github.com/luca-moser/iota/tangle"
+	"github.com/luca-moser/iota/whiteflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var genesis = iota.MessageID{}
+
+func indexationMessage(index string) *iota.Message {
+	return &iota.Message{
+		Parent1: genesis,
+		Parent2: genesis,
+		Payload: &iota.IndexationPayload{Index: index, Data: []byte(index)},
+		Nonce:   1,
+	}
+}
+
+func unspentOutput(txID byte, addr iota.Serializable, amount uint64) *iota.UnspentOutput {
+	return &iota.UnspentOutput{
+		Input:   &iota.UTXOInput{TransactionID: [iota.TransactionIDLength]byte{txID}},
+		Address: addr,
+		Amount:  amount,
+	}
+}
+
+// drain returns the events currently buffered by the given subscription, stopping at its close.
+func drain(sub *events.Subscription) []events.Event {
+	var evts []events.Event
+	for {
+		select {
+		case event, ok := <-sub.C:
+			if !ok {
+				return evts
+			}
+			evts = append(evts, event)
+		default:
+			return evts
+		}
+	}
+}
+
+func topicsOf(evts []events.Event) []events.Topic {
+	topics := make([]events.Topic, len(evts))
+	for i, event := range evts {
+		topics[i] = event.Topic()
+	}
+	return topics
+}
+
+func TestBus_Filter(t *testing.T) {
+	addr := &iota.Ed25519Address{1}
+	otherAddr := &iota.Ed25519Address{2}
+	msgEvent := &events.MessageEvent{Message: indexationMessage("a")}
+	otherMsgEvent := &events.MessageEvent{Message: indexationMessage("b")}
+	noIndexMsgEvent := &events.MessageEvent{Message: &iota.Message{}}
+	txMsgEvent := &events.MessageEvent{Message: &iota.Message{Payload: &iota.SignedTransactionPayload{
+		Transaction: &iota.UnsignedTransaction{Payload: &iota.IndexationPayload{Index: "a"}},
+	}}}
+	solidEvent := &events.MessageEvent{Message: indexationMessage("a"), Solid: true}
+	msEvent := &events.MilestoneEvent{Confirmation: &whiteflag.Confirmation{MilestoneIndex: 2}}
+	createdEvent := &events.OutputEvent{Output: unspentOutput(1, addr, 10)}
+	spentEvent := &events.OutputEvent{Output: unspentOutput(2, otherAddr, 10), Spent: true}
+	all := []events.Event{msgEvent, otherMsgEvent, noIndexMsgEvent, txMsgEvent, solidEvent, msEvent, createdEvent, spentEvent}
+
+	tests := []struct {
+		name     string
+		filter   events.Filter
+		expected []events.Event
+	}{
+		{"everything", events.Filter{}, all},
+		{"topics", events.Filter{Topics: []events.Topic{events.TopicMessageSolid, events.TopicOutputSpent}}, []events.Event{solidEvent, spentEvent}},
+		{"index", events.Filter{Indices: []string{"a"}}, []events.Event{msgEvent, txMsgEvent, solidEvent, msEvent, createdEvent, spentEvent}},
+		{"address", events.Filter{Addresses: []iota.Serializable{addr}}, []events.Event{msgEvent, otherMsgEvent, noIndexMsgEvent, txMsgEvent, solidEvent, msEvent, createdEvent}},
+		{"combined", events.Filter{
+			Topics:    []events.Topic{events.TopicMessage, events.TopicOutputCreated, events.TopicOutputSpent},
+			Indices:   []string{"b"},
+			Addresses: []iota.Serializable{otherAddr},
+		}, []events.Event{otherMsgEvent, spentEvent}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := events.NewBus()
+			sub, err := bus.Subscribe(tt.filter, 0)
+			require.NoError(t, err)
+			for _, event := range all {
+				bus.Publish(event)
+			}
+			assert.Equal(t, tt.expected, drain(sub))
+		})
+	}
+
+	_, err := events.NewBus().Subscribe(events.Filter{Topics: []events.Topic{"unknown"}}, 0)
+	assert.True(t, errors.Is(err, events.ErrUnknownTopic))
+}
+
+func TestBus_DropAndClose(t *testing.T) {
+	bus := events.NewBus()
+	sub, err := bus.Subscribe(events.Filter{}, 2)
+	require.NoError(t, err)
+	other, err := bus.Subscribe(events.Filter{}, 1)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		bus.Publish(&events.MessageEvent{Message: indexationMessage("a")})
+	}
+	assert.EqualValues(t, 3, sub.Dropped())
+	assert.EqualValues(t, 4, other.Dropped())
+	assert.Len(t, drain(sub), 2)
+
+	sub.Close()
+	sub.Close()
+	_, ok := <-sub.C
+	assert.False(t, ok)
+	bus.Publish(&events.MessageEvent{Message: indexationMessage("a")})
+
+	bus.Close()
+	assert.Len(t, drain(other), 1)
+	_, ok = <-other.C
+	assert.False(t, ok)
+}
+
+func TestPublishTangle(t *testing.T) {
+	bus := events.NewBus()
+	sub, err := bus.Subscribe(events.Filter{}, 0)
+	require.NoError(t, err)
+	tngl := tangle.New(genesis)
+	events.PublishTangle(bus, tngl)
+
+	// the child is attached before its parent, so it becomes solid together with it
+	parent := indexationMessage("parent")
+	parentID, err := parent.ID()
+	require.NoError(t, err)
+	child := &iota.Message{Parent1: parentID, Parent2: genesis, Payload: &iota.IndexationPayload{Index: "child"}, Nonce: 1}
+	childID, _, err := tngl.Attach(child)
+	require.NoError(t, err)
+	_, _, err = tngl.Attach(parent)
+	require.NoError(t, err)
+
+	evts := drain(sub)
+	assert.Equal(t, []events.Topic{events.TopicMessage, events.TopicMessage, events.TopicMessageSolid, events.TopicMessageSolid}, topicsOf(evts))
+	assert.Equal(t, childID, evts[0].(*events.MessageEvent).MessageID)
+	assert.Equal(t, parentID, evts[2].(*events.MessageEvent).MessageID)
+	assert.Equal(t, childID, evts[3].(*events.MessageEvent).MessageID)
+}
+
+func TestPublishConfirmation(t *testing.T) {
+	bus := events.NewBus()
+	sub, err := bus.Subscribe(events.Filter{}, 0)
+	require.NoError(t, err)
+
+	addr, otherAddr := &iota.Ed25519Address{1}, &iota.Ed25519Address{2}
+	// the output the first transaction creates is spent by the third one within the same milestone
+	conf := &whiteflag.Confirmation{
+		MilestoneIndex: 2,
+		MilestoneID:    iota.MessageID{9},
+		Referenced:     []iota.MessageID{{1}, {2}, {3}, {9}},
+		Transactions: []*ledger.TransactionResult{
+			{
+				MessageID: iota.MessageID{1},
+				Consumed:  []*iota.UnspentOutput{unspentOutput(1, addr, 10)},
+				Created:   []*iota.UnspentOutput{unspentOutput(2, addr, 10)},
+			},
+			{MessageID: iota.MessageID{2}, Conflict: iota.ConflictInputUTXONotFound},
+			{
+				MessageID: iota.MessageID{3},
+				Consumed:  []*iota.UnspentOutput{unspentOutput(2, addr, 10)},
+				Created:   []*iota.UnspentOutput{unspentOutput(3, otherAddr, 10)},
+			},
+		},
+		Included: []iota.MessageID{{1}, {3}},
+		Diff: &ledger.Diff{
+			MilestoneIndex: 2,
+			Created:        []*iota.UnspentOutput{unspentOutput(3, otherAddr, 10)},
+			Consumed:       []*iota.UnspentOutput{unspentOutput(1, addr, 10)},
+		},
+	}
+	events.PublishConfirmation(bus, conf)
+
+	evts := drain(sub)
+	require.Equal(t, []events.Topic{
+		events.TopicMilestoneConfirmed,
+		events.TopicOutputSpent, events.TopicOutputCreated,
+		events.TopicOutputSpent, events.TopicOutputCreated,
+	}, topicsOf(evts))
+	assert.Equal(t, unspentOutput(2, addr, 10), evts[3].(*events.OutputEvent).Output)
+
+	msJSON, err := json.Marshal(evts[0])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"milestone_index": 2,
+		"milestone_id": "09`+zeroHex(iota.MessageHashLength-1)+`",
+		"referenced": 4,
+		"included": ["01`+zeroHex(iota.MessageHashLength-1)+`", "03`+zeroHex(iota.MessageHashLength-1)+`"],
+		"conflicting": ["02`+zeroHex(iota.MessageHashLength-1)+`"]
+	}`, string(msJSON))
+
+	outputJSON, err := json.Marshal(evts[2])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"milestone_index": 2,
+		"output_id": "02`+zeroHex(iota.TransactionIDLength+1)+`",
+		"output": {"type": 0, "address": {"type": 1, "address": "01`+zeroHex(iota.Ed25519AddressBytesLength-1)+`"}, "amount": 10}
+	}`, string(outputJSON))
+}
+
+func zeroHex(bytes int) string {
+	s := make([]byte, bytes*2)
+	for i := range s {
+		s[i] = '0'
+	}
+	return string(s)
+}
@@ -0,0 +1,165 @@
+// Package events implements an in-process event bus for tangle and ledger events and
+// streams them to remote clients via Server-Sent Events or WebSocket.
+package events
+
+import (
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/luca-moser/iota"
+	"github.com/luca-moser/iota/tangle"
+	"github.com/luca-moser/iota/whiteflag"
+)
+
+// Topic defines the kind of an event.
+type Topic string
+
+const (
+	// Denotes that a message was newly attached to the tangle.
+	TopicMessage Topic = "message"
+	// Denotes that a message became solid.
+	TopicMessageSolid Topic = "message_solid"
+	// Denotes that a milestone was confirmed.
+	TopicMilestoneConfirmed Topic = "milestone_confirmed"
+	// Denotes that a confirmed milestone created an output.
+	TopicOutputCreated Topic = "output_created"
+	// Denotes that a confirmed milestone spent an output.
+	TopicOutputSpent Topic = "output_spent"
+)
+
+// Topics holds all topics.
+var Topics = []Topic{TopicMessage, TopicMessageSolid, TopicMilestoneConfirmed, TopicOutputCreated, TopicOutputSpent}
+
+// Event is something which happened to the tangle or ledger.
+// Its JSON form is the data of the event, the topic is carried alongside it.
+type Event interface {
+	json.Marshaler
+	// Topic returns the topic of the event.
+	Topic() Topic
+}
+
+// MessageEvent is published when a message is attached or becomes solid.
+type MessageEvent struct {
+	// The ID of the message.
+	MessageID iota.MessageID
+	// The message.
+	Message *iota.Message
+	// Whether the message became solid, otherwise it was newly attached.
+	Solid bool
+}
+
+func (e *MessageEvent) Topic() Topic {
+	if e.Solid {
+		return TopicMessageSolid
+	}
+	return TopicMessage
+}
+
+// jsonMessageEvent defines the JSON form of a MessageEvent.
+type jsonMessageEvent struct {
+	MessageID string        `json:"message_id"`
+	Message   *iota.Message `json:"message"`
+}
+
+func (e *MessageEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonMessageEvent{MessageID: hex.EncodeToString(e.MessageID[:]), Message: e.Message})
+}
+
+// MilestoneEvent is published when a milestone is confirmed.
+type MilestoneEvent struct {
+	// The confirmation of the milestone.
+	Confirmation *whiteflag.Confirmation
+}
+
+func (e *MilestoneEvent) Topic() Topic {
+	return TopicMilestoneConfirmed
+}
+
+// jsonMilestoneEvent defines the JSON form of a MilestoneEvent.
+type jsonMilestoneEvent struct {
+	MilestoneIndex uint64   `json:"milestone_index"`
+	MilestoneID    string   `json:"milestone_id"`
+	Referenced     int      `json:"referenced"`
+	Included       []string `json:"included"`
+	Conflicting    []string `json:"conflicting"`
+}
+
+func (e *MilestoneEvent) MarshalJSON() ([]byte, error) {
+	conf := e.Confirmation
+	jEvent := &jsonMilestoneEvent{
+		MilestoneIndex: conf.MilestoneIndex,
+		MilestoneID:    hex.EncodeToString(conf.MilestoneID[:]),
+		Referenced:     len(conf.Referenced),
+		Included:       make([]string, len(conf.Included)),
+		Conflicting:    []string{},
+	}
+	for i, id := range conf.Included {
+		jEvent.Included[i] = hex.EncodeToString(id[:])
+	}
+	for _, tx := range conf.Transactions {
+		if !tx.Applied() {
+			jEvent.Conflicting = append(jEvent.Conflicting, hex.EncodeToString(tx.MessageID[:]))
+		}
+	}
+	return json.Marshal(jEvent)
+}
+
+// OutputEvent is published when a confirmed milestone created or spent an output.
+type OutputEvent struct {
+	// The index of the milestone which created or spent the output.
+	MilestoneIndex uint64
+	// The output.
+	Output *iota.UnspentOutput
+	// Whether the output was spent, otherwise it was created.
+	Spent bool
+}
+
+func (e *OutputEvent) Topic() Topic {
+	if e.Spent {
+		return TopicOutputSpent
+	}
+	return TopicOutputCreated
+}
+
+// jsonOutputEvent defines the JSON form of an OutputEvent.
+type jsonOutputEvent struct {
+	MilestoneIndex uint64                       `json:"milestone_index"`
+	OutputID       string                       `json:"output_id"`
+	Output         *iota.SigLockedSingleDeposit `json:"output"`
+}
+
+func (e *OutputEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonOutputEvent{
+		MilestoneIndex: e.MilestoneIndex,
+		OutputID:       e.Output.Input.OutputIDHex(),
+		Output:         &iota.SigLockedSingleDeposit{Address: e.Output.Address, Amount: e.Output.Amount},
+	})
+}
+
+// PublishTangle publishes a MessageEvent on the given bus whenever a message is attached to or becomes solid in the given tangle.
+func PublishTangle(bus *Bus, tngl *tangle.Tangle) {
+	tngl.OnAttached(func(id iota.MessageID, msg *iota.Message) {
+		bus.Publish(&MessageEvent{MessageID: id, Message: msg})
+	})
+	tngl.OnSolid(func(id iota.MessageID, msg *iota.Message) {
+		bus.Publish(&MessageEvent{MessageID: id, Message: msg, Solid: true})
+	})
+}
+
+// PublishConfirmation publishes the MilestoneEvent of the given confirmation followed by an OutputEvent
+// for every output each applied transaction spent and created, in the order of the transactions.
+// Unlike in the ledger diff, outputs created and spent within the same milestone are published as well.
+func PublishConfirmation(bus *Bus, conf *whiteflag.Confirmation) {
+	bus.Publish(&MilestoneEvent{Confirmation: conf})
+	for _, tx := range conf.Transactions {
+		if !tx.Applied() {
+			continue
+		}
+		for _, output := range tx.Consumed {
+			bus.Publish(&OutputEvent{MilestoneIndex: conf.MilestoneIndex, Output: output, Spent: true})
+		}
+		for _, output := range tx.Created {
+			bus.Publish(&OutputEvent{MilestoneIndex: conf.MilestoneIndex, Output: output})
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package events
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/luca-moser/iota"
+)
+
+const (
+	// The query parameter selecting a topic to stream, may be given multiple times.
+	StreamQueryParameterTopic = "topic"
+	// The query parameter selecting an IndexationPayload index to stream messages of, may be given multiple times.
+	StreamQueryParameterIndex = "index"
+	// The query parameter selecting a hex encoded serialized address to stream outputs of, may be given multiple times.
+	StreamQueryParameterAddress = "address"
+
+	// The MIME type of Server-Sent Events.
+	MIMETextEventStream = "text/event-stream"
+
+	// The error code of stream requests with invalid parameters.
+	ErrCodeInvalidData = "invalid_data"
+	// The error code of stream requests over connections which can't stream.
+	ErrCodeNotImplemented = "not_implemented"
+)
+
+// StreamEnvelope is the JSON form in which events are streamed.
+type StreamEnvelope struct {
+	// The topic of the event.
+	Topic Topic `json:"topic"`
+	// The JSON form of the event.
+	Data json.RawMessage `json:"data"`
+}
+
+// FilterFromQuery parses a Filter from the given query parameters.
+func FilterFromQuery(query map[string][]string) (Filter, error) {
+	filter := Filter{Indices: query[StreamQueryParameterIndex]}
+	for _, topic := range query[StreamQueryParameterTopic] {
+		if !isTopic(Topic(topic)) {
+			return Filter{}, fmt.Errorf("%w: %s", ErrUnknownTopic, topic)
+		}
+		filter.Topics = append(filter.Topics, Topic(topic))
+	}
+	for _, addrHex := range query[StreamQueryParameterAddress] {
+		addrData, err := hex.DecodeString(addrHex)
+		if err != nil {
+			return Filter{}, fmt.Errorf("%w: address is not valid hex: %v", iota.ErrInvalidBytes, err)
+		}
+		addr, bytesRead, err := iota.DeserializeObject(addrData, iota.DeSeriModePerformValidation, iota.TypeDenotationByte, iota.AddressSelector)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid address: %w", err)
+		}
+		if bytesRead != len(addrData) {
+			return Filter{}, fmt.Errorf("invalid address: %w", iota.ErrDeserializationNotAllConsumed)
+		}
+		filter.Addresses = append(filter.Addresses, addr)
+	}
+	return filter, nil
+}
+
+// StreamHandler streams the events of a Bus to HTTP clients. Requests asking for a WebSocket upgrade
+// receive every event as a text frame, all others receive them as Server-Sent Events named by their topic.
+// In both cases the payload is the StreamEnvelope of the event. The events are filtered by the request's
+// query parameters, see FilterFromQuery.
+type StreamHandler struct {
+	bus        *Bus
+	bufferSize int
+}
+
+// NewStreamHandler creates a new StreamHandler which subscribes to the given bus with the given buffer size
+// per client, see Bus.Subscribe.
+func NewStreamHandler(bus *Bus, bufferSize int) *StreamHandler {
+	return &StreamHandler{bus: bus, bufferSize: bufferSize}
+}
+
+func (h *StreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, ErrCodeInvalidData, fmt.Sprintf("method %s is not allowed", r.Method))
+		return
+	}
+	filter, err := FilterFromQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidData, err.Error())
+		return
+	}
+
+	if isWebSocketUpgrade(r) {
+		h.serveWebSocket(w, r, filter)
+		return
+	}
+	h.serveSSE(w, r, filter)
+}
+
+func (h *StreamHandler) serveSSE(w http.ResponseWriter, r *http.Request, filter Filter) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, ErrCodeNotImplemented, "streaming is not supported")
+		return
+	}
+	sub, err := h.bus.Subscribe(filter, h.bufferSize)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidData, err.Error())
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", MIMETextEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			data, err := marshalEnvelope(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Topic(), data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (h *StreamHandler) serveWebSocket(w http.ResponseWriter, r *http.Request, filter Filter) {
+	sub, err := h.bus.Subscribe(filter, h.bufferSize)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidData, err.Error())
+		return
+	}
+	defer sub.Close()
+
+	ws, err := upgradeWebSocket(w, r)
+	if err != nil {
+		// other errors occur after the connection was taken over, which can't be written to anymore
+		if errors.Is(err, ErrWebSocketHandshake) {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidData, err.Error())
+		}
+		return
+	}
+
+	readerDone := make(chan uint16, 1)
+	go func() {
+		readerDone <- ws.readLoop()
+	}()
+
+	for {
+		select {
+		case code := <-readerDone:
+			ws.close(code)
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				ws.close(wsCloseGoingAway)
+				return
+			}
+			data, err := marshalEnvelope(event)
+			if err != nil {
+				continue
+			}
+			if err := ws.writeText(data); err != nil {
+				ws.close(wsCloseGoingAway)
+				return
+			}
+		}
+	}
+}
+
+func marshalEnvelope(event Event) ([]byte, error) {
+	data, err := event.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&StreamEnvelope{Topic: event.Topic(), Data: data})
+}
+
+func writeError(w http.ResponseWriter, status int, code string, msg string) {
+	res := &iota.HTTPErrorResponseEnvelope{}
+	res.Error.Code = code
+	res.Error.Message = msg
+	data, err := json.Marshal(res)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", iota.MIMEApplicationJSON)
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,232 @@
+package events_test
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luca-moser/iota"
+	"github.com/luca-moser/iota/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStreamServer(t *testing.T) (*events.Bus, *httptest.Server) {
+	bus := events.NewBus()
+	srv := httptest.NewServer(events.NewStreamHandler(bus, 0))
+	t.Cleanup(srv.Close)
+	t.Cleanup(bus.Close)
+	return bus, srv
+}
+
+func addressHex(t *testing.T, addr iota.Serializable) string {
+	data, err := addr.Serialize(iota.DeSeriModePerformValidation)
+	require.NoError(t, err)
+	return hex.EncodeToString(data)
+}
+
+func TestStreamHandler_SSE(t *testing.T) {
+	bus, srv := newStreamServer(t)
+
+	query := url.Values{events.StreamQueryParameterTopic: {string(events.TopicMessage)}, events.StreamQueryParameterIndex: {"a"}}
+	res, err := http.Get(srv.URL + "?" + query.Encode())
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, events.MIMETextEventStream, res.Header.Get("Content-Type"))
+
+	// the subscription exists once the headers are sent
+	bus.Publish(&events.MessageEvent{Message: indexationMessage("b")})
+	bus.Publish(&events.MessageEvent{Message: indexationMessage("a"), Solid: true})
+	msg := indexationMessage("a")
+	msgID, err := msg.ID()
+	require.NoError(t, err)
+	bus.Publish(&events.MessageEvent{MessageID: msgID, Message: msg})
+
+	reader := bufio.NewReader(res.Body)
+	var lines []string
+	for i := 0; i < 3; i++ {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		lines = append(lines, line)
+	}
+	assert.Equal(t, "event: message\n", lines[0])
+	assert.Equal(t, "\n", lines[2])
+	require.True(t, strings.HasPrefix(lines[1], "data: "))
+
+	envelope := &events.StreamEnvelope{}
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), envelope))
+	assert.Equal(t, events.TopicMessage, envelope.Topic)
+	data := &struct {
+		MessageID string          `json:"message_id"`
+		Message   json.RawMessage `json:"message"`
+	}{}
+	require.NoError(t, json.Unmarshal(envelope.Data, data))
+	assert.Equal(t, hex.EncodeToString(msgID[:]), data.MessageID)
+	streamedMsg := &iota.Message{}
+	require.NoError(t, json.Unmarshal(data.Message, streamedMsg))
+	assert.Equal(t, msg, streamedMsg)
+}
+
+// wsClient is a minimal client side of the WebSocket protocol.
+type wsClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dialWebSocket(t *testing.T, srvURL string, query url.Values) *wsClient {
+	u, err := url.Parse(srvURL)
+	require.NoError(t, err)
+	conn, err := net.Dial("tcp", u.Host)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, conn.SetDeadline(time.Now().Add(10*time.Second)))
+
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef"))
+	req, err := http.NewRequest(http.MethodGet, srvURL+"?"+query.Encode(), nil)
+	require.NoError(t, err)
+	req.Header.Set("Connection", "keep-alive, Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	require.NoError(t, req.Write(conn))
+
+	reader := bufio.NewReader(conn)
+	res, err := http.ReadResponse(reader, req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+	accept := sha1.Sum([]byte(key + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+	require.Equal(t, base64.StdEncoding.EncodeToString(accept[:]), res.Header.Get("Sec-WebSocket-Accept"))
+	return &wsClient{conn: conn, reader: reader}
+}
+
+func (c *wsClient) writeFrame(t *testing.T, opcode byte, payload []byte) {
+	mask := []byte{1, 2, 3, 4}
+	frame := []byte{0x80 | opcode, 0x80 | byte(len(payload))}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := c.conn.Write(frame)
+	require.NoError(t, err)
+}
+
+func (c *wsClient) readFrame(t *testing.T) (byte, []byte) {
+	header := make([]byte, 2)
+	_, err := io.ReadFull(c.reader, header)
+	require.NoError(t, err)
+	require.Zero(t, header[1]&0x80, "server frames must not be masked")
+	length := uint64(header[1])
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		_, err := io.ReadFull(c.reader, ext)
+		require.NoError(t, err)
+		length = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		_, err := io.ReadFull(c.reader, ext)
+		require.NoError(t, err)
+		length = binary.BigEndian.Uint64(ext)
+	}
+	payload := make([]byte, length)
+	_, err = io.ReadFull(c.reader, payload)
+	require.NoError(t, err)
+	return header[0] & 0x0F, payload
+}
+
+func TestStreamHandler_WebSocket(t *testing.T) {
+	bus, srv := newStreamServer(t)
+	addr := &iota.Ed25519Address{1}
+	client := dialWebSocket(t, srv.URL, url.Values{events.StreamQueryParameterAddress: {addressHex(t, addr)}})
+
+	// a ping is answered, which also ensures the subscription exists
+	client.writeFrame(t, 0x9, []byte("ping"))
+	opcode, payload := client.readFrame(t)
+	assert.EqualValues(t, 0xA, opcode)
+	assert.Equal(t, []byte("ping"), payload)
+
+	bus.Publish(&events.OutputEvent{MilestoneIndex: 2, Output: unspentOutput(1, &iota.Ed25519Address{2}, 5)})
+	// large enough to need an extended payload length
+	bus.Publish(&events.MessageEvent{Message: indexationMessage(strings.Repeat("a", 200))})
+	bus.Publish(&events.OutputEvent{MilestoneIndex: 2, Output: unspentOutput(2, addr, 10), Spent: true})
+
+	opcode, payload = client.readFrame(t)
+	assert.EqualValues(t, 0x1, opcode)
+	envelope := &events.StreamEnvelope{}
+	require.NoError(t, json.Unmarshal(payload, envelope))
+	assert.Equal(t, events.TopicMessage, envelope.Topic)
+
+	opcode, payload = client.readFrame(t)
+	assert.EqualValues(t, 0x1, opcode)
+	require.NoError(t, json.Unmarshal(payload, envelope))
+	assert.Equal(t, events.TopicOutputSpent, envelope.Topic)
+	data := &struct {
+		OutputID string          `json:"output_id"`
+		Output   json.RawMessage `json:"output"`
+	}{}
+	require.NoError(t, json.Unmarshal(envelope.Data, data))
+	assert.Equal(t, unspentOutput(2, addr, 10).Input.OutputIDHex(), data.OutputID)
+	output := &iota.SigLockedSingleDeposit{}
+	require.NoError(t, json.Unmarshal(data.Output, output))
+	assert.Equal(t, &iota.SigLockedSingleDeposit{Address: addr, Amount: 10}, output)
+
+	// the close handshake is answered and ends the stream
+	client.writeFrame(t, 0x8, []byte{0x03, 0xE8})
+	opcode, payload = client.readFrame(t)
+	assert.EqualValues(t, 0x8, opcode)
+	assert.Equal(t, []byte{0x03, 0xE8}, payload)
+	_, err := client.reader.ReadByte()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestStreamHandler_Errors(t *testing.T) {
+	_, srv := newStreamServer(t)
+
+	tests := []struct {
+		name    string
+		method  string
+		query   url.Values
+		headers map[string]string
+		status  int
+	}{
+		{"method", http.MethodPost, nil, nil, http.StatusMethodNotAllowed},
+		{"unknown topic", http.MethodGet, url.Values{events.StreamQueryParameterTopic: {"unknown"}}, nil, http.StatusBadRequest},
+		{"invalid address hex", http.MethodGet, url.Values{events.StreamQueryParameterAddress: {"xyz"}}, nil, http.StatusBadRequest},
+		{"invalid address", http.MethodGet, url.Values{events.StreamQueryParameterAddress: {"07aa"}}, nil, http.StatusBadRequest},
+		{"WebSocket version", http.MethodGet, nil, map[string]string{
+			"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "8", "Sec-WebSocket-Key": "MDEyMzQ1Njc4OWFiY2RlZg==",
+		}, http.StatusBadRequest},
+		{"WebSocket key", http.MethodGet, nil, map[string]string{
+			"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "13", "Sec-WebSocket-Key": "short",
+		}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, srv.URL+"?"+tt.query.Encode(), nil)
+			require.NoError(t, err)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			assert.Equal(t, tt.status, res.StatusCode)
+			errRes := &iota.HTTPErrorResponseEnvelope{}
+			require.NoError(t, json.NewDecoder(res.Body).Decode(errRes))
+			assert.Equal(t, events.ErrCodeInvalidData, errRes.Error.Code)
+			assert.NotEmpty(t, errRes.Error.Message)
+		})
+	}
+}
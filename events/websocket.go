@@ -0,0 +1,242 @@
+package events
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The server side of the WebSocket protocol (RFC 6455) as needed to stream events:
+// the server only sends text frames and merely answers the control frames of the client.
+
+const (
+	// The GUID the Sec-WebSocket-Accept header is derived with.
+	webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	// The only supported version of the protocol.
+	webSocketVersion = "13"
+	// The maximum payload size of frames read from clients, which only send control frames.
+	webSocketMaxReadPayload = 4096
+	// The time a frame write may take.
+	webSocketWriteTimeout = 10 * time.Second
+
+	wsOpText  = 0x1
+	wsOpClose = 0x8
+	wsOpPing  = 0x9
+	wsOpPong  = 0xA
+
+	wsFinBit  = 0x80
+	wsMaskBit = 0x80
+
+	wsCloseNormal          = 1000
+	wsCloseGoingAway       = 1001
+	wsCloseProtocolError   = 1002
+	wsCloseMessageTooLarge = 1009
+)
+
+var (
+	ErrWebSocketHandshake = errors.New("invalid WebSocket handshake")
+	ErrWebSocketProtocol  = errors.New("WebSocket protocol violation")
+
+	errWebSocketFrameTooLarge = errors.New("WebSocket frame too large")
+	errWebSocketClosed        = errors.New("WebSocket connection closed")
+)
+
+// isWebSocketUpgrade tells whether the given request asks for a WebSocket connection.
+func isWebSocketUpgrade(r *http.Request) bool {
+	return headerContainsToken(r.Header, "Connection", "upgrade") && headerContainsToken(r.Header, "Upgrade", "websocket")
+}
+
+func headerContainsToken(header http.Header, name string, token string) bool {
+	for _, value := range header[http.CanonicalHeaderKey(name)] {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// webSocketAccept computes the Sec-WebSocket-Accept header value for the given Sec-WebSocket-Key.
+func webSocketAccept(key string) string {
+	h := sha1.Sum([]byte(key + webSocketGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// wsConn is a server side WebSocket connection.
+type wsConn struct {
+	conn    net.Conn
+	rw      *bufio.ReadWriter
+	writeMu sync.Mutex
+	closed  bool
+}
+
+// upgradeWebSocket validates the handshake of the given request and takes over its connection.
+// Errors wrapping ErrWebSocketHandshake occur before the connection is taken over. Any other error
+// occurs afterwards, in which case the connection is closed and nothing must be written to w.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if r.Method != http.MethodGet {
+		return nil, fmt.Errorf("%w: method must be GET", ErrWebSocketHandshake)
+	}
+	if version := r.Header.Get("Sec-WebSocket-Version"); version != webSocketVersion {
+		w.Header().Set("Sec-WebSocket-Version", webSocketVersion)
+		return nil, fmt.Errorf("%w: unsupported version '%s'", ErrWebSocketHandshake, version)
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, fmt.Errorf("%w: invalid key", ErrWebSocketHandshake)
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, fmt.Errorf("%w: connection can not be taken over", ErrWebSocketHandshake)
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWebSocketHandshake, err)
+	}
+
+	ws := &wsConn{conn: conn, rw: rw}
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	_ = conn.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+	_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	_, _ = rw.WriteString("Sec-WebSocket-Accept: " + webSocketAccept(key) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("unable to complete WebSocket handshake: %w", err)
+	}
+	return ws, nil
+}
+
+// writeFrame writes a single unmasked frame with the given opcode and payload.
+func (ws *wsConn) writeFrame(opcode byte, payload []byte) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	if ws.closed {
+		return errWebSocketClosed
+	}
+
+	header := make([]byte, 2, 10)
+	header[0] = wsFinBit | opcode
+	switch l := len(payload); {
+	case l < 126:
+		header[1] = byte(l)
+	case l <= 0xFFFF:
+		header[1] = 126
+		header = header[:4]
+		binary.BigEndian.PutUint16(header[2:], uint16(l))
+	default:
+		header[1] = 127
+		header = header[:10]
+		binary.BigEndian.PutUint64(header[2:], uint64(l))
+	}
+
+	_ = ws.conn.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+	if _, err := ws.rw.Write(header); err != nil {
+		return err
+	}
+	if _, err := ws.rw.Write(payload); err != nil {
+		return err
+	}
+	return ws.rw.Flush()
+}
+
+// writeText writes the given payload as a text frame.
+func (ws *wsConn) writeText(payload []byte) error {
+	return ws.writeFrame(wsOpText, payload)
+}
+
+// close sends a close frame with the given status code and closes the connection.
+func (ws *wsConn) close(code uint16) {
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, code)
+	_ = ws.writeFrame(wsOpClose, payload)
+
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	if ws.closed {
+		return
+	}
+	ws.closed = true
+	_ = ws.conn.Close()
+}
+
+// readLoop reads the frames sent by the client until the connection is closed: pings are answered and
+// data frames are discarded. It returns the status code to close the connection with.
+func (ws *wsConn) readLoop() uint16 {
+	for {
+		opcode, payload, err := ws.readFrame()
+		switch {
+		case errors.Is(err, ErrWebSocketProtocol):
+			return wsCloseProtocolError
+		case errors.Is(err, errWebSocketFrameTooLarge):
+			return wsCloseMessageTooLarge
+		case err != nil:
+			return wsCloseGoingAway
+		}
+		switch opcode {
+		case wsOpClose:
+			return wsCloseNormal
+		case wsOpPing:
+			if err := ws.writeFrame(wsOpPong, payload); err != nil {
+				return wsCloseGoingAway
+			}
+		}
+	}
+}
+
+// readFrame reads a single frame sent by the client.
+func (ws *wsConn) readFrame() (byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(ws.rw, header[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode := header[0] & 0x0F
+	if header[1]&wsMaskBit == 0 {
+		return 0, nil, fmt.Errorf("%w: client frames must be masked", ErrWebSocketProtocol)
+	}
+
+	length := uint64(header[1] &^ wsMaskBit)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(ws.rw, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(ws.rw, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if opcode >= wsOpClose && length > 125 {
+		return 0, nil, fmt.Errorf("%w: control frame payload exceeds 125 bytes", ErrWebSocketProtocol)
+	}
+	if length > webSocketMaxReadPayload {
+		return 0, nil, fmt.Errorf("%w: %d bytes", errWebSocketFrameTooLarge, length)
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(ws.rw, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(ws.rw, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return opcode, payload, nil
+}
@@ -5,4 +5,5 @@ go 1.15
 require (
 	github.com/blang/vfs v1.0.0
 	github.com/stretchr/testify v1.6.1
+	golang.org/x/crypto v0.0.0-20211117183948-ae814b36b871
 )
@@ -0,0 +1,79 @@
+package gossip
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luca-moser/iota"
+)
+
+const (
+	// The version of the gossip protocol.
+	ProtocolVersion = 1
+	// The size of a HandshakePacket: protocol version + network ID + timestamp.
+	HandshakePacketSize = iota.OneByte + iota.UInt64ByteSize + iota.Int64ByteSize
+	// The maximum difference between the timestamp of a handshake and the local time.
+	MaxHandshakeTimeSkew = 30 * time.Second
+)
+
+var (
+	ErrProtocolVersionMismatch = errors.New("peer speaks a different protocol version")
+	ErrNetworkIDMismatch       = errors.New("peer is part of a different network")
+	ErrHandshakeTimeSkew       = errors.New("peer's handshake timestamp deviates too much from the local time")
+	ErrHandshakeExpected       = errors.New("expected a handshake packet")
+)
+
+// HandshakePacket is the first packet peers exchange after connecting.
+type HandshakePacket struct {
+	// The version of the gossip protocol the peer speaks.
+	ProtocolVersion byte `json:"protocol_version"`
+	// The ID of the network the peer is part of.
+	NetworkID uint64 `json:"network_id"`
+	// The time at which the handshake was created as unix nanoseconds.
+	Timestamp int64 `json:"timestamp"`
+}
+
+// NewHandshakePacket creates a HandshakePacket for the given network with the current time.
+func NewHandshakePacket(networkID uint64) *HandshakePacket {
+	return &HandshakePacket{ProtocolVersion: ProtocolVersion, NetworkID: networkID, Timestamp: time.Now().UnixNano()}
+}
+
+func (p *HandshakePacket) PacketType() PacketType {
+	return PacketHandshake
+}
+
+func (p *HandshakePacket) Deserialize(data []byte, deSeriMode iota.DeSerializationMode) (int, error) {
+	if err := checkMinLength(HandshakePacketSize, len(data)); err != nil {
+		return 0, err
+	}
+	p.ProtocolVersion = data[0]
+	p.NetworkID = binary.LittleEndian.Uint64(data[iota.OneByte:])
+	p.Timestamp = int64(binary.LittleEndian.Uint64(data[iota.OneByte+iota.UInt64ByteSize:]))
+	return HandshakePacketSize, nil
+}
+
+func (p *HandshakePacket) Serialize(deSeriMode iota.DeSerializationMode) ([]byte, error) {
+	b := make([]byte, HandshakePacketSize)
+	b[0] = p.ProtocolVersion
+	binary.LittleEndian.PutUint64(b[iota.OneByte:], p.NetworkID)
+	binary.LittleEndian.PutUint64(b[iota.OneByte+iota.UInt64ByteSize:], uint64(p.Timestamp))
+	return b, nil
+}
+
+// Verify checks whether the handshake was made by a peer of the given network speaking
+// the same protocol version at around the given time.
+func (p *HandshakePacket) Verify(networkID uint64, now time.Time) error {
+	if p.ProtocolVersion != ProtocolVersion {
+		return fmt.Errorf("%w: %d instead of %d", ErrProtocolVersionMismatch, p.ProtocolVersion, ProtocolVersion)
+	}
+	if p.NetworkID != networkID {
+		return fmt.Errorf("%w: %d instead of %d", ErrNetworkIDMismatch, p.NetworkID, networkID)
+	}
+	skew := now.Sub(time.Unix(0, p.Timestamp))
+	if skew > MaxHandshakeTimeSkew || skew < -MaxHandshakeTimeSkew {
+		return fmt.Errorf("%w: %v", ErrHandshakeTimeSkew, skew)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+// Package gossip implements the exchange of messages between nodes and their peers.
+// Packets are framed by a header denoting their type and length. A Manager moves them between
+// nodes over handshaked TCP connections, and a RequestQueue keeps track of the messages to request.
+package gossip
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/luca-moser/iota"
+)
+
+const (
+	// The size of a packet header: packet type + packet length.
+	HeaderSize = iota.SmallTypeDenotationByteSize + iota.UInt16ByteSize
+)
+
+var (
+	ErrUnknownPacketType    = errors.New("unknown packet type")
+	ErrInvalidPacketLength  = errors.New("packet length is invalid for the packet type")
+	ErrPacketTypeMismatch   = errors.New("packet type doesn't match")
+	ErrPacketNotAllConsumed = errors.New("packet data wasn't entirely consumed")
+)
+
+// PacketType defines the type of a packet.
+type PacketType = byte
+
+// Header frames a packet on the wire.
+type Header struct {
+	// The type of the packet.
+	Type PacketType
+	// The length of the packet's data following the header.
+	Length uint16
+}
+
+func (h *Header) Deserialize(data []byte, deSeriMode iota.DeSerializationMode) (int, error) {
+	if len(data) < HeaderSize {
+		return 0, fmt.Errorf("%w: packet header requires %d bytes but only %d are available", iota.ErrDeserializationNotEnoughData, HeaderSize, len(data))
+	}
+	h.Type = data[0]
+	h.Length = binary.LittleEndian.Uint16(data[iota.SmallTypeDenotationByteSize:])
+	if deSeriMode.HasMode(iota.DeSeriModePerformValidation) {
+		if err := h.validate(); err != nil {
+			return 0, err
+		}
+	}
+	return HeaderSize, nil
+}
+
+func (h *Header) Serialize(deSeriMode iota.DeSerializationMode) ([]byte, error) {
+	if deSeriMode.HasMode(iota.DeSeriModePerformValidation) {
+		if err := h.validate(); err != nil {
+			return nil, err
+		}
+	}
+	var b [HeaderSize]byte
+	b[0] = h.Type
+	binary.LittleEndian.PutUint16(b[iota.SmallTypeDenotationByteSize:], h.Length)
+	return b[:], nil
+}
+
+// validate checks whether the header's length is within the bounds of its packet type.
+func (h *Header) validate() error {
+	def, has := definitions[h.Type]
+	if !has {
+		return fmt.Errorf("%w: %d", ErrUnknownPacketType, h.Type)
+	}
+	if h.Length < def.MinLength || h.Length > def.MaxLength {
+		return fmt.Errorf("%w: packet type %d allows %d to %d bytes but denotes %d", ErrInvalidPacketLength, h.Type, def.MinLength, def.MaxLength, h.Length)
+	}
+	return nil
+}
+
+// Packet is a Serializable which can be sent to peers.
+// Its serialized form excludes the header, which is added by Frame.
+type Packet interface {
+	iota.Serializable
+	// PacketType returns the type of the packet.
+	PacketType() PacketType
+}
+
+// Frame serializes the given packet and prefixes it with its header.
+func Frame(packet Packet, deSeriMode iota.DeSerializationMode) ([]byte, error) {
+	data, err := packet.Serialize(deSeriMode)
+	if err != nil {
+		return nil, fmt.Errorf("unable to serialize packet of type %d: %w", packet.PacketType(), err)
+	}
+	if len(data) > int(^uint16(0)) {
+		return nil, fmt.Errorf("%w: packet of type %d is %d bytes long", ErrInvalidPacketLength, packet.PacketType(), len(data))
+	}
+
+	header := &Header{Type: packet.PacketType(), Length: uint16(len(data))}
+	headerBytes, err := header.Serialize(deSeriMode)
+	if err != nil {
+		return nil, err
+	}
+	return append(headerBytes, data...), nil
+}
+
+// ParsePacket parses the framed packet at the start of the given data and returns it together with the
+// amount of bytes consumed. The header is always validated against the packet type's length bounds.
+func ParsePacket(data []byte, deSeriMode iota.DeSerializationMode) (Packet, int, error) {
+	header := &Header{}
+	if _, err := header.Deserialize(data, iota.DeSeriModePerformValidation); err != nil {
+		return nil, 0, err
+	}
+	data = data[HeaderSize:]
+	if len(data) < int(header.Length) {
+		return nil, 0, fmt.Errorf("%w: packet of type %d denotes %d bytes but only %d are available", iota.ErrDeserializationNotEnoughData, header.Type, header.Length, len(data))
+	}
+
+	packet, err := parsePacketData(header, data[:header.Length], deSeriMode)
+	if err != nil {
+		return nil, 0, err
+	}
+	return packet, HeaderSize + int(header.Length), nil
+}
+
+// ReadPacket reads the next framed packet from the given reader.
+// The header is validated before the packet's data is read, so a peer can't make the reader
+// allocate more than the packet type's maximum length.
+func ReadPacket(r io.Reader, deSeriMode iota.DeSerializationMode) (Packet, error) {
+	var headerBytes [HeaderSize]byte
+	if _, err := io.ReadFull(r, headerBytes[:]); err != nil {
+		return nil, err
+	}
+	header := &Header{}
+	if _, err := header.Deserialize(headerBytes[:], iota.DeSeriModePerformValidation); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, header.Length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return parsePacketData(header, data, deSeriMode)
+}
+
+// parsePacketData deserializes the data of the packet denoted by the given header, which must consume all of it.
+func parsePacketData(header *Header, data []byte, deSeriMode iota.DeSerializationMode) (Packet, error) {
+	packet := definitions[header.Type].new()
+	bytesRead, err := packet.Deserialize(data, deSeriMode)
+	if err != nil {
+		return nil, fmt.Errorf("unable to deserialize packet of type %d: %w", header.Type, err)
+	}
+	if bytesRead != len(data) {
+		return nil, fmt.Errorf("%w: packet of type %d consumed %d of %d bytes", ErrPacketNotAllConsumed, header.Type, bytesRead, len(data))
+	}
+	return packet, nil
+}
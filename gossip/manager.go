@@ -0,0 +1,256 @@
+package gossip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// PacketHandlerFunc handles a packet received from the given peer.
+// Handlers are called sequentially per peer from the peer's read goroutine, so a slow handler
+// slows down reading from that peer.
+type PacketHandlerFunc func(peer *Peer, packet Packet)
+
+// PeerCallbackFunc is called when the given peer connected or disconnected.
+type PeerCallbackFunc func(peer *Peer)
+
+// Manager accepts and establishes TCP connections to peers of the same network,
+// handshakes them and dispatches the packets they send to the registered handlers.
+// It is safe for concurrent use.
+type Manager struct {
+	networkID     uint64
+	sendQueueSize int
+
+	mu        sync.RWMutex
+	closed    bool
+	listeners []net.Listener
+	peers     map[string]*Peer
+	wg        sync.WaitGroup
+
+	handlersMu            sync.RWMutex
+	handlers              map[PacketType][]PacketHandlerFunc
+	connectedCallbacks    []PeerCallbackFunc
+	disconnectedCallbacks []PeerCallbackFunc
+}
+
+// NewManager creates a new Manager for the given network.
+// sendQueueSize defines how many packets can be queued per peer, DefaultSendQueueSize is used if it is not positive.
+func NewManager(networkID uint64, sendQueueSize int) *Manager {
+	if sendQueueSize <= 0 {
+		sendQueueSize = DefaultSendQueueSize
+	}
+	return &Manager{
+		networkID:     networkID,
+		sendQueueSize: sendQueueSize,
+		peers:         make(map[string]*Peer),
+		handlers:      make(map[PacketType][]PacketHandlerFunc),
+	}
+}
+
+// On registers the given handler for packets of the given type.
+func (m *Manager) On(packetType PacketType, f PacketHandlerFunc) {
+	m.handlersMu.Lock()
+	defer m.handlersMu.Unlock()
+	m.handlers[packetType] = append(m.handlers[packetType], f)
+}
+
+// OnConnected registers the given callback to be called whenever a peer completed the handshake.
+func (m *Manager) OnConnected(f PeerCallbackFunc) {
+	m.handlersMu.Lock()
+	defer m.handlersMu.Unlock()
+	m.connectedCallbacks = append(m.connectedCallbacks, f)
+}
+
+// OnDisconnected registers the given callback to be called whenever a peer disconnected.
+func (m *Manager) OnDisconnected(f PeerCallbackFunc) {
+	m.handlersMu.Lock()
+	defer m.handlersMu.Unlock()
+	m.disconnectedCallbacks = append(m.disconnectedCallbacks, f)
+}
+
+// Listen accepts connections on the given TCP address and returns the address it listens on.
+// Accepting continues in the background until the Manager is closed.
+func (m *Manager) Listen(address string) (net.Addr, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		_ = listener.Close()
+		return nil, ErrManagerClosed
+	}
+	m.listeners = append(m.listeners, listener)
+	m.wg.Add(1)
+	m.mu.Unlock()
+
+	go func() {
+		defer m.wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			m.wg.Add(1)
+			go func() {
+				defer m.wg.Done()
+				// failed inbound handshakes are not actionable for the caller
+				_, _ = m.setup(conn)
+			}()
+		}
+	}()
+	return listener.Addr(), nil
+}
+
+// Connect establishes a connection to the peer at the given TCP address and handshakes it.
+func (m *Manager) Connect(ctx context.Context, address string) (*Peer, error) {
+	if m.IsClosed() {
+		return nil, ErrManagerClosed
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return m.setup(conn)
+}
+
+// setup handshakes the given connection and starts the peer's read and write goroutines.
+func (m *Manager) setup(conn net.Conn) (*Peer, error) {
+	peerHandshake, err := handshake(conn, m.networkID)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("handshake with %s failed: %w", conn.RemoteAddr(), err)
+	}
+	peer := newPeer(conn, peerHandshake, m.sendQueueSize)
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		_ = conn.Close()
+		return nil, ErrManagerClosed
+	}
+	if _, has := m.peers[peer.ID()]; has {
+		m.mu.Unlock()
+		_ = conn.Close()
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyPeered, peer.ID())
+	}
+	m.peers[peer.ID()] = peer
+	m.wg.Add(2)
+	m.mu.Unlock()
+
+	m.firePeer(true, peer)
+
+	go func() {
+		defer m.wg.Done()
+		peer.writeLoop()
+	}()
+	go func() {
+		defer m.wg.Done()
+		peer.readLoop(m.dispatch)
+		// make sure the write loop stops too
+		peer.close(nil)
+
+		m.mu.Lock()
+		delete(m.peers, peer.ID())
+		m.mu.Unlock()
+		m.firePeer(false, peer)
+	}()
+	return peer, nil
+}
+
+func (m *Manager) dispatch(peer *Peer, packet Packet) {
+	m.handlersMu.RLock()
+	handlers := m.handlers[packet.PacketType()]
+	m.handlersMu.RUnlock()
+	for _, f := range handlers {
+		f(peer, packet)
+	}
+}
+
+// firePeer calls the connected or disconnected callbacks with the given peer.
+func (m *Manager) firePeer(connected bool, peer *Peer) {
+	m.handlersMu.RLock()
+	callbacks := m.disconnectedCallbacks
+	if connected {
+		callbacks = m.connectedCallbacks
+	}
+	m.handlersMu.RUnlock()
+	for _, f := range callbacks {
+		f(peer)
+	}
+}
+
+// Peers returns the currently connected peers.
+func (m *Manager) Peers() []*Peer {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	peers := make([]*Peer, 0, len(m.peers))
+	for _, peer := range m.peers {
+		peers = append(peers, peer)
+	}
+	return peers
+}
+
+// Broadcast queues the given packet for every connected peer except the given ones without blocking.
+// Peers whose send queue is full miss the packet, the returned error then wraps ErrSendQueueFull.
+func (m *Manager) Broadcast(packet Packet, except ...*Peer) error {
+	var errs []error
+	for _, peer := range m.Peers() {
+		if contains(except, peer) {
+			continue
+		}
+		if err := peer.TrySend(packet); err != nil && !errors.Is(err, ErrPeerClosed) {
+			errs = append(errs, fmt.Errorf("%s: %w", peer.ID(), err))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("broadcast failed for %d peers: %w", len(errs), errs[0])
+}
+
+// IsClosed tells whether the Manager is closed.
+func (m *Manager) IsClosed() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.closed
+}
+
+// Close stops listening, disconnects all peers and waits for all goroutines to finish.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return ErrManagerClosed
+	}
+	m.closed = true
+	listeners := m.listeners
+	peers := make([]*Peer, 0, len(m.peers))
+	for _, peer := range m.peers {
+		peers = append(peers, peer)
+	}
+	m.mu.Unlock()
+
+	for _, listener := range listeners {
+		_ = listener.Close()
+	}
+	for _, peer := range peers {
+		_ = peer.Close()
+	}
+	m.wg.Wait()
+	return nil
+}
+
+func contains(peers []*Peer, peer *Peer) bool {
+	for _, p := range peers {
+		if p == peer {
+			return true
+		}
+	}
+	return false
+}
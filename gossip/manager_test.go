@@ -0,0 +1,228 @@
+package gossip_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/luca-moser/iota"
+	"github.com/luca-moser/iota/gossip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testNetworkID = 1337
+
+// node is a minimal node which floods every message it sees for the first time to its other peers.
+type node struct {
+	t       *testing.T
+	manager *gossip.Manager
+	addr    string
+
+	mu   sync.Mutex
+	seen map[iota.MessageID]struct{}
+}
+
+func newNode(t *testing.T, networkID uint64) *node {
+	n := &node{t: t, manager: gossip.NewManager(networkID, gossip.DefaultSendQueueSize), seen: make(map[iota.MessageID]struct{})}
+	addr, err := n.manager.Listen("127.0.0.1:0")
+	require.NoError(t, err)
+	n.addr = addr.String()
+	n.manager.On(gossip.PacketMessage, func(peer *gossip.Peer, packet gossip.Packet) {
+		msgPacket := packet.(*gossip.MessagePacket)
+		if n.see(msgPacket.Message) {
+			assert.NoError(t, n.manager.Broadcast(msgPacket, peer))
+		}
+	})
+	t.Cleanup(func() { _ = n.manager.Close() })
+	return n
+}
+
+// see records the given message and returns whether it wasn't seen before.
+func (n *node) see(msg *iota.Message) bool {
+	id, err := msg.ID()
+	require.NoError(n.t, err)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, has := n.seen[id]; has {
+		return false
+	}
+	n.seen[id] = struct{}{}
+	return true
+}
+
+func (n *node) seenCount() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.seen)
+}
+
+func (n *node) connect(other *node) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	peer, err := n.manager.Connect(ctx, other.addr)
+	require.NoError(n.t, err)
+	assert.EqualValues(n.t, testNetworkID, peer.Handshake().NetworkID)
+}
+
+func (n *node) peerCount() int {
+	return len(n.manager.Peers())
+}
+
+func TestManager_Gossip(t *testing.T) {
+	const nodeCount = 5
+	const msgCount = 300
+
+	nodes := make([]*node, nodeCount)
+	for i := range nodes {
+		nodes[i] = newNode(t, testNetworkID)
+	}
+	// a ring with an additional chord, so messages arrive over multiple paths
+	for i := range nodes {
+		nodes[i].connect(nodes[(i+1)%nodeCount])
+	}
+	nodes[0].connect(nodes[2])
+
+	require.Eventually(t, func() bool {
+		return nodes[0].peerCount() == 3 && nodes[1].peerCount() == 2 && nodes[2].peerCount() == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	for i := 0; i < msgCount; i++ {
+		msg := testMessage(i)
+		msg.Nonce = uint64(i)
+		require.True(t, nodes[0].see(msg))
+		require.NoError(t, nodes[0].manager.Broadcast(&gossip.MessagePacket{Message: msg}))
+	}
+
+	require.Eventually(t, func() bool {
+		for _, n := range nodes {
+			if n.seenCount() != msgCount {
+				return false
+			}
+		}
+		return true
+	}, 10*time.Second, 10*time.Millisecond)
+
+	for _, peer := range nodes[0].manager.Peers() {
+		stats := peer.Stats()
+		assert.Zero(t, stats.PacketsDropped)
+		assert.NotZero(t, stats.PacketsSent)
+	}
+}
+
+func TestManager_HandshakeNetworkMismatch(t *testing.T) {
+	a, b := newNode(t, testNetworkID), newNode(t, testNetworkID+1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := a.manager.Connect(ctx, b.addr)
+	assert.True(t, errors.Is(err, gossip.ErrNetworkIDMismatch))
+	assert.Zero(t, a.peerCount())
+	assert.Zero(t, b.peerCount())
+}
+
+func TestManager_Disconnect(t *testing.T) {
+	a, b := newNode(t, testNetworkID), newNode(t, testNetworkID)
+
+	disconnected := make(chan *gossip.Peer, 1)
+	b.manager.OnDisconnected(func(peer *gossip.Peer) {
+		disconnected <- peer
+	})
+
+	a.connect(b)
+	require.Eventually(t, func() bool { return b.peerCount() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, a.manager.Close())
+	select {
+	case peer := <-disconnected:
+		assert.Error(t, peer.Err())
+	case <-time.After(5 * time.Second):
+		t.Fatal("peer didn't disconnect")
+	}
+	assert.Zero(t, b.peerCount())
+
+	_, err := a.manager.Connect(context.Background(), b.addr)
+	assert.True(t, errors.Is(err, gossip.ErrManagerClosed))
+}
+
+func TestPeer_Backpressure(t *testing.T) {
+	sender := gossip.NewManager(testNetworkID, 1)
+	t.Cleanup(func() { _ = sender.Close() })
+
+	receiver := gossip.NewManager(testNetworkID, 1)
+	t.Cleanup(func() { _ = receiver.Close() })
+	addr, err := receiver.Listen("127.0.0.1:0")
+	require.NoError(t, err)
+
+	// the receiver stops reading until released
+	release := make(chan struct{})
+	var releaseOnce sync.Once
+	releaseReceiver := func() { releaseOnce.Do(func() { close(release) }) }
+	t.Cleanup(releaseReceiver)
+	var received int64
+	receiver.On(gossip.PacketMessage, func(*gossip.Peer, gossip.Packet) {
+		<-release
+		atomic.AddInt64(&received, 1)
+	})
+
+	peer, err := sender.Connect(context.Background(), addr.String())
+	require.NoError(t, err)
+
+	// fill the socket buffers and the send queue until a blocking send times out
+	packet := &gossip.MessagePacket{Message: testMessage(gossip.MaxMessageSize - 200)}
+	var sent int64
+	blocked := false
+	for i := 0; i < 100_000 && !blocked; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		err := peer.Send(ctx, packet)
+		cancel()
+		if err == nil {
+			sent++
+			continue
+		}
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+		blocked = true
+	}
+	require.True(t, blocked, "send never blocked")
+
+	// with a full send queue, non-blocking sends drop the packet
+	assert.True(t, errors.Is(peer.TrySend(packet), gossip.ErrSendQueueFull))
+	assert.EqualValues(t, 1, peer.Stats().PacketsDropped)
+
+	// once the receiver reads again, blocking sends go through
+	releaseReceiver()
+	require.NoError(t, peer.Send(context.Background(), packet))
+	sent++
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt64(&received) == sent
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
+func TestHandshakePacket_Verify(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		handshake *gossip.HandshakePacket
+		err       error
+	}{
+		{"ok", &gossip.HandshakePacket{ProtocolVersion: gossip.ProtocolVersion, NetworkID: testNetworkID, Timestamp: now.UnixNano()}, nil},
+		{"version", &gossip.HandshakePacket{ProtocolVersion: gossip.ProtocolVersion + 1, NetworkID: testNetworkID, Timestamp: now.UnixNano()}, gossip.ErrProtocolVersionMismatch},
+		{"network", &gossip.HandshakePacket{ProtocolVersion: gossip.ProtocolVersion, NetworkID: 1, Timestamp: now.UnixNano()}, gossip.ErrNetworkIDMismatch},
+		{"past", &gossip.HandshakePacket{ProtocolVersion: gossip.ProtocolVersion, NetworkID: testNetworkID, Timestamp: now.Add(-time.Minute).UnixNano()}, gossip.ErrHandshakeTimeSkew},
+		{"future", &gossip.HandshakePacket{ProtocolVersion: gossip.ProtocolVersion, NetworkID: testNetworkID, Timestamp: now.Add(time.Minute).UnixNano()}, gossip.ErrHandshakeTimeSkew},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.handshake.Verify(testNetworkID, now)
+			if tt.err == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, tt.err))
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package gossip
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/luca-moser/iota"
+)
+
+const (
+	// Denotes a HandshakePacket.
+	PacketHandshake PacketType = 0
+	// Denotes a MessagePacket.
+	PacketMessage PacketType = 1
+	// Denotes a MessageRequestPacket.
+	PacketMessageRequest PacketType = 2
+	// Denotes a HeartbeatPacket.
+	PacketHeartbeat PacketType = 3
+	// Denotes a MilestoneRequestPacket.
+	PacketMilestoneRequest PacketType = 4
+)
+
+const (
+	// The maximum size of a serialized message.
+	MaxMessageSize = 32768
+	// The size of a MessageRequestPacket: the requested message ID.
+	MessageRequestPacketSize = iota.MessageHashLength
+	// The size of a HeartbeatPacket: solid, pruned and latest milestone index + connected and synced peers.
+	HeartbeatPacketSize = 3*iota.UInt64ByteSize + 2*iota.OneByte
+	// The size of a MilestoneRequestPacket: the requested milestone index.
+	MilestoneRequestPacketSize = iota.UInt64ByteSize
+	// The milestone index which requests the latest milestone.
+	LatestMilestoneIndex = 0
+)
+
+// definition describes the length bounds of a packet type and how to create an empty instance of it.
+type definition struct {
+	MinLength uint16
+	MaxLength uint16
+	new       func() Packet
+}
+
+var definitions = map[PacketType]definition{
+	PacketHandshake:        {MinLength: HandshakePacketSize, MaxLength: HandshakePacketSize, new: func() Packet { return &HandshakePacket{} }},
+	PacketMessage:          {MinLength: iota.MessageMinSize, MaxLength: MaxMessageSize, new: func() Packet { return &MessagePacket{} }},
+	PacketMessageRequest:   {MinLength: MessageRequestPacketSize, MaxLength: MessageRequestPacketSize, new: func() Packet { return &MessageRequestPacket{} }},
+	PacketHeartbeat:        {MinLength: HeartbeatPacketSize, MaxLength: HeartbeatPacketSize, new: func() Packet { return &HeartbeatPacket{} }},
+	PacketMilestoneRequest: {MinLength: MilestoneRequestPacketSize, MaxLength: MilestoneRequestPacketSize, new: func() Packet { return &MilestoneRequestPacket{} }},
+}
+
+// MaxPacketLength returns the maximum length of the data of the given packet type.
+func MaxPacketLength(packetType PacketType) (uint16, error) {
+	def, has := definitions[packetType]
+	if !has {
+		return 0, fmt.Errorf("%w: %d", ErrUnknownPacketType, packetType)
+	}
+	return def.MaxLength, nil
+}
+
+// checkPacketLength checks whether the given data length is within the bounds of the given packet type.
+func checkPacketLength(packetType PacketType, length int) error {
+	def := definitions[packetType]
+	if length < int(def.MinLength) || length > int(def.MaxLength) {
+		return fmt.Errorf("%w: packet type %d allows %d to %d bytes but has %d", ErrInvalidPacketLength, packetType, def.MinLength, def.MaxLength, length)
+	}
+	return nil
+}
+
+// MessagePacket carries a message.
+type MessagePacket struct {
+	Message *iota.Message `json:"message"`
+}
+
+func (p *MessagePacket) PacketType() PacketType {
+	return PacketMessage
+}
+
+func (p *MessagePacket) Deserialize(data []byte, deSeriMode iota.DeSerializationMode) (int, error) {
+	if deSeriMode.HasMode(iota.DeSeriModePerformValidation) {
+		if err := checkPacketLength(PacketMessage, len(data)); err != nil {
+			return 0, err
+		}
+	}
+	msg := &iota.Message{}
+	bytesRead, err := msg.Deserialize(data, deSeriMode)
+	if err != nil {
+		return 0, err
+	}
+	p.Message = msg
+	return bytesRead, nil
+}
+
+func (p *MessagePacket) Serialize(deSeriMode iota.DeSerializationMode) ([]byte, error) {
+	data, err := p.Message.Serialize(deSeriMode)
+	if err != nil {
+		return nil, err
+	}
+	if deSeriMode.HasMode(iota.DeSeriModePerformValidation) {
+		if err := checkPacketLength(PacketMessage, len(data)); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// MessageRequestPacket requests the message with the given ID.
+type MessageRequestPacket struct {
+	MessageID iota.MessageID `json:"message_id"`
+}
+
+func (p *MessageRequestPacket) PacketType() PacketType {
+	return PacketMessageRequest
+}
+
+func (p *MessageRequestPacket) Deserialize(data []byte, deSeriMode iota.DeSerializationMode) (int, error) {
+	if err := checkMinLength(MessageRequestPacketSize, len(data)); err != nil {
+		return 0, err
+	}
+	copy(p.MessageID[:], data[:MessageRequestPacketSize])
+	return MessageRequestPacketSize, nil
+}
+
+func (p *MessageRequestPacket) Serialize(deSeriMode iota.DeSerializationMode) ([]byte, error) {
+	b := make([]byte, MessageRequestPacketSize)
+	copy(b, p.MessageID[:])
+	return b, nil
+}
+
+// HeartbeatPacket informs peers about the state of the node.
+type HeartbeatPacket struct {
+	// The index of the latest solid milestone.
+	SolidMilestoneIndex uint64 `json:"solid_milestone_index"`
+	// The index of the oldest milestone the node still holds.
+	PrunedMilestoneIndex uint64 `json:"pruned_milestone_index"`
+	// The index of the latest milestone the node knows.
+	LatestMilestoneIndex uint64 `json:"latest_milestone_index"`
+	// The amount of peers the node is connected to.
+	ConnectedPeers byte `json:"connected_peers"`
+	// The amount of connected peers which are synchronized.
+	SyncedPeers byte `json:"synced_peers"`
+}
+
+func (p *HeartbeatPacket) PacketType() PacketType {
+	return PacketHeartbeat
+}
+
+func (p *HeartbeatPacket) Deserialize(data []byte, deSeriMode iota.DeSerializationMode) (int, error) {
+	if err := checkMinLength(HeartbeatPacketSize, len(data)); err != nil {
+		return 0, err
+	}
+	p.SolidMilestoneIndex = binary.LittleEndian.Uint64(data)
+	p.PrunedMilestoneIndex = binary.LittleEndian.Uint64(data[iota.UInt64ByteSize:])
+	p.LatestMilestoneIndex = binary.LittleEndian.Uint64(data[2*iota.UInt64ByteSize:])
+	p.ConnectedPeers = data[3*iota.UInt64ByteSize]
+	p.SyncedPeers = data[3*iota.UInt64ByteSize+iota.OneByte]
+
+	if deSeriMode.HasMode(iota.DeSeriModePerformValidation) {
+		if err := p.validate(); err != nil {
+			return 0, err
+		}
+	}
+	return HeartbeatPacketSize, nil
+}
+
+func (p *HeartbeatPacket) Serialize(deSeriMode iota.DeSerializationMode) ([]byte, error) {
+	if deSeriMode.HasMode(iota.DeSeriModePerformValidation) {
+		if err := p.validate(); err != nil {
+			return nil, err
+		}
+	}
+	b := make([]byte, HeartbeatPacketSize)
+	binary.LittleEndian.PutUint64(b, p.SolidMilestoneIndex)
+	binary.LittleEndian.PutUint64(b[iota.UInt64ByteSize:], p.PrunedMilestoneIndex)
+	binary.LittleEndian.PutUint64(b[2*iota.UInt64ByteSize:], p.LatestMilestoneIndex)
+	b[3*iota.UInt64ByteSize] = p.ConnectedPeers
+	b[3*iota.UInt64ByteSize+iota.OneByte] = p.SyncedPeers
+	return b, nil
+}
+
+// validate checks whether the milestone indices and peer counts are consistent.
+func (p *HeartbeatPacket) validate() error {
+	if p.PrunedMilestoneIndex > p.SolidMilestoneIndex || p.SolidMilestoneIndex > p.LatestMilestoneIndex {
+		return fmt.Errorf("%w: heartbeat milestone indices must satisfy pruned (%d) <= solid (%d) <= latest (%d)",
+			iota.ErrInvalidBytes, p.PrunedMilestoneIndex, p.SolidMilestoneIndex, p.LatestMilestoneIndex)
+	}
+	if p.SyncedPeers > p.ConnectedPeers {
+		return fmt.Errorf("%w: heartbeat denotes more synced (%d) than connected (%d) peers", iota.ErrInvalidBytes, p.SyncedPeers, p.ConnectedPeers)
+	}
+	return nil
+}
+
+// MilestoneRequestPacket requests the milestone with the given index, LatestMilestoneIndex requests the latest one.
+type MilestoneRequestPacket struct {
+	MilestoneIndex uint64 `json:"milestone_index"`
+}
+
+func (p *MilestoneRequestPacket) PacketType() PacketType {
+	return PacketMilestoneRequest
+}
+
+func (p *MilestoneRequestPacket) Deserialize(data []byte, deSeriMode iota.DeSerializationMode) (int, error) {
+	if err := checkMinLength(MilestoneRequestPacketSize, len(data)); err != nil {
+		return 0, err
+	}
+	p.MilestoneIndex = binary.LittleEndian.Uint64(data)
+	return MilestoneRequestPacketSize, nil
+}
+
+func (p *MilestoneRequestPacket) Serialize(deSeriMode iota.DeSerializationMode) ([]byte, error) {
+	b := make([]byte, MilestoneRequestPacketSize)
+	binary.LittleEndian.PutUint64(b, p.MilestoneIndex)
+	return b, nil
+}
+
+func checkMinLength(min int, length int) error {
+	if length < min {
+		return fmt.Errorf("%w: data must be at least %d bytes long but is %d", iota.ErrDeserializationNotEnoughData, min, length)
+	}
+	return nil
+}
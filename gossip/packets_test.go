@@ -0,0 +1,127 @@
+package gossip_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/luca-moser/iota/gossip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMessage(dataSize int) *iota.Message {
+	return &iota.Message{
+		Parent1: iota.MessageID{1},
+		Parent2: iota.MessageID{2},
+		Payload: &iota.IndexationPayload{Index: "gossip", Data: bytes.Repeat([]byte{0xAB}, dataSize)},
+		Nonce:   42,
+	}
+}
+
+func TestPackets_FrameParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		packet gossip.Packet
+	}{
+		{"message", &gossip.MessagePacket{Message: testMessage(100)}},
+		{"message request", &gossip.MessageRequestPacket{MessageID: iota.MessageID{3, 4, 5}}},
+		{"heartbeat", &gossip.HeartbeatPacket{
+			SolidMilestoneIndex: 100, PrunedMilestoneIndex: 10, LatestMilestoneIndex: 105,
+			ConnectedPeers: 5, SyncedPeers: 3,
+		}},
+		{"milestone request", &gossip.MilestoneRequestPacket{MilestoneIndex: 1337}},
+		{"latest milestone request", &gossip.MilestoneRequestPacket{MilestoneIndex: gossip.LatestMilestoneIndex}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := gossip.Frame(tt.packet, iota.DeSeriModePerformValidation)
+			require.NoError(t, err)
+			assert.Equal(t, tt.packet.PacketType(), data[0])
+
+			packet, bytesRead, err := gossip.ParsePacket(data, iota.DeSeriModePerformValidation)
+			require.NoError(t, err)
+			assert.Equal(t, len(data), bytesRead)
+			assert.EqualValues(t, tt.packet, packet)
+
+			packet, err = gossip.ReadPacket(bytes.NewReader(data), iota.DeSeriModePerformValidation)
+			require.NoError(t, err)
+			assert.EqualValues(t, tt.packet, packet)
+		})
+	}
+}
+
+func TestPackets_ReadStream(t *testing.T) {
+	packets := []gossip.Packet{
+		&gossip.MessageRequestPacket{MessageID: iota.MessageID{1}},
+		&gossip.MessagePacket{Message: testMessage(10)},
+		&gossip.MilestoneRequestPacket{MilestoneIndex: 5},
+	}
+	var stream bytes.Buffer
+	for _, packet := range packets {
+		data, err := gossip.Frame(packet, iota.DeSeriModePerformValidation)
+		require.NoError(t, err)
+		stream.Write(data)
+	}
+
+	for _, expected := range packets {
+		packet, err := gossip.ReadPacket(&stream, iota.DeSeriModePerformValidation)
+		require.NoError(t, err)
+		assert.EqualValues(t, expected, packet)
+	}
+	_, err := gossip.ReadPacket(&stream, iota.DeSeriModePerformValidation)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestPackets_Invalid(t *testing.T) {
+	frame := func(packetType gossip.PacketType, data []byte) []byte {
+		return append([]byte{packetType, byte(len(data)), byte(len(data) >> 8)}, data...)
+	}
+	heartbeat := func(solid, pruned, latest uint64, connected, synced byte) []byte {
+		h := &gossip.HeartbeatPacket{SolidMilestoneIndex: solid, PrunedMilestoneIndex: pruned, LatestMilestoneIndex: latest, ConnectedPeers: connected, SyncedPeers: synced}
+		data, err := h.Serialize(iota.DeSeriModeNoValidation)
+		require.NoError(t, err)
+		return data
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"unknown type", frame(99, make([]byte, 8)), gossip.ErrUnknownPacketType},
+		{"message request too short", frame(gossip.PacketMessageRequest, make([]byte, 31)), gossip.ErrInvalidPacketLength},
+		{"message request too long", frame(gossip.PacketMessageRequest, make([]byte, 33)), gossip.ErrInvalidPacketLength},
+		{"milestone request too long", frame(gossip.PacketMilestoneRequest, make([]byte, 9)), gossip.ErrInvalidPacketLength},
+		{"message too short", frame(gossip.PacketMessage, make([]byte, 10)), gossip.ErrInvalidPacketLength},
+		{"message too long", frame(gossip.PacketMessage, make([]byte, gossip.MaxMessageSize+1)), gossip.ErrInvalidPacketLength},
+		{"truncated data", frame(gossip.PacketMilestoneRequest, make([]byte, 8))[:7], iota.ErrDeserializationNotEnoughData},
+		{"truncated header", []byte{gossip.PacketHeartbeat}, iota.ErrDeserializationNotEnoughData},
+		{"heartbeat solid above latest", frame(gossip.PacketHeartbeat, heartbeat(10, 0, 5, 1, 1)), iota.ErrInvalidBytes},
+		{"heartbeat more synced than connected", frame(gossip.PacketHeartbeat, heartbeat(10, 0, 10, 1, 2)), iota.ErrInvalidBytes},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := gossip.ParsePacket(tt.data, iota.DeSeriModePerformValidation)
+			assert.True(t, errors.Is(err, tt.err), "expected %v, got %v", tt.err, err)
+		})
+	}
+}
+
+func TestPackets_FrameTooLarge(t *testing.T) {
+	_, err := gossip.Frame(&gossip.MessagePacket{Message: testMessage(gossip.MaxMessageSize)}, iota.DeSeriModePerformValidation)
+	assert.True(t, errors.Is(err, gossip.ErrInvalidPacketLength))
+}
+
+func TestMaxPacketLength(t *testing.T) {
+	maxLength, err := gossip.MaxPacketLength(gossip.PacketMessage)
+	require.NoError(t, err)
+	assert.EqualValues(t, gossip.MaxMessageSize, maxLength)
+
+	_, err = gossip.MaxPacketLength(99)
+	assert.True(t, errors.Is(err, gossip.ErrUnknownPacketType))
+}
@@ -0,0 +1,258 @@
+package gossip
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/luca-moser/iota"
+)
+
+const (
+	// The default amount of packets which can be queued for sending to a peer.
+	DefaultSendQueueSize = 1000
+	// The time within which peers must complete the handshake.
+	HandshakeTimeout = 5 * time.Second
+)
+
+var (
+	ErrPeerClosed    = errors.New("peer is closed")
+	ErrSendQueueFull = errors.New("send queue of the peer is full")
+	ErrManagerClosed = errors.New("peering manager is closed")
+	ErrAlreadyPeered = errors.New("already peered with the given address")
+)
+
+// Peer is a handshaked connection to another node.
+// Packets sent to a peer are queued and written by a dedicated goroutine, so that a slow
+// peer only fills its own queue instead of blocking the sender.
+type Peer struct {
+	conn      net.Conn
+	handshake *HandshakePacket
+	sendQueue chan []byte
+	closing   chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+	closeMu   sync.Mutex
+
+	// guards the packet counters
+	statsMu         sync.Mutex
+	packetsSent     uint64
+	packetsReceived uint64
+	packetsDropped  uint64
+}
+
+func newPeer(conn net.Conn, handshake *HandshakePacket, sendQueueSize int) *Peer {
+	return &Peer{
+		conn:      conn,
+		handshake: handshake,
+		sendQueue: make(chan []byte, sendQueueSize),
+		closing:   make(chan struct{}),
+	}
+}
+
+// ID returns the identifier of the peer: the remote address of the connection.
+func (p *Peer) ID() string {
+	return p.conn.RemoteAddr().String()
+}
+
+// Handshake returns the handshake the peer sent.
+func (p *Peer) Handshake() *HandshakePacket {
+	return p.handshake
+}
+
+// Send queues the given packet for sending. If the send queue is full, Send blocks until
+// there is room, the context is done or the peer is closed. This applies backpressure onto senders
+// which must not lose packets.
+func (p *Peer) Send(ctx context.Context, packet Packet) error {
+	data, err := Frame(packet, iota.DeSeriModePerformValidation)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-p.closing:
+		return ErrPeerClosed
+	default:
+	}
+	select {
+	case p.sendQueue <- data:
+		return nil
+	case <-p.closing:
+		return ErrPeerClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TrySend queues the given packet for sending without blocking.
+// It returns ErrSendQueueFull and drops the packet if the send queue is full.
+func (p *Peer) TrySend(packet Packet) error {
+	data, err := Frame(packet, iota.DeSeriModePerformValidation)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-p.closing:
+		return ErrPeerClosed
+	default:
+	}
+	select {
+	case p.sendQueue <- data:
+		return nil
+	default:
+		p.statsMu.Lock()
+		p.packetsDropped++
+		p.statsMu.Unlock()
+		return ErrSendQueueFull
+	}
+}
+
+// PeerStats holds the packet counters of a peer.
+type PeerStats struct {
+	// The amount of packets written to the peer.
+	PacketsSent uint64
+	// The amount of packets received from the peer.
+	PacketsReceived uint64
+	// The amount of packets dropped because the send queue was full.
+	PacketsDropped uint64
+	// The amount of packets currently waiting in the send queue.
+	Queued int
+}
+
+// Stats returns the packet counters of the peer.
+func (p *Peer) Stats() PeerStats {
+	p.statsMu.Lock()
+	defer p.statsMu.Unlock()
+	return PeerStats{
+		PacketsSent:     p.packetsSent,
+		PacketsReceived: p.packetsReceived,
+		PacketsDropped:  p.packetsDropped,
+		Queued:          len(p.sendQueue),
+	}
+}
+
+// Close closes the connection to the peer. Packets still in the send queue are discarded.
+func (p *Peer) Close() error {
+	p.close(nil)
+	return nil
+}
+
+// Closed returns a channel which is closed once the peer is closed.
+func (p *Peer) Closed() <-chan struct{} {
+	return p.closing
+}
+
+// Err returns the error which caused the peer to be closed, nil if it was closed deliberately or is still open.
+func (p *Peer) Err() error {
+	p.closeMu.Lock()
+	defer p.closeMu.Unlock()
+	return p.closeErr
+}
+
+func (p *Peer) close(err error) {
+	p.closeOnce.Do(func() {
+		p.closeMu.Lock()
+		p.closeErr = err
+		p.closeMu.Unlock()
+		close(p.closing)
+		_ = p.conn.Close()
+	})
+}
+
+// writeLoop writes the queued packets to the connection until the peer is closed.
+func (p *Peer) writeLoop() {
+	w := bufio.NewWriter(p.conn)
+	for {
+		select {
+		case <-p.closing:
+			return
+		case data := <-p.sendQueue:
+			if _, err := w.Write(data); err != nil {
+				p.close(fmt.Errorf("unable to write to peer: %w", err))
+				return
+			}
+			sent := uint64(1)
+			// batch up everything which is already queued into a single flush
+		drain:
+			for {
+				select {
+				case data := <-p.sendQueue:
+					if _, err := w.Write(data); err != nil {
+						p.close(fmt.Errorf("unable to write to peer: %w", err))
+						return
+					}
+					sent++
+				default:
+					break drain
+				}
+			}
+			if err := w.Flush(); err != nil {
+				p.close(fmt.Errorf("unable to write to peer: %w", err))
+				return
+			}
+			p.statsMu.Lock()
+			p.packetsSent += sent
+			p.statsMu.Unlock()
+		}
+	}
+}
+
+// readLoop reads packets from the connection and passes them to dispatch until the peer is closed.
+func (p *Peer) readLoop(dispatch func(p *Peer, packet Packet)) {
+	r := bufio.NewReader(p.conn)
+	for {
+		packet, err := ReadPacket(r, iota.DeSeriModePerformValidation)
+		if err != nil {
+			select {
+			case <-p.closing:
+				// the error stems from closing the connection
+			default:
+				p.close(fmt.Errorf("unable to read from peer: %w", err))
+			}
+			return
+		}
+		p.statsMu.Lock()
+		p.packetsReceived++
+		p.statsMu.Unlock()
+		dispatch(p, packet)
+	}
+}
+
+// handshake sends the own handshake over the given connection and reads and verifies the peer's.
+func handshake(conn net.Conn, networkID uint64) (*HandshakePacket, error) {
+	if err := conn.SetDeadline(time.Now().Add(HandshakeTimeout)); err != nil {
+		return nil, err
+	}
+
+	data, err := Frame(NewHandshakePacket(networkID), iota.DeSeriModePerformValidation)
+	if err != nil {
+		return nil, err
+	}
+	// send and receive concurrently, the peer does the same
+	writeErr := make(chan error, 1)
+	go func() {
+		_, err := conn.Write(data)
+		writeErr <- err
+	}()
+
+	packet, err := ReadPacket(conn, iota.DeSeriModePerformValidation)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read handshake: %w", err)
+	}
+	if err := <-writeErr; err != nil {
+		return nil, fmt.Errorf("unable to send handshake: %w", err)
+	}
+
+	peerHandshake, ok := packet.(*HandshakePacket)
+	if !ok {
+		return nil, fmt.Errorf("%w: got packet type %d", ErrHandshakeExpected, packet.PacketType())
+	}
+	if err := peerHandshake.Verify(networkID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return peerHandshake, conn.SetDeadline(time.Time{})
+}
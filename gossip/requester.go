@@ -0,0 +1,322 @@
+package gossip
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/luca-moser/iota"
+	"github.com/luca-moser/iota/tangle"
+)
+
+const (
+	// The default time after which a request which wasn't answered is sent again.
+	DefaultRequestTimeout = 5 * time.Second
+	// The default upper bound of the time between two attempts of the same request.
+	DefaultMaxRequestBackoff = time.Minute
+)
+
+// Request is an outstanding request for a message.
+type Request struct {
+	// The ID of the requested message.
+	MessageID iota.MessageID
+	// The index of the milestone whose past cone needs the message.
+	MilestoneIndex uint64
+	// How often the request was sent so far.
+	Attempts int
+
+	// the order in which requests were enqueued, breaks ties between equal milestone indices
+	seq uint64
+	// when the request is due again if it is in flight
+	deadline time.Time
+	// the positions within the heaps, -1 if not contained
+	readyIndex    int
+	inFlightIndex int
+}
+
+// RequestFunc sends a request for the given message to peers.
+type RequestFunc func(req *Request)
+
+// RequestQueue keeps track of the messages which need to be requested from peers.
+// Requests are deduplicated by message ID and handed out in the order of their milestone index, lowest first.
+// A handed out request is in flight until it is answered by Received or its timeout elapses, in which case
+// it is handed out again. The timeout doubles with every attempt up to a maximum backoff.
+// Requests for messages below the solid entry points of a local snapshot are dropped.
+// It is safe for concurrent use.
+type RequestQueue struct {
+	mu          sync.Mutex
+	timeout     time.Duration
+	maxBackoff  time.Duration
+	requests    map[iota.MessageID]*Request
+	ready       readyHeap
+	inFlight    inFlightHeap
+	seq         uint64
+	cutoffIndex uint64
+	seps        map[iota.MessageID]struct{}
+}
+
+// NewRequestQueue creates a new RequestQueue. If timeout or maxBackoff are not positive,
+// DefaultRequestTimeout and DefaultMaxRequestBackoff are used.
+func NewRequestQueue(timeout time.Duration, maxBackoff time.Duration) *RequestQueue {
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxRequestBackoff
+	}
+	return &RequestQueue{
+		timeout:    timeout,
+		maxBackoff: maxBackoff,
+		requests:   make(map[iota.MessageID]*Request),
+		seps:       make(map[iota.MessageID]struct{}),
+	}
+}
+
+// LSHeaderConsumer returns an iota.LSHeaderConsumerFunc which drops requests below the milestone index of a local snapshot.
+func (q *RequestQueue) LSHeaderConsumer() iota.LSHeaderConsumerFunc {
+	return func(header *iota.LSFileHeader) error {
+		q.SetCutoff(header.MilestoneIndex)
+		return nil
+	}
+}
+
+// LSSEPConsumer returns an iota.LSSEPConsumerFunc which prevents the solid entry points of a local snapshot from being requested.
+func (q *RequestQueue) LSSEPConsumer() iota.LSSEPConsumerFunc {
+	return func(sep [iota.SolidEntryPointHashLength]byte) error {
+		q.AddSolidEntryPoint(sep)
+		return nil
+	}
+}
+
+// RequestMissing enqueues every message the given tangle reports as missing for the milestone index
+// milestoneIndex returns at that time, and answers requests once the tangle attaches the message.
+func (q *RequestQueue) RequestMissing(tngl *tangle.Tangle, milestoneIndex func() uint64) {
+	tngl.OnMissing(func(id iota.MessageID) {
+		// the message may have been attached since the tangle reported it as missing, in which case
+		// the attached callback already ran and doesn't answer the request anymore
+		if q.Enqueue(id, milestoneIndex()) && tngl.Contains(id) {
+			q.Received(id)
+		}
+	})
+	tngl.OnAttached(func(id iota.MessageID, _ *iota.Message) {
+		q.Received(id)
+	})
+}
+
+// Enqueue adds a request for the given message needed by the milestone with the given index and
+// returns whether it was newly added. Enqueuing a message which is already requested lowers the
+// request's milestone index to the given one if it is lower.
+func (q *RequestQueue) Enqueue(id iota.MessageID, milestoneIndex uint64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if milestoneIndex < q.cutoffIndex {
+		return false
+	}
+	if _, isSEP := q.seps[id]; isSEP {
+		return false
+	}
+
+	if req, has := q.requests[id]; has {
+		if milestoneIndex < req.MilestoneIndex {
+			req.MilestoneIndex = milestoneIndex
+			if req.readyIndex >= 0 {
+				heap.Fix(&q.ready, req.readyIndex)
+			}
+		}
+		return false
+	}
+
+	q.seq++
+	req := &Request{MessageID: id, MilestoneIndex: milestoneIndex, seq: q.seq, readyIndex: -1, inFlightIndex: -1}
+	q.requests[id] = req
+	heap.Push(&q.ready, req)
+	return true
+}
+
+// Next hands out the request with the lowest milestone index which is due at the given time and marks it as in flight.
+// It returns false if no request is due.
+func (q *RequestQueue) Next(now time.Time) (*Request, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// requests whose timeout elapsed are due again
+	for q.inFlight.Len() > 0 && !q.inFlight[0].deadline.After(now) {
+		req := heap.Pop(&q.inFlight).(*Request)
+		heap.Push(&q.ready, req)
+	}
+
+	if q.ready.Len() == 0 {
+		return nil, false
+	}
+	req := heap.Pop(&q.ready).(*Request)
+	req.Attempts++
+	req.deadline = now.Add(q.backoff(req.Attempts))
+	heap.Push(&q.inFlight, req)
+
+	reqCopy := *req
+	return &reqCopy, true
+}
+
+// backoff returns the timeout of the given attempt: the base timeout doubled for every previous attempt.
+func (q *RequestQueue) backoff(attempts int) time.Duration {
+	backoff := q.timeout
+	for i := 1; i < attempts && backoff < q.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > q.maxBackoff {
+		return q.maxBackoff
+	}
+	return backoff
+}
+
+// Received drops the request for the given message and returns whether it was requested.
+func (q *RequestQueue) Received(id iota.MessageID) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	req, has := q.requests[id]
+	if !has {
+		return false
+	}
+	q.remove(req)
+	return true
+}
+
+// SetCutoff drops every request for a milestone index lower than the given one and rejects such requests from now on.
+func (q *RequestQueue) SetCutoff(milestoneIndex uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.cutoffIndex = milestoneIndex
+	for _, req := range q.requests {
+		if req.MilestoneIndex < milestoneIndex {
+			q.remove(req)
+		}
+	}
+}
+
+// AddSolidEntryPoint drops the request for the given solid entry point and prevents it from being requested.
+func (q *RequestQueue) AddSolidEntryPoint(id iota.MessageID) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.seps[id] = struct{}{}
+	if req, has := q.requests[id]; has {
+		q.remove(req)
+	}
+}
+
+// IsRequested tells whether the given message is requested.
+func (q *RequestQueue) IsRequested(id iota.MessageID) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, has := q.requests[id]
+	return has
+}
+
+// Size returns the amount of outstanding requests and how many of them are in flight.
+func (q *RequestQueue) Size() (total int, inFlight int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.requests), q.inFlight.Len()
+}
+
+// Run hands every due request to the given RequestFunc until the context is done,
+// checking for due requests in the given interval.
+func (q *RequestQueue) Run(ctx context.Context, interval time.Duration, request RequestFunc) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for {
+			req, ok := q.Next(time.Now())
+			if !ok {
+				break
+			}
+			request(req)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// BroadcastRequests returns a RequestFunc which sends the requests to all peers of the given Manager.
+func BroadcastRequests(m *Manager) RequestFunc {
+	return func(req *Request) {
+		// a peer which missed the request receives it with the next attempt
+		_ = m.Broadcast(&MessageRequestPacket{MessageID: req.MessageID})
+	}
+}
+
+// remove drops the given request from the queue. The caller must hold the lock.
+func (q *RequestQueue) remove(req *Request) {
+	delete(q.requests, req.MessageID)
+	if req.readyIndex >= 0 {
+		heap.Remove(&q.ready, req.readyIndex)
+	}
+	if req.inFlightIndex >= 0 {
+		heap.Remove(&q.inFlight, req.inFlightIndex)
+	}
+}
+
+// readyHeap orders requests by their milestone index and then by the order in which they were enqueued.
+type readyHeap []*Request
+
+func (h readyHeap) Len() int { return len(h) }
+
+func (h readyHeap) Less(i, j int) bool {
+	if h[i].MilestoneIndex != h[j].MilestoneIndex {
+		return h[i].MilestoneIndex < h[j].MilestoneIndex
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h readyHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].readyIndex = i
+	h[j].readyIndex = j
+}
+
+func (h *readyHeap) Push(x interface{}) {
+	req := x.(*Request)
+	req.readyIndex = len(*h)
+	*h = append(*h, req)
+}
+
+func (h *readyHeap) Pop() interface{} {
+	old := *h
+	req := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	req.readyIndex = -1
+	return req
+}
+
+// inFlightHeap orders requests by the time they are due again.
+type inFlightHeap []*Request
+
+func (h inFlightHeap) Len() int { return len(h) }
+
+func (h inFlightHeap) Less(i, j int) bool { return h[i].deadline.Before(h[j].deadline) }
+
+func (h inFlightHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].inFlightIndex = i
+	h[j].inFlightIndex = j
+}
+
+func (h *inFlightHeap) Push(x interface{}) {
+	req := x.(*Request)
+	req.inFlightIndex = len(*h)
+	*h = append(*h, req)
+}
+
+func (h *inFlightHeap) Pop() interface{} {
+	old := *h
+	req := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	req.inFlightIndex = -1
+	return req
+}
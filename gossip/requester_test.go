@@ -0,0 +1,187 @@
+package gossip_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/luca-moser/iota"
+	"github.com/luca-moser/iota/gossip"
+	"github.com/luca-moser/iota/tangle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestQueue_Priority(t *testing.T) {
+	q := gossip.NewRequestQueue(time.Second, time.Minute)
+	now := time.Now()
+
+	assert.True(t, q.Enqueue(iota.MessageID{1}, 10))
+	assert.True(t, q.Enqueue(iota.MessageID{2}, 5))
+	assert.True(t, q.Enqueue(iota.MessageID{3}, 10))
+	assert.True(t, q.Enqueue(iota.MessageID{4}, 7))
+	// duplicates are ignored but can raise the priority
+	assert.False(t, q.Enqueue(iota.MessageID{1}, 20))
+	assert.False(t, q.Enqueue(iota.MessageID{3}, 6))
+
+	var order []iota.MessageID
+	for {
+		req, ok := q.Next(now)
+		if !ok {
+			break
+		}
+		assert.Equal(t, 1, req.Attempts)
+		order = append(order, req.MessageID)
+	}
+	assert.Equal(t, []iota.MessageID{{2}, {3}, {4}, {1}}, order)
+
+	total, inFlight := q.Size()
+	assert.Equal(t, 4, total)
+	assert.Equal(t, 4, inFlight)
+}
+
+func TestRequestQueue_Backoff(t *testing.T) {
+	q := gossip.NewRequestQueue(time.Second, 5*time.Second)
+	now := time.Now()
+	id := iota.MessageID{1}
+	require.True(t, q.Enqueue(id, 1))
+
+	// the request is due again after 1s, 2s, 4s and then every 5s
+	expectedBackoffs := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, backoff := range expectedBackoffs {
+		req, ok := q.Next(now)
+		require.True(t, ok)
+		assert.Equal(t, i+1, req.Attempts)
+
+		_, ok = q.Next(now.Add(backoff - time.Millisecond))
+		assert.False(t, ok, "request is due before its timeout elapsed")
+		now = now.Add(backoff)
+	}
+
+	assert.True(t, q.Received(id))
+	assert.False(t, q.Received(id))
+	_, ok := q.Next(now.Add(time.Hour))
+	assert.False(t, ok)
+	total, inFlight := q.Size()
+	assert.Zero(t, total)
+	assert.Zero(t, inFlight)
+}
+
+func TestRequestQueue_SolidEntryPoints(t *testing.T) {
+	q := gossip.NewRequestQueue(0, 0)
+	now := time.Now()
+
+	require.True(t, q.Enqueue(iota.MessageID{1}, 5))
+	require.True(t, q.Enqueue(iota.MessageID{2}, 10))
+	require.True(t, q.Enqueue(iota.MessageID{3}, 15))
+	require.True(t, q.Enqueue(iota.MessageID{4}, 20))
+	// put one request below the snapshot in flight
+	req, ok := q.Next(now)
+	require.True(t, ok)
+	require.Equal(t, iota.MessageID{1}, req.MessageID)
+
+	require.NoError(t, q.LSHeaderConsumer()(&iota.LSFileHeader{MilestoneIndex: 12}))
+	require.NoError(t, q.LSSEPConsumer()(iota.MessageID{3}))
+
+	assert.False(t, q.IsRequested(iota.MessageID{1}))
+	assert.False(t, q.IsRequested(iota.MessageID{2}))
+	assert.False(t, q.IsRequested(iota.MessageID{3}))
+	assert.True(t, q.IsRequested(iota.MessageID{4}))
+
+	// below the snapshot or solid entry point
+	assert.False(t, q.Enqueue(iota.MessageID{5}, 11))
+	assert.False(t, q.Enqueue(iota.MessageID{3}, 30))
+
+	req, ok = q.Next(now)
+	require.True(t, ok)
+	assert.Equal(t, iota.MessageID{4}, req.MessageID)
+	_, ok = q.Next(now.Add(time.Hour))
+	require.True(t, ok)
+	_, ok = q.Next(now.Add(time.Hour))
+	assert.False(t, ok)
+}
+
+func TestRequestQueue_RequestMissing(t *testing.T) {
+	genesis := iota.MessageID{}
+	tngl := tangle.New(genesis)
+	q := gossip.NewRequestQueue(0, 0)
+	q.RequestMissing(tngl, func() uint64 { return 7 })
+
+	parent := &iota.Message{Parent1: genesis, Parent2: genesis, Nonce: 1}
+	parentID, err := parent.ID()
+	require.NoError(t, err)
+	child := &iota.Message{Parent1: parentID, Parent2: genesis, Nonce: 2}
+
+	_, _, err = tngl.Attach(child)
+	require.NoError(t, err)
+	require.True(t, q.IsRequested(parentID))
+	req, ok := q.Next(time.Now())
+	require.True(t, ok)
+	assert.Equal(t, parentID, req.MessageID)
+	assert.EqualValues(t, 7, req.MilestoneIndex)
+
+	_, _, err = tngl.Attach(parent)
+	require.NoError(t, err)
+	assert.False(t, q.IsRequested(parentID))
+}
+
+func TestRequestQueue_RequestMissingAttachedMeanwhile(t *testing.T) {
+	genesis := iota.MessageID{}
+	tngl := tangle.New(genesis)
+	parent := &iota.Message{Parent1: genesis, Parent2: genesis, Nonce: 1}
+	parentID, err := parent.ID()
+	require.NoError(t, err)
+
+	// the parent is attached after the child reported it as missing but before the queue learns about it
+	tngl.OnMissing(func(id iota.MessageID) {
+		_, _, err := tngl.Attach(parent)
+		assert.NoError(t, err)
+	})
+	q := gossip.NewRequestQueue(0, 0)
+	q.RequestMissing(tngl, func() uint64 { return 7 })
+
+	_, _, err = tngl.Attach(&iota.Message{Parent1: parentID, Parent2: genesis, Nonce: 2})
+	require.NoError(t, err)
+	require.True(t, tngl.Contains(parentID))
+	assert.False(t, q.IsRequested(parentID))
+	total, _ := q.Size()
+	assert.Zero(t, total)
+}
+
+func TestRequestQueue_Run(t *testing.T) {
+	a, b := newNode(t, testNetworkID), newNode(t, testNetworkID)
+	// b answers requests for messages it has seen
+	stored := testMessage(10)
+	storedID, err := stored.ID()
+	require.NoError(t, err)
+	require.True(t, b.see(stored))
+	b.manager.On(gossip.PacketMessageRequest, func(peer *gossip.Peer, packet gossip.Packet) {
+		if packet.(*gossip.MessageRequestPacket).MessageID == storedID {
+			assert.NoError(t, peer.TrySend(&gossip.MessagePacket{Message: stored}))
+		}
+	})
+
+	q := gossip.NewRequestQueue(0, 0)
+	a.manager.On(gossip.PacketMessage, func(_ *gossip.Peer, packet gossip.Packet) {
+		id, err := packet.(*gossip.MessagePacket).Message.ID()
+		assert.NoError(t, err)
+		q.Received(id)
+	})
+	a.connect(b)
+	require.Eventually(t, func() bool { return b.peerCount() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.Run(ctx, 10*time.Millisecond, gossip.BroadcastRequests(a.manager))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	require.True(t, q.Enqueue(storedID, 1))
+	require.Eventually(t, func() bool { return !q.IsRequested(storedID) }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, a.seenCount())
+}
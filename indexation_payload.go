@@ -3,6 +3,8 @@ package iota
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 )
 
@@ -83,3 +85,35 @@ func (u *IndexationPayload) Serialize(deSeriMode DeSerializationMode) ([]byte, e
 
 	return b.Bytes(), nil
 }
+
+// jsonIndexationPayload defines the JSON form of an IndexationPayload.
+type jsonIndexationPayload struct {
+	Type  int    `json:"type"`
+	Index string `json:"index"`
+	Data  string `json:"data"`
+}
+
+func (u *IndexationPayload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonIndexationPayload{
+		Type:  int(IndexationPayloadID),
+		Index: u.Index,
+		Data:  hex.EncodeToString(u.Data),
+	})
+}
+
+func (u *IndexationPayload) UnmarshalJSON(data []byte) error {
+	jPayload := &jsonIndexationPayload{}
+	if err := json.Unmarshal(data, jPayload); err != nil {
+		return err
+	}
+	if err := checkJSONType(jPayload.Type, IndexationPayloadID); err != nil {
+		return fmt.Errorf("unable to decode indexation payload from JSON: %w", err)
+	}
+	payloadData, err := hex.DecodeString(jPayload.Data)
+	if err != nil {
+		return fmt.Errorf("%w: data is not valid hex: %v", ErrInvalidJSON, err)
+	}
+	u.Index = jPayload.Index
+	u.Data = payloadData
+	return nil
+}
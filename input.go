@@ -2,6 +2,8 @@ package iota
 
 import (
 	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -19,6 +21,8 @@ const (
 
 	// input type + tx id + index
 	UTXOInputSize = SmallTypeDenotationByteSize + TransactionIDLength + UInt16ByteSize
+	// tx id + index
+	OutputIDLength = TransactionIDLength + UInt16ByteSize
 )
 
 var (
@@ -87,6 +91,72 @@ func (u *UTXOInput) Serialize(deSeriMode DeSerializationMode) (data []byte, err
 	return b[:], nil
 }
 
+// OutputID returns the ID of the output the input references:
+// the transaction ID followed by the little endian output index.
+func (u *UTXOInput) OutputID() [OutputIDLength]byte {
+	var id [OutputIDLength]byte
+	copy(id[:TransactionIDLength], u.TransactionID[:])
+	binary.LittleEndian.PutUint16(id[TransactionIDLength:], u.TransactionOutputIndex)
+	return id
+}
+
+// OutputIDHex returns the hex encoded ID of the output the input references, see OutputID.
+func (u *UTXOInput) OutputIDHex() string {
+	id := u.OutputID()
+	return hex.EncodeToString(id[:])
+}
+
+// UTXOInputFromOutputID returns the UTXOInput referencing the output with the given ID.
+func UTXOInputFromOutputID(id [OutputIDLength]byte) *UTXOInput {
+	u := &UTXOInput{TransactionOutputIndex: binary.LittleEndian.Uint16(id[TransactionIDLength:])}
+	copy(u.TransactionID[:], id[:TransactionIDLength])
+	return u
+}
+
+// UTXOInputFromOutputIDHex returns the UTXOInput referencing the output with the given hex encoded ID.
+func UTXOInputFromOutputIDHex(outputID string) (*UTXOInput, error) {
+	id, err := hex.DecodeString(outputID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: output ID is not valid hex: %v", ErrInvalidBytes, err)
+	}
+	if err := checkExactByteLength(OutputIDLength, len(id)); err != nil {
+		return nil, fmt.Errorf("invalid output ID: %w", err)
+	}
+	var fixedID [OutputIDLength]byte
+	copy(fixedID[:], id)
+	return UTXOInputFromOutputID(fixedID), nil
+}
+
+// jsonUTXOInput defines the JSON form of a UTXOInput.
+type jsonUTXOInput struct {
+	Type                   int    `json:"type"`
+	TransactionID          string `json:"transaction_id"`
+	TransactionOutputIndex uint16 `json:"transaction_output_index"`
+}
+
+func (u *UTXOInput) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonUTXOInput{
+		Type:                   int(InputUTXO),
+		TransactionID:          hex.EncodeToString(u.TransactionID[:]),
+		TransactionOutputIndex: u.TransactionOutputIndex,
+	})
+}
+
+func (u *UTXOInput) UnmarshalJSON(data []byte) error {
+	jInput := &jsonUTXOInput{}
+	if err := json.Unmarshal(data, jInput); err != nil {
+		return err
+	}
+	if err := checkJSONType(jInput.Type, uint32(InputUTXO)); err != nil {
+		return fmt.Errorf("unable to decode UTXO input from JSON: %w", err)
+	}
+	if err := decodeHexJSON("transaction_id", jInput.TransactionID, u.TransactionID[:]); err != nil {
+		return err
+	}
+	u.TransactionOutputIndex = jInput.TransactionOutputIndex
+	return nil
+}
+
 // InputsValidatorFunc which given the index of an input and the input itself, runs validations and returns an error if any should fail.
 type InputsValidatorFunc func(index int, input *UTXOInput) error
 
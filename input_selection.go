@@ -0,0 +1,247 @@
+package iota
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// The default amount of branches the branch and bound input selection explores before giving up.
+	DefaultBranchAndBoundMaxTries = 100_000
+)
+
+var (
+	ErrInputSelectionInsufficientBalance = errors.New("the available unspent outputs don't cover the target amount")
+	ErrInputSelectionNoExactMatch        = errors.New("no combination of unspent outputs matches the target amount exactly")
+	ErrInputSelectionInvalidTarget       = errors.New("the target amount must be greater than zero and not exceed the total supply")
+)
+
+// UnspentOutput is an output which can be consumed by a transaction.
+type UnspentOutput struct {
+	// The reference to the output.
+	Input *UTXOInput `json:"input"`
+	// The address onto which the output deposits.
+	Address Serializable `json:"address"`
+	// The amount the output deposits.
+	Amount uint64 `json:"amount"`
+}
+
+// UnspentOutputsFromLS converts the given local snapshot unspent outputs into UnspentOutputs.
+func UnspentOutputsFromLS(txOutputs *LSTransactionUnspentOutputs) []*UnspentOutput {
+	outputs := make([]*UnspentOutput, len(txOutputs.UnspentOutputs))
+	for i, lsOutput := range txOutputs.UnspentOutputs {
+		outputs[i] = &UnspentOutput{
+			Input:   &UTXOInput{TransactionID: txOutputs.TransactionHash, TransactionOutputIndex: lsOutput.Index},
+			Address: lsOutput.Address,
+			Amount:  lsOutput.Value,
+		}
+	}
+	return outputs
+}
+
+// InputSelection is the result of an InputSelectionFunc.
+type InputSelection struct {
+	// The selected unspent outputs to consume.
+	Inputs []*UnspentOutput
+	// The sum of the selected unspent outputs.
+	Sum uint64
+	// The amount by which the selected unspent outputs exceed the target amount.
+	Remainder uint64
+}
+
+// InputSelectionFunc selects unspent outputs out of the available ones which cover the target amount.
+// It must not select more than MaxInputsCount unspent outputs and must not modify the given slice.
+type InputSelectionFunc func(available []*UnspentOutput, target uint64) (*InputSelection, error)
+
+// LargestFirstInputSelection returns an InputSelectionFunc which consumes the unspent outputs with the
+// largest amounts first, minimizing the amount of inputs.
+func LargestFirstInputSelection() InputSelectionFunc {
+	return func(available []*UnspentOutput, target uint64) (*InputSelection, error) {
+		if err := checkInputSelectionAvailable(available, target); err != nil {
+			return nil, err
+		}
+		sorted := sortedUnspentOutputs(available, func(a, b *UnspentOutput) bool { return a.Amount > b.Amount })
+		return accumulateInputSelection(sorted, target)
+	}
+}
+
+// SmallestFirstInputSelection returns an InputSelectionFunc which consumes the unspent outputs with the
+// smallest amounts first in order to consolidate them.
+func SmallestFirstInputSelection() InputSelectionFunc {
+	return func(available []*UnspentOutput, target uint64) (*InputSelection, error) {
+		if err := checkInputSelectionAvailable(available, target); err != nil {
+			return nil, err
+		}
+		sorted := sortedUnspentOutputs(available, func(a, b *UnspentOutput) bool { return a.Amount < b.Amount })
+		return accumulateInputSelection(sorted, target)
+	}
+}
+
+// RandomInputSelection returns an InputSelectionFunc which consumes the unspent outputs in a random order,
+// so that the selected outputs don't reveal which strategy picked them. If rng is nil, a source seeded from
+// crypto/rand is used. The returned InputSelectionFunc is safe for concurrent use.
+func RandomInputSelection(rng *rand.Rand) InputSelectionFunc {
+	if rng == nil {
+		rng = rand.New(rand.NewSource(randomSeed()))
+	}
+	var rngMu sync.Mutex
+	return func(available []*UnspentOutput, target uint64) (*InputSelection, error) {
+		if err := checkInputSelectionAvailable(available, target); err != nil {
+			return nil, err
+		}
+		shuffled := make([]*UnspentOutput, len(available))
+		copy(shuffled, available)
+		rngMu.Lock()
+		rng.Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+		rngMu.Unlock()
+		return accumulateInputSelection(shuffled, target)
+	}
+}
+
+// randomSeed returns a seed read from crypto/rand, falling back to the current time if it fails.
+func randomSeed() int64 {
+	var seed [8]byte
+	if _, err := cryptorand.Read(seed[:]); err != nil {
+		return time.Now().UnixNano()
+	}
+	return int64(binary.LittleEndian.Uint64(seed[:]))
+}
+
+// BranchAndBoundInputSelection returns an InputSelectionFunc which searches for a set of unspent outputs
+// whose sum matches the target amount exactly, so that no remainder output needs to be created.
+// The search gives up after exploring maxTries branches and then returns ErrInputSelectionNoExactMatch,
+// in which case callers usually fall back to a different strategy.
+func BranchAndBoundInputSelection(maxTries int) InputSelectionFunc {
+	if maxTries <= 0 {
+		maxTries = DefaultBranchAndBoundMaxTries
+	}
+	return func(available []*UnspentOutput, target uint64) (*InputSelection, error) {
+		if err := checkInputSelectionAvailable(available, target); err != nil {
+			return nil, err
+		}
+
+		sorted := sortedUnspentOutputs(available, func(a, b *UnspentOutput) bool { return a.Amount > b.Amount })
+
+		// remaining[i] is the sum of all amounts from index i onwards
+		remaining := make([]uint64, len(sorted)+1)
+		for i := len(sorted) - 1; i >= 0; i-- {
+			remaining[i] = remaining[i+1] + sorted[i].Amount
+		}
+
+		// explore the inclusion/exclusion tree depth-first with an explicit stack:
+		// every frame denotes the decision for the unspent output at index depth.
+		type frame struct {
+			depth    int
+			included bool
+		}
+		var stack []frame
+		var selected []int
+		var sum uint64
+		tries := 0
+
+		push := func(depth int) {
+			stack = append(stack, frame{depth: depth, included: true})
+			selected = append(selected, depth)
+			sum += sorted[depth].Amount
+		}
+
+		// backtrack flips the last inclusion into an exclusion, returns false if the tree is exhausted
+		backtrack := func() bool {
+			for len(stack) > 0 {
+				top := &stack[len(stack)-1]
+				if top.included {
+					top.included = false
+					selected = selected[:len(selected)-1]
+					sum -= sorted[top.depth].Amount
+					return true
+				}
+				stack = stack[:len(stack)-1]
+			}
+			return false
+		}
+
+		if len(sorted) == 0 {
+			return nil, ErrInputSelectionNoExactMatch
+		}
+		push(0)
+		for tries < maxTries {
+			tries++
+			top := stack[len(stack)-1]
+			next := top.depth + 1
+
+			switch {
+			case sum == target:
+				inputs := make([]*UnspentOutput, len(selected))
+				for i, index := range selected {
+					inputs[i] = sorted[index]
+				}
+				return &InputSelection{Inputs: inputs, Sum: sum}, nil
+			case sum > target, sum+remaining[next] < target:
+				// bound: overshot or can't reach the target anymore
+				if !backtrack() {
+					return nil, ErrInputSelectionNoExactMatch
+				}
+				continue
+			case next == len(sorted), len(selected) == MaxInputsCount:
+				// no further unspent output is left or may be included
+				if !backtrack() {
+					return nil, ErrInputSelectionNoExactMatch
+				}
+				continue
+			}
+			push(next)
+		}
+
+		return nil, fmt.Errorf("%w: gave up after %d tries", ErrInputSelectionNoExactMatch, maxTries)
+	}
+}
+
+// checks that the target is valid and the available unspent outputs can cover it.
+func checkInputSelectionAvailable(available []*UnspentOutput, target uint64) error {
+	if target == 0 || target > TokenSupply {
+		return fmt.Errorf("%w: target is %d", ErrInputSelectionInvalidTarget, target)
+	}
+	var total uint64
+	for _, output := range available {
+		total += output.Amount
+	}
+	if total < target {
+		return fmt.Errorf("%w: available %d, target %d", ErrInputSelectionInsufficientBalance, total, target)
+	}
+	return nil
+}
+
+// sortedUnspentOutputs returns a sorted copy of the given unspent outputs.
+func sortedUnspentOutputs(outputs []*UnspentOutput, less func(a, b *UnspentOutput) bool) []*UnspentOutput {
+	sorted := make([]*UnspentOutput, len(outputs))
+	copy(sorted, outputs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return less(sorted[i], sorted[j])
+	})
+	return sorted
+}
+
+// accumulateInputSelection consumes the given unspent outputs in order until the target is covered.
+func accumulateInputSelection(ordered []*UnspentOutput, target uint64) (*InputSelection, error) {
+	selection := &InputSelection{}
+	for _, output := range ordered {
+		if len(selection.Inputs) == MaxInputsCount {
+			return nil, fmt.Errorf("%w: covering %d needs more inputs", ErrMaxInputsExceeded, target)
+		}
+		selection.Inputs = append(selection.Inputs, output)
+		selection.Sum += output.Amount
+		if selection.Sum >= target {
+			selection.Remainder = selection.Sum - target
+			return selection, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: available %d, target %d", ErrInputSelectionInsufficientBalance, selection.Sum, target)
+}
@@ -0,0 +1,128 @@
+package iota_test
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func unspentOutputs(amounts ...uint64) []*iota.UnspentOutput {
+	outputs := make([]*iota.UnspentOutput, len(amounts))
+	addr, _ := randEd25519Addr()
+	for i, amount := range amounts {
+		input, _ := randUTXOInput()
+		outputs[i] = &iota.UnspentOutput{Input: input, Address: addr, Amount: amount}
+	}
+	return outputs
+}
+
+func amountsOf(selection *iota.InputSelection) []uint64 {
+	amounts := make([]uint64, len(selection.Inputs))
+	for i, input := range selection.Inputs {
+		amounts[i] = input.Amount
+	}
+	return amounts
+}
+
+func TestUnspentOutputsFromLS(t *testing.T) {
+	lsOutputs := randLSTransactionUnspentOutputs(3)
+	outputs := iota.UnspentOutputsFromLS(lsOutputs)
+	require.Len(t, outputs, 3)
+	for i, output := range outputs {
+		assert.Equal(t, lsOutputs.TransactionHash, output.Input.TransactionID)
+		assert.Equal(t, lsOutputs.UnspentOutputs[i].Index, output.Input.TransactionOutputIndex)
+		assert.Equal(t, lsOutputs.UnspentOutputs[i].Value, output.Amount)
+	}
+}
+
+func TestInputSelection(t *testing.T) {
+	type test struct {
+		name      string
+		selection iota.InputSelectionFunc
+		available []*iota.UnspentOutput
+		target    uint64
+		amounts   []uint64
+		remainder uint64
+		err       error
+	}
+	ones := func(count int) []uint64 {
+		amounts := make([]uint64, count)
+		for i := range amounts {
+			amounts[i] = 1
+		}
+		return amounts
+	}
+	tests := []test{
+		{"largest first", iota.LargestFirstInputSelection(), unspentOutputs(5, 50, 20, 1), 60, []uint64{50, 20}, 10, nil},
+		{"smallest first", iota.SmallestFirstInputSelection(), unspentOutputs(5, 50, 20, 1), 25, []uint64{1, 5, 20}, 1, nil},
+		{"branch and bound exact", iota.BranchAndBoundInputSelection(0), unspentOutputs(5, 50, 20, 1, 7), 32, []uint64{20, 7, 5}, 0, nil},
+		{"branch and bound no match", iota.BranchAndBoundInputSelection(0), unspentOutputs(10, 20, 40), 35, nil, 0, iota.ErrInputSelectionNoExactMatch},
+		{"branch and bound gives up", iota.BranchAndBoundInputSelection(1), unspentOutputs(10, 20, 40), 30, nil, 0, iota.ErrInputSelectionNoExactMatch},
+		{"branch and bound max inputs", iota.BranchAndBoundInputSelection(0), unspentOutputs(ones(iota.MaxInputsCount + 1)...), iota.MaxInputsCount, ones(iota.MaxInputsCount), 0, nil},
+		{"branch and bound too many inputs", iota.BranchAndBoundInputSelection(0), unspentOutputs(ones(iota.MaxInputsCount + 1)...), iota.MaxInputsCount + 1, nil, 0, iota.ErrInputSelectionNoExactMatch},
+		{"insufficient balance", iota.LargestFirstInputSelection(), unspentOutputs(5, 10), 20, nil, 0, iota.ErrInputSelectionInsufficientBalance},
+		{"zero target", iota.SmallestFirstInputSelection(), unspentOutputs(5, 10), 0, nil, 0, iota.ErrInputSelectionInvalidTarget},
+		{"too many inputs", iota.SmallestFirstInputSelection(), unspentOutputs(ones(iota.MaxInputsCount + 10)...), iota.MaxInputsCount + 1, nil, 0, iota.ErrMaxInputsExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selection, err := tt.selection(tt.available, tt.target)
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.amounts, amountsOf(selection))
+			assert.Equal(t, tt.remainder, selection.Remainder)
+			assert.Equal(t, tt.target+tt.remainder, selection.Sum)
+		})
+	}
+}
+
+func TestRandomInputSelection(t *testing.T) {
+	available := unspentOutputs(5, 50, 20, 1, 8, 13)
+	selection, err := iota.RandomInputSelection(rand.New(rand.NewSource(42)))(available, 40)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, selection.Sum, uint64(40))
+	assert.Equal(t, selection.Sum-40, selection.Remainder)
+
+	// must not have modified the available unspent outputs
+	assert.Equal(t, []uint64{5, 50, 20, 1, 8, 13}, amountsOf(&iota.InputSelection{Inputs: available}))
+}
+
+func TestRandomInputSelection_Unseeded(t *testing.T) {
+	amounts := make([]uint64, 50)
+	var total uint64
+	for i := range amounts {
+		amounts[i] = uint64(i + 1)
+		total += amounts[i]
+	}
+	available := unspentOutputs(amounts...)
+
+	// selections consuming every output only differ in their order, which must not be predictable
+	first, err := iota.RandomInputSelection(nil)(available, total)
+	require.NoError(t, err)
+	second, err := iota.RandomInputSelection(nil)(available, total)
+	require.NoError(t, err)
+	assert.NotEqual(t, amountsOf(first), amountsOf(second))
+
+	selection := iota.RandomInputSelection(nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_, err := selection(available, 100)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -60,6 +60,22 @@ func TestUTXOInput_Serialize(t *testing.T) {
 	}
 }
 
+func TestUTXOInput_OutputID(t *testing.T) {
+	input := &iota.UTXOInput{TransactionID: [iota.TransactionIDLength]byte{1, 2}, TransactionOutputIndex: 0x0102}
+	id := input.OutputID()
+	assert.Equal(t, []byte{1, 2}, id[:2])
+	// the output index is little endian
+	assert.Equal(t, []byte{0x02, 0x01}, id[iota.TransactionIDLength:])
+	assert.Equal(t, input, iota.UTXOInputFromOutputID(id))
+
+	fromHex, err := iota.UTXOInputFromOutputIDHex(input.OutputIDHex())
+	assert.NoError(t, err)
+	assert.Equal(t, input, fromHex)
+
+	_, err = iota.UTXOInputFromOutputIDHex("0102")
+	assert.Error(t, err)
+}
+
 func TestInputsValidatorFunc(t *testing.T) {
 	type args struct {
 		inputs []iota.Serializable
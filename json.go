@@ -0,0 +1,80 @@
+package iota
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// The JSON form of the objects mirrors their binary form: every polymorphic object carries its type denotation
+// in a "type" field and byte arrays are hex encoded. The nonce of a message, which usually exceeds the precision
+// of JSON numbers in other languages, is encoded as a decimal string. Other uint64 values such as amounts,
+// milestone indices and timestamps stay JSON numbers, as they fit into the 53 bits of precision.
+
+var (
+	ErrInvalidJSON = errors.New("invalid JSON")
+)
+
+// jsonTypeDenotation is used to read the type denotation of a polymorphic JSON object.
+type jsonTypeDenotation struct {
+	Type *int `json:"type"`
+}
+
+// DeserializeObjectFromJSON decodes the given polymorphic JSON object into the Serializable
+// which the given selector returns for the object's type denotation.
+func DeserializeObjectFromJSON(data json.RawMessage, serSel SerializableSelectorFunc) (Serializable, error) {
+	var typeDen jsonTypeDenotation
+	if err := json.Unmarshal(data, &typeDen); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+	}
+	if typeDen.Type == nil || *typeDen.Type < 0 {
+		return nil, fmt.Errorf("%w: object has no type denotation", ErrInvalidJSON)
+	}
+	seri, err := serSel(uint32(*typeDen.Type))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, seri); err != nil {
+		return nil, fmt.Errorf("unable to decode %T from JSON: %w", seri, err)
+	}
+	return seri, nil
+}
+
+// DeserializeArrayOfObjectsFromJSON decodes the given polymorphic JSON objects via DeserializeObjectFromJSON.
+func DeserializeArrayOfObjectsFromJSON(data []json.RawMessage, serSel SerializableSelectorFunc) (Serializables, error) {
+	seris := make(Serializables, 0, len(data))
+	for i, objData := range data {
+		seri, err := DeserializeObjectFromJSON(objData, serSel)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode object at index %d: %w", i, err)
+		}
+		seris = append(seris, seri)
+	}
+	return seris, nil
+}
+
+// isJSONNull tells whether the given JSON value is absent or null.
+func isJSONNull(data json.RawMessage) bool {
+	return len(data) == 0 || bytes.Equal(data, []byte("null"))
+}
+
+// checkJSONType checks whether the type denotation of a JSON object matches the expected type.
+func checkJSONType(actualType int, shouldType uint32) error {
+	if actualType != int(shouldType) {
+		return fmt.Errorf("%w: type denotation must be %d but is %d", ErrDeserializationTypeMismatch, shouldType, actualType)
+	}
+	return nil
+}
+
+// decodeHexJSON decodes the given hex encoded field into target, which it must fill exactly.
+func decodeHexJSON(field string, s string, target []byte) error {
+	if hex.DecodedLen(len(s)) != len(target) {
+		return fmt.Errorf("%w: %s must be %d bytes long but is %d", ErrInvalidJSON, field, len(target), hex.DecodedLen(len(s)))
+	}
+	if _, err := hex.Decode(target, []byte(s)); err != nil {
+		return fmt.Errorf("%w: %s is not valid hex: %v", ErrInvalidJSON, field, err)
+	}
+	return nil
+}
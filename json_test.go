@@ -0,0 +1,87 @@
+package iota_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage_JSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name        string
+		payloadType uint32
+	}{
+		{"signed transaction", iota.SignedTransactionPayloadID},
+		{"milestone", iota.MilestonePayloadID},
+		{"indexation", iota.IndexationPayloadID},
+		{"no payload", 1337},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, sourceData := randMessage(tt.payloadType)
+			// nonces beyond the precision of float64 must survive
+			source.Nonce = 1<<63 + 1
+			sourceData = sourceData[:len(sourceData)-iota.UInt64ByteSize]
+
+			jsonData, err := json.Marshal(source)
+			require.NoError(t, err)
+
+			target := &iota.Message{}
+			require.NoError(t, json.Unmarshal(jsonData, target))
+			assert.EqualValues(t, source, target)
+
+			targetData, err := target.Serialize(iota.DeSeriModePerformValidation)
+			require.NoError(t, err)
+			assert.Equal(t, sourceData, targetData[:len(targetData)-iota.UInt64ByteSize])
+		})
+	}
+}
+
+func TestMessage_JSONForm(t *testing.T) {
+	msg := &iota.Message{
+		Parent1: iota.MessageID{0xAA},
+		Parent2: iota.MessageID{0xBB},
+		Payload: &iota.IndexationPayload{Index: "index", Data: []byte{1, 2, 3}},
+		Nonce:   42,
+	}
+	jsonData, err := json.Marshal(msg)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"version": 1,
+		"parent_1": "aa00000000000000000000000000000000000000000000000000000000000000",
+		"parent_2": "bb00000000000000000000000000000000000000000000000000000000000000",
+		"payload": {"type": 2, "index": "index", "data": "010203"},
+		"nonce": "42"
+	}`, string(jsonData))
+}
+
+func TestMessage_UnmarshalJSONErrors(t *testing.T) {
+	const parent = "aa00000000000000000000000000000000000000000000000000000000000000"
+	tests := []struct {
+		name string
+		json string
+		err  error
+	}{
+		{"version", `{"version": 2, "parent_1": "` + parent + `", "parent_2": "` + parent + `", "nonce": "0"}`, iota.ErrDeserializationTypeMismatch},
+		{"short parent", `{"version": 1, "parent_1": "aa", "parent_2": "` + parent + `", "nonce": "0"}`, iota.ErrInvalidJSON},
+		{"invalid hex", `{"version": 1, "parent_1": "` + parent[:62] + `zz", "parent_2": "` + parent + `", "nonce": "0"}`, iota.ErrInvalidJSON},
+		{"nonce", `{"version": 1, "parent_1": "` + parent + `", "parent_2": "` + parent + `", "nonce": "-1"}`, iota.ErrInvalidJSON},
+		{"payload without type", `{"version": 1, "parent_1": "` + parent + `", "parent_2": "` + parent + `", "payload": {"index": "a"}, "nonce": "0"}`, iota.ErrInvalidJSON},
+		{"unknown payload", `{"version": 1, "parent_1": "` + parent + `", "parent_2": "` + parent + `", "payload": {"type": 9}, "nonce": "0"}`, iota.ErrUnknownPayloadType},
+		{"unknown address", `{"version": 1, "parent_1": "` + parent + `", "parent_2": "` + parent + `", "nonce": "0", "payload": {
+			"type": 0,
+			"transaction": {"type": 0, "inputs": [], "outputs": [{"type": 0, "address": {"type": 7, "address": "aa"}, "amount": 1}], "payload": null},
+			"unlock_blocks": []
+		}}`, iota.ErrUnknownAddrType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := json.Unmarshal([]byte(tt.json), &iota.Message{})
+			assert.True(t, errors.Is(err, tt.err), "expected %v, got %v", tt.err, err)
+		})
+	}
+}
@@ -0,0 +1,463 @@
+package kvstore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+)
+
+const (
+	// The version of the file format.
+	FileStoreVersion = 1
+	// The amount of bytes which must be garbage before a FileStore compacts itself automatically.
+	// Compaction additionally requires the garbage to exceed the live data.
+	DefaultCompactionMinGarbage = 4 << 20
+
+	// magic + version
+	fileStoreHeaderSize = len(fileStoreMagic) + 1
+	fileStoreMagic      = "IOTAKV"
+	// op + key length + value length
+	fileStoreOpHeaderSize = 1 + 4 + 4
+	// mutation count
+	fileStoreGroupHeaderSize = 4
+	// crc32 over the group
+	fileStoreGroupTrailerSize = 4
+
+	fileStoreOpSet    byte = 0
+	fileStoreOpDelete byte = 1
+)
+
+var (
+	ErrInvalidFileStore = errors.New("invalid file store")
+)
+
+// valueLocation is the position of a value within the file.
+type valueLocation struct {
+	offset int64
+	length uint32
+}
+
+// FileStore is a KVStore which appends every batch of mutations to a single file, while an index of the
+// value positions is kept in memory. A batch is written as a group of mutations followed by a checksum,
+// so a group which was only partially written, for example because the process crashed, is discarded
+// when the file is opened again. Overwritten and deleted values remain in the file as garbage until
+// the file is compacted, which happens automatically once the garbage exceeds both
+// DefaultCompactionMinGarbage and the live data, or explicitly via Compact.
+type FileStore struct {
+	mu      sync.RWMutex
+	path    string
+	file    *os.File
+	size    int64
+	garbage int64
+	index   map[string]valueLocation
+	// the error of the last automatic compaction
+	compactionErr error
+}
+
+// OpenFileStore opens the FileStore at the given path, creating it if it doesn't exist.
+// A trailing group of mutations which was not completely written is truncated, while a corrupt group,
+// for example one whose checksum doesn't match, yields ErrInvalidFileStore and leaves the file untouched.
+func OpenFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileStore{path: path, file: file, index: make(map[string]valueLocation)}
+	if err := s.load(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// load writes the header into an empty file or reads the groups of an existing one into the index.
+func (s *FileStore) load() error {
+	info, err := s.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		header := append([]byte(fileStoreMagic), FileStoreVersion)
+		if _, err := s.file.WriteAt(header, 0); err != nil {
+			return err
+		}
+		s.size = int64(len(header))
+		return nil
+	}
+
+	r := bufio.NewReader(io.NewSectionReader(s.file, 0, info.Size()))
+	header := make([]byte, fileStoreHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return fmt.Errorf("%w: unable to read header: %v", ErrInvalidFileStore, err)
+	}
+	if string(header[:len(fileStoreMagic)]) != fileStoreMagic {
+		return fmt.Errorf("%w: unknown file format", ErrInvalidFileStore)
+	}
+	if version := header[len(fileStoreMagic)]; version != FileStoreVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidFileStore, version)
+	}
+
+	s.size = int64(fileStoreHeaderSize)
+	for s.size < info.Size() {
+		groupSize, ops, err := readGroup(r, s.size, info.Size())
+		switch {
+		case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			// the last group was only partially written
+			return s.file.Truncate(s.size)
+		case err != nil:
+			return fmt.Errorf("group at offset %d: %w", s.size, err)
+		}
+		s.apply(ops)
+		s.size += groupSize
+	}
+	return nil
+}
+
+// fileOp is a mutation read from the file, the value of a set is given by its location.
+type fileOp struct {
+	op  byte
+	key string
+	loc valueLocation
+}
+
+// readGroup reads a group of mutations starting at the given offset of a file of the given size and returns its size.
+// A group which exceeds the end of the file yields io.ErrUnexpectedEOF, a group which is corrupt ErrInvalidFileStore.
+// Lengths are checked against the end of the file before anything is allocated for them.
+func readGroup(r *bufio.Reader, offset int64, fileSize int64) (int64, []fileOp, error) {
+	crc := crc32.NewIEEE()
+	tr := io.TeeReader(r, crc)
+
+	var countBytes [fileStoreGroupHeaderSize]byte
+	if _, err := io.ReadFull(tr, countBytes[:]); err != nil {
+		return 0, nil, err
+	}
+	count := binary.LittleEndian.Uint32(countBytes[:])
+	size := int64(fileStoreGroupHeaderSize)
+	remaining := func() int64 { return fileSize - offset - size }
+	if int64(count)*fileStoreOpHeaderSize+fileStoreGroupTrailerSize > remaining() {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+
+	ops := make([]fileOp, 0, count)
+	var opHeader [fileStoreOpHeaderSize]byte
+	for i := uint32(0); i < count; i++ {
+		if _, err := io.ReadFull(tr, opHeader[:]); err != nil {
+			return 0, nil, err
+		}
+		op := opHeader[0]
+		keyLength := binary.LittleEndian.Uint32(opHeader[1:])
+		valueLength := binary.LittleEndian.Uint32(opHeader[5:])
+		if (op != fileStoreOpSet && op != fileStoreOpDelete) || keyLength == 0 {
+			return 0, nil, fmt.Errorf("%w: corrupt mutation", ErrInvalidFileStore)
+		}
+		if fileStoreOpHeaderSize+int64(keyLength)+int64(valueLength)+fileStoreGroupTrailerSize > remaining() {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+
+		key := make([]byte, keyLength)
+		if _, err := io.ReadFull(tr, key); err != nil {
+			return 0, nil, err
+		}
+		valueOffset := offset + size + fileStoreOpHeaderSize + int64(keyLength)
+		if _, err := io.CopyN(ioutil.Discard, tr, int64(valueLength)); err != nil {
+			return 0, nil, err
+		}
+		size += fileStoreOpHeaderSize + int64(keyLength) + int64(valueLength)
+		ops = append(ops, fileOp{op: op, key: string(key), loc: valueLocation{offset: valueOffset, length: valueLength}})
+	}
+
+	var checksum [fileStoreGroupTrailerSize]byte
+	if _, err := io.ReadFull(r, checksum[:]); err != nil {
+		return 0, nil, err
+	}
+	if binary.LittleEndian.Uint32(checksum[:]) != crc.Sum32() {
+		return 0, nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidFileStore)
+	}
+	return size + fileStoreGroupTrailerSize, ops, nil
+}
+
+// apply updates the index with the given mutations and accounts for the garbage they produce.
+// The caller must hold the lock.
+func (s *FileStore) apply(ops []fileOp) {
+	for _, op := range ops {
+		if prev, has := s.index[op.key]; has {
+			s.garbage += recordSize(op.key, prev.length)
+			delete(s.index, op.key)
+		}
+		if op.op == fileStoreOpDelete {
+			s.garbage += recordSize(op.key, 0)
+			continue
+		}
+		s.index[op.key] = op.loc
+	}
+}
+
+// recordSize returns the size a mutation with the given key and value length occupies in the file.
+func recordSize(key string, valueLength uint32) int64 {
+	return fileStoreOpHeaderSize + int64(len(key)) + int64(valueLength)
+}
+
+func (s *FileStore) Get(key []byte) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.file == nil {
+		return nil, ErrStoreClosed
+	}
+	loc, has := s.index[string(key)]
+	if !has {
+		return nil, ErrKeyNotFound
+	}
+	return s.read(loc)
+}
+
+// read reads the value at the given location. The caller must hold the lock.
+func (s *FileStore) read(loc valueLocation) ([]byte, error) {
+	value := make([]byte, loc.length)
+	if _, err := s.file.ReadAt(value, loc.offset); err != nil {
+		return nil, fmt.Errorf("unable to read value: %w", err)
+	}
+	return value, nil
+}
+
+func (s *FileStore) Has(key []byte) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.file == nil {
+		return false, ErrStoreClosed
+	}
+	_, has := s.index[string(key)]
+	return has, nil
+}
+
+func (s *FileStore) Set(key []byte, value []byte) error {
+	batch := NewBatch()
+	if err := batch.Set(key, value); err != nil {
+		return err
+	}
+	return s.Write(batch)
+}
+
+func (s *FileStore) Delete(key []byte) error {
+	batch := NewBatch()
+	if err := batch.Delete(key); err != nil {
+		return err
+	}
+	return s.Write(batch)
+}
+
+func (s *FileStore) Iterate(prefix []byte, f IteratorFunc) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.file == nil {
+		return ErrStoreClosed
+	}
+
+	keys := make([]string, 0)
+	for key := range s.index {
+		if bytes.HasPrefix([]byte(key), prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value, err := s.read(s.index[key])
+		if err != nil {
+			return err
+		}
+		if !f([]byte(key), value) {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) Write(batch *Batch) error {
+	if batch.Len() == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	var countBytes [fileStoreGroupHeaderSize]byte
+	binary.LittleEndian.PutUint32(countBytes[:], uint32(batch.Len()))
+	buf.Write(countBytes[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return ErrStoreClosed
+	}
+
+	ops := make([]fileOp, len(batch.ops))
+	var opHeader [fileStoreOpHeaderSize]byte
+	for i, op := range batch.ops {
+		kind := fileStoreOpSet
+		if op.value == nil {
+			kind = fileStoreOpDelete
+		}
+		opHeader[0] = kind
+		binary.LittleEndian.PutUint32(opHeader[1:], uint32(len(op.key)))
+		binary.LittleEndian.PutUint32(opHeader[5:], uint32(len(op.value)))
+		buf.Write(opHeader[:])
+		buf.Write(op.key)
+		ops[i] = fileOp{
+			op:  kind,
+			key: string(op.key),
+			loc: valueLocation{offset: s.size + int64(buf.Len()), length: uint32(len(op.value))},
+		}
+		buf.Write(op.value)
+	}
+
+	var checksum [fileStoreGroupTrailerSize]byte
+	binary.LittleEndian.PutUint32(checksum[:], crc32.ChecksumIEEE(buf.Bytes()))
+	buf.Write(checksum[:])
+
+	if _, err := s.file.WriteAt(buf.Bytes(), s.size); err != nil {
+		// drop whatever part of the group made it into the file
+		_ = s.file.Truncate(s.size)
+		return fmt.Errorf("unable to append batch: %w", err)
+	}
+	s.size += int64(buf.Len())
+	s.apply(ops)
+
+	// the batch is durable at this point, so a failed compaction doesn't fail the write
+	// but is recorded and retried with the next write
+	if s.garbage > DefaultCompactionMinGarbage && s.garbage > s.size-s.garbage {
+		s.compactionErr = s.compact()
+	}
+	return nil
+}
+
+// CompactionError returns the error of the last automatic compaction, nil if it succeeded.
+func (s *FileStore) CompactionError() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.compactionErr
+}
+
+// Sync commits the written data to stable storage.
+func (s *FileStore) Sync() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.file == nil {
+		return ErrStoreClosed
+	}
+	return s.file.Sync()
+}
+
+// Size returns the size of the file and how many bytes of it are garbage.
+func (s *FileStore) Size() (size int64, garbage int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.size, s.garbage
+}
+
+// Compact rewrites the file so that it only contains the live key-value pairs.
+func (s *FileStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return ErrStoreClosed
+	}
+	return s.compact()
+}
+
+// compact writes the live key-value pairs as a single group into a temporary file
+// which then atomically replaces the current file. The caller must hold the lock.
+func (s *FileStore) compact() error {
+	tmpPath := s.path + ".compact"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	cleanup := func(err error) error {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("unable to compact file store: %w", err)
+	}
+
+	keys := make([]string, 0, len(s.index))
+	for key := range s.index {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	w := bufio.NewWriter(tmp)
+	crc := crc32.NewIEEE()
+	header := append([]byte(fileStoreMagic), FileStoreVersion)
+	if _, err := w.Write(header); err != nil {
+		return cleanup(err)
+	}
+
+	var countBytes [fileStoreGroupHeaderSize]byte
+	binary.LittleEndian.PutUint32(countBytes[:], uint32(len(keys)))
+	group := io.MultiWriter(w, crc)
+	if _, err := group.Write(countBytes[:]); err != nil {
+		return cleanup(err)
+	}
+
+	index := make(map[string]valueLocation, len(keys))
+	size := int64(len(header) + fileStoreGroupHeaderSize)
+	var opHeader [fileStoreOpHeaderSize]byte
+	for _, key := range keys {
+		loc := s.index[key]
+		value, err := s.read(loc)
+		if err != nil {
+			return cleanup(err)
+		}
+		opHeader[0] = fileStoreOpSet
+		binary.LittleEndian.PutUint32(opHeader[1:], uint32(len(key)))
+		binary.LittleEndian.PutUint32(opHeader[5:], loc.length)
+		for _, b := range [][]byte{opHeader[:], []byte(key), value} {
+			if _, err := group.Write(b); err != nil {
+				return cleanup(err)
+			}
+		}
+		index[key] = valueLocation{offset: size + fileStoreOpHeaderSize + int64(len(key)), length: loc.length}
+		size += recordSize(key, loc.length)
+	}
+
+	var checksum [fileStoreGroupTrailerSize]byte
+	binary.LittleEndian.PutUint32(checksum[:], crc.Sum32())
+	if _, err := w.Write(checksum[:]); err != nil {
+		return cleanup(err)
+	}
+	size += fileStoreGroupTrailerSize
+
+	if err := w.Flush(); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return cleanup(err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return cleanup(err)
+	}
+
+	_ = s.file.Close()
+	s.file = tmp
+	s.size = size
+	s.garbage = 0
+	s.index = index
+	return nil
+}
+
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return ErrStoreClosed
+	}
+	err := s.file.Close()
+	s.file = nil
+	s.index = nil
+	return err
+}
@@ -0,0 +1,228 @@
+package kvstore_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/luca-moser/iota/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	s, err := kvstore.OpenFileStore(path)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Set([]byte("a"), []byte("1")))
+	require.NoError(t, s.Set([]byte("b"), []byte("2")))
+	require.NoError(t, s.Set([]byte("a"), []byte("3")))
+	require.NoError(t, s.Delete([]byte("b")))
+	require.NoError(t, s.Close())
+
+	s, err = kvstore.OpenFileStore(path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	got, err := s.Get([]byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("3"), got)
+	has, err := s.Has([]byte("b"))
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestFileStore_PartialWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	s, err := kvstore.OpenFileStore(path)
+	require.NoError(t, err)
+	require.NoError(t, s.Set([]byte("kept"), []byte("value")))
+	sizeBefore, _ := s.Size()
+
+	batch := kvstore.NewBatch()
+	require.NoError(t, batch.Set([]byte("lost1"), []byte("value")))
+	require.NoError(t, batch.Set([]byte("lost2"), []byte("value")))
+	require.NoError(t, s.Write(batch))
+	sizeAfter, _ := s.Size()
+	require.NoError(t, s.Close())
+
+	// simulate a crash in the middle of appending the batch
+	require.NoError(t, os.Truncate(path, sizeBefore+(sizeAfter-sizeBefore)/2))
+
+	s, err = kvstore.OpenFileStore(path)
+	require.NoError(t, err)
+
+	has, err := s.Has([]byte("kept"))
+	require.NoError(t, err)
+	assert.True(t, has)
+	for _, key := range []string{"lost1", "lost2"} {
+		has, err := s.Has([]byte(key))
+		require.NoError(t, err)
+		assert.False(t, has, "the partially written batch must be discarded as a whole")
+	}
+	size, _ := s.Size()
+	assert.Equal(t, sizeBefore, size)
+
+	// the store keeps working after the truncated group
+	require.NoError(t, s.Set([]byte("new"), []byte("value")))
+	require.NoError(t, s.Close())
+	s, err = kvstore.OpenFileStore(path)
+	require.NoError(t, err)
+	defer s.Close()
+	has, err = s.Has([]byte("new"))
+	require.NoError(t, err)
+	assert.True(t, has)
+}
+
+func TestFileStore_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	require.NoError(t, ioutil.WriteFile(path, []byte("NOTAKVSTORE"), 0o600))
+	_, err := kvstore.OpenFileStore(path)
+	assert.True(t, errors.Is(err, kvstore.ErrInvalidFileStore))
+}
+
+func TestFileStore_CorruptGroup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	s, err := kvstore.OpenFileStore(path)
+	require.NoError(t, err)
+	require.NoError(t, s.Set([]byte("first"), []byte("value")))
+	require.NoError(t, s.Set([]byte("second"), []byte("value")))
+	size, _ := s.Size()
+	require.NoError(t, s.Close())
+
+	// flip the last byte of the value of the first group
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	firstValueEnd := len("IOTAKV") + 1 + 4 + 9 + len("first") + len("value")
+	data[firstValueEnd-1] ^= 0xff
+	require.NoError(t, ioutil.WriteFile(path, data, 0o600))
+
+	// the valid group after the corrupt one must not be truncated
+	_, err = kvstore.OpenFileStore(path)
+	assert.True(t, errors.Is(err, kvstore.ErrInvalidFileStore))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, size, info.Size())
+}
+
+func TestFileStore_OversizedTrailingGroup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	s, err := kvstore.OpenFileStore(path)
+	require.NoError(t, err)
+	require.NoError(t, s.Set([]byte("kept"), []byte("value")))
+	size, _ := s.Size()
+	require.NoError(t, s.Close())
+
+	// a trailing group claiming the maximum amount of mutations, each with a huge key
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	s, err = kvstore.OpenFileStore(path)
+	require.NoError(t, err)
+	defer s.Close()
+	has, err := s.Has([]byte("kept"))
+	require.NoError(t, err)
+	assert.True(t, has)
+	newSize, _ := s.Size()
+	assert.Equal(t, size, newSize)
+}
+
+func TestFileStore_Compact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	s, err := kvstore.OpenFileStore(path)
+	require.NoError(t, err)
+
+	value := make([]byte, 1024)
+	for round := 0; round < 10; round++ {
+		for i := 0; i < 100; i++ {
+			require.NoError(t, s.Set([]byte(fmt.Sprintf("key-%d", i)), append(value, byte(round))))
+		}
+	}
+	for i := 50; i < 100; i++ {
+		require.NoError(t, s.Delete([]byte(fmt.Sprintf("key-%d", i))))
+	}
+
+	sizeBefore, garbage := s.Size()
+	assert.Greater(t, garbage, sizeBefore/2)
+
+	require.NoError(t, s.Compact())
+	size, garbage := s.Size()
+	assert.Zero(t, garbage)
+	assert.Less(t, size, sizeBefore/10)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, size, info.Size())
+
+	check := func(s *kvstore.FileStore) {
+		for i := 0; i < 100; i++ {
+			got, err := s.Get([]byte(fmt.Sprintf("key-%d", i)))
+			if i >= 50 {
+				assert.True(t, errors.Is(err, kvstore.ErrKeyNotFound))
+				continue
+			}
+			require.NoError(t, err)
+			assert.Equal(t, append(value, 9), got)
+		}
+	}
+	check(s)
+
+	// writes after the compaction land in the compacted file
+	require.NoError(t, s.Set([]byte("after"), []byte("compaction")))
+	require.NoError(t, s.Close())
+
+	s, err = kvstore.OpenFileStore(path)
+	require.NoError(t, err)
+	defer s.Close()
+	check(s)
+	got, err := s.Get([]byte("after"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("compaction"), got)
+}
+
+func TestFileStore_AutoCompact(t *testing.T) {
+	s, err := kvstore.OpenFileStore(filepath.Join(t.TempDir(), "store"))
+	require.NoError(t, err)
+	defer s.Close()
+
+	value := make([]byte, 64*1024)
+	writes := 3 * kvstore.DefaultCompactionMinGarbage / len(value)
+	for i := 0; i < writes; i++ {
+		require.NoError(t, s.Set([]byte("key"), value))
+	}
+	size, _ := s.Size()
+	assert.Less(t, size, int64(writes*len(value)/2))
+}
+
+func TestFileStore_AutoCompactFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	s, err := kvstore.OpenFileStore(path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	// the temporary file of the compaction can't be created
+	require.NoError(t, os.Mkdir(path+".compact", 0o700))
+
+	value := make([]byte, 64*1024)
+	writes := 3 * kvstore.DefaultCompactionMinGarbage / len(value)
+	for i := 0; i < writes; i++ {
+		require.NoError(t, s.Set([]byte("key"), append(value, byte(i))))
+	}
+	assert.Error(t, s.CompactionError())
+	got, err := s.Get([]byte("key"))
+	require.NoError(t, err)
+	assert.Equal(t, append(value, byte(writes-1)), got)
+
+	// the compaction is retried with the next write
+	require.NoError(t, os.Remove(path+".compact"))
+	require.NoError(t, s.Set([]byte("key"), value))
+	assert.NoError(t, s.CompactionError())
+	_, garbage := s.Size()
+	assert.Zero(t, garbage)
+}
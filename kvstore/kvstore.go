@@ -0,0 +1,98 @@
+// Package kvstore defines a small key-value storage interface together with an in-memory and an
+// append-only file backend, and the key schemas under which messages, their metadata and UTXOs are stored.
+package kvstore
+
+import (
+	"errors"
+)
+
+var (
+	ErrKeyNotFound = errors.New("key not found")
+	ErrStoreClosed = errors.New("store is closed")
+	ErrEmptyKey    = errors.New("key must not be empty")
+)
+
+// IteratorFunc is called for every key-value pair during an iteration.
+// Returning false stops the iteration. The given slices must not be retained.
+type IteratorFunc func(key []byte, value []byte) bool
+
+// Writer mutates key-value pairs. It is implemented by KVStore and Batch.
+type Writer interface {
+	// Set sets the value of the given key.
+	Set(key []byte, value []byte) error
+	// Delete deletes the given key. Deleting a key which doesn't exist is a no-op.
+	Delete(key []byte) error
+}
+
+// KVStore is a key-value store. Keys and values are copied on the way in and out,
+// callers are free to modify the slices they pass or get back.
+// Implementations must be safe for concurrent use.
+type KVStore interface {
+	Writer
+	// Get returns the value of the given key or ErrKeyNotFound.
+	Get(key []byte) ([]byte, error)
+	// Has tells whether the given key exists.
+	Has(key []byte) (bool, error)
+	// Iterate calls f for every key with the given prefix in lexical key order.
+	// An empty prefix iterates over all keys. f must not mutate the store.
+	Iterate(prefix []byte, f IteratorFunc) error
+	// Write applies all mutations of the given batch atomically: either all or none of them are applied.
+	Write(batch *Batch) error
+	// Close closes the store. Every further call returns ErrStoreClosed.
+	Close() error
+}
+
+// batchOp is a single mutation within a Batch. A nil value denotes a deletion.
+type batchOp struct {
+	key   []byte
+	value []byte
+}
+
+// Batch collects mutations which are applied atomically via KVStore.Write.
+// Mutations of the same key are applied in the order in which they were added.
+// A Batch is not safe for concurrent use.
+type Batch struct {
+	ops []batchOp
+}
+
+// NewBatch creates a new empty Batch.
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// Set adds the setting of the given key to the batch.
+func (b *Batch) Set(key []byte, value []byte) error {
+	if len(key) == 0 {
+		return ErrEmptyKey
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	b.ops = append(b.ops, batchOp{key: copyBytes(key), value: copyBytes(value)})
+	return nil
+}
+
+// Delete adds the deletion of the given key to the batch.
+func (b *Batch) Delete(key []byte) error {
+	if len(key) == 0 {
+		return ErrEmptyKey
+	}
+	b.ops = append(b.ops, batchOp{key: copyBytes(key)})
+	return nil
+}
+
+// Len returns the amount of mutations in the batch.
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// Reset removes all mutations from the batch so it can be reused.
+func (b *Batch) Reset() {
+	b.ops = b.ops[:0]
+}
+
+func copyBytes(b []byte) []byte {
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
@@ -0,0 +1,149 @@
+package kvstore_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/luca-moser/iota/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// backends returns a constructor for every KVStore implementation.
+func backends(t *testing.T) map[string]func() kvstore.KVStore {
+	return map[string]func() kvstore.KVStore{
+		"memory": func() kvstore.KVStore { return kvstore.NewMemoryStore() },
+		"file": func() kvstore.KVStore {
+			s, err := kvstore.OpenFileStore(filepath.Join(t.TempDir(), "store"))
+			require.NoError(t, err)
+			return s
+		},
+	}
+}
+
+func TestKVStore(t *testing.T) {
+	for name, newStore := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Run("get set delete", func(t *testing.T) {
+				s := newStore()
+				defer s.Close()
+
+				_, err := s.Get([]byte("a"))
+				assert.True(t, errors.Is(err, kvstore.ErrKeyNotFound))
+
+				value := []byte("value")
+				require.NoError(t, s.Set([]byte("a"), value))
+				value[0] = 'X'
+				got, err := s.Get([]byte("a"))
+				require.NoError(t, err)
+				assert.Equal(t, []byte("value"), got)
+
+				require.NoError(t, s.Set([]byte("a"), []byte("other")))
+				got, err = s.Get([]byte("a"))
+				require.NoError(t, err)
+				assert.Equal(t, []byte("other"), got)
+
+				require.NoError(t, s.Set([]byte("empty"), nil))
+				has, err := s.Has([]byte("empty"))
+				require.NoError(t, err)
+				assert.True(t, has)
+
+				require.NoError(t, s.Delete([]byte("a")))
+				require.NoError(t, s.Delete([]byte("never-set")))
+				has, err = s.Has([]byte("a"))
+				require.NoError(t, err)
+				assert.False(t, has)
+
+				assert.True(t, errors.Is(s.Set(nil, []byte{1}), kvstore.ErrEmptyKey))
+			})
+
+			t.Run("iterate", func(t *testing.T) {
+				s := newStore()
+				defer s.Close()
+
+				for _, key := range []string{"b2", "a1", "b1", "c", "b3"} {
+					require.NoError(t, s.Set([]byte(key), []byte(key+"-value")))
+				}
+
+				var keys []string
+				require.NoError(t, s.Iterate([]byte("b"), func(key []byte, value []byte) bool {
+					assert.Equal(t, string(key)+"-value", string(value))
+					keys = append(keys, string(key))
+					return true
+				}))
+				assert.Equal(t, []string{"b1", "b2", "b3"}, keys)
+
+				keys = nil
+				require.NoError(t, s.Iterate(nil, func(key []byte, _ []byte) bool {
+					keys = append(keys, string(key))
+					return len(keys) < 2
+				}))
+				assert.Equal(t, []string{"a1", "b1"}, keys)
+			})
+
+			t.Run("batch", func(t *testing.T) {
+				s := newStore()
+				defer s.Close()
+
+				require.NoError(t, s.Set([]byte("gone"), []byte{1}))
+
+				batch := kvstore.NewBatch()
+				require.NoError(t, batch.Set([]byte("x"), []byte{1}))
+				require.NoError(t, batch.Set([]byte("x"), []byte{2}))
+				require.NoError(t, batch.Set([]byte("y"), []byte{3}))
+				require.NoError(t, batch.Delete([]byte("gone")))
+				assert.Equal(t, 4, batch.Len())
+				require.NoError(t, s.Write(batch))
+
+				got, err := s.Get([]byte("x"))
+				require.NoError(t, err)
+				assert.Equal(t, []byte{2}, got)
+				has, err := s.Has([]byte("gone"))
+				require.NoError(t, err)
+				assert.False(t, has)
+
+				batch.Reset()
+				assert.Equal(t, 0, batch.Len())
+			})
+
+			t.Run("concurrent", func(t *testing.T) {
+				s := newStore()
+				defer s.Close()
+
+				var wg sync.WaitGroup
+				for i := 0; i < 10; i++ {
+					wg.Add(1)
+					go func(i int) {
+						defer wg.Done()
+						for j := 0; j < 50; j++ {
+							key := []byte(fmt.Sprintf("%d-%d", i, j))
+							assert.NoError(t, s.Set(key, key))
+							_, err := s.Get(key)
+							assert.NoError(t, err)
+						}
+					}(i)
+				}
+				wg.Wait()
+
+				count := 0
+				require.NoError(t, s.Iterate(nil, func([]byte, []byte) bool {
+					count++
+					return true
+				}))
+				assert.Equal(t, 500, count)
+			})
+
+			t.Run("closed", func(t *testing.T) {
+				s := newStore()
+				require.NoError(t, s.Close())
+				_, err := s.Get([]byte("a"))
+				assert.True(t, errors.Is(err, kvstore.ErrStoreClosed))
+				assert.True(t, errors.Is(s.Set([]byte("a"), nil), kvstore.ErrStoreClosed))
+				assert.True(t, errors.Is(s.Close(), kvstore.ErrStoreClosed))
+			})
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package kvstore
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+)
+
+// MemoryStore is a KVStore which holds all key-value pairs in memory.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	closed bool
+	data   map[string][]byte
+}
+
+// NewMemoryStore creates a new empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: make(map[string][]byte)}
+}
+
+func (s *MemoryStore) Get(key []byte) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrStoreClosed
+	}
+	value, has := s.data[string(key)]
+	if !has {
+		return nil, ErrKeyNotFound
+	}
+	return copyBytes(value), nil
+}
+
+func (s *MemoryStore) Has(key []byte) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return false, ErrStoreClosed
+	}
+	_, has := s.data[string(key)]
+	return has, nil
+}
+
+func (s *MemoryStore) Set(key []byte, value []byte) error {
+	batch := NewBatch()
+	if err := batch.Set(key, value); err != nil {
+		return err
+	}
+	return s.Write(batch)
+}
+
+func (s *MemoryStore) Delete(key []byte) error {
+	batch := NewBatch()
+	if err := batch.Delete(key); err != nil {
+		return err
+	}
+	return s.Write(batch)
+}
+
+func (s *MemoryStore) Iterate(prefix []byte, f IteratorFunc) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return ErrStoreClosed
+	}
+
+	keys := make([]string, 0)
+	for key := range s.data {
+		if bytes.HasPrefix([]byte(key), prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !f([]byte(key), copyBytes(s.data[key])) {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Write(batch *Batch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrStoreClosed
+	}
+	// the batch already holds copies
+	for _, op := range batch.ops {
+		if op.value == nil {
+			delete(s.data, string(op.key))
+			continue
+		}
+		s.data[string(op.key)] = op.value
+	}
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrStoreClosed
+	}
+	s.closed = true
+	s.data = nil
+	return nil
+}
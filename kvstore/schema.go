@@ -0,0 +1,244 @@
+package kvstore
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/luca-moser/iota"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Key prefixes of the records stored on top of a KVStore.
+const (
+	// MessageID -> serialized message.
+	KeyPrefixMessage byte = 1
+	// MessageID -> serialized message metadata.
+	KeyPrefixMessageMetadata byte = 2
+	// transaction ID + output index -> serialized output.
+	KeyPrefixUnspentOutput byte = 3
+	// serialized address + transaction ID + output index -> nothing, indexes the unspent outputs by address.
+	KeyPrefixAddressOutput byte = 4
+	// transaction ID + output index -> milestone index + serialized output.
+	KeyPrefixSpentOutput byte = 5
+)
+
+const (
+	// transaction ID + output index
+	outputKeyLength = iota.TransactionIDLength + iota.UInt16ByteSize
+)
+
+// MessageKey returns the key under which the message with the given ID is stored.
+func MessageKey(id iota.MessageID) []byte {
+	return append([]byte{KeyPrefixMessage}, id[:]...)
+}
+
+// MessageMetadataKey returns the key under which the metadata of the message with the given ID is stored.
+func MessageMetadataKey(id iota.MessageID) []byte {
+	return append([]byte{KeyPrefixMessageMetadata}, id[:]...)
+}
+
+// UnspentOutputKey returns the key under which the unspent output referenced by the given input is stored.
+func UnspentOutputKey(input *iota.UTXOInput) []byte {
+	return outputKey(KeyPrefixUnspentOutput, input)
+}
+
+// SpentOutputKey returns the key under which the spent output referenced by the given input is stored.
+func SpentOutputKey(input *iota.UTXOInput) []byte {
+	return outputKey(KeyPrefixSpentOutput, input)
+}
+
+// AddressOutputsKeyPrefix returns the key prefix under which the unspent outputs of the given address are indexed.
+func AddressOutputsKeyPrefix(addr iota.Serializable) ([]byte, error) {
+	addrBytes, err := addr.Serialize(iota.DeSeriModeNoValidation)
+	if err != nil {
+		return nil, fmt.Errorf("unable to serialize address: %w", err)
+	}
+	return append([]byte{KeyPrefixAddressOutput}, addrBytes...), nil
+}
+
+func outputKey(prefix byte, input *iota.UTXOInput) []byte {
+	key := make([]byte, 1+outputKeyLength)
+	key[0] = prefix
+	copy(key[1:], input.TransactionID[:])
+	binary.LittleEndian.PutUint16(key[1+iota.TransactionIDLength:], input.TransactionOutputIndex)
+	return key
+}
+
+// StoreMessage stores the given message under its ID and returns the ID.
+func StoreMessage(w Writer, msg *iota.Message) (iota.MessageID, error) {
+	data, err := msg.Serialize(iota.DeSeriModePerformValidation)
+	if err != nil {
+		return iota.MessageID{}, fmt.Errorf("unable to serialize message: %w", err)
+	}
+	id := blake2b.Sum256(data)
+	return id, w.Set(MessageKey(id), data)
+}
+
+// LoadMessage loads the message with the given ID.
+func LoadMessage(s KVStore, id iota.MessageID) (*iota.Message, error) {
+	data, err := s.Get(MessageKey(id))
+	if err != nil {
+		return nil, fmt.Errorf("unable to load message %x: %w", id, err)
+	}
+	msg := &iota.Message{}
+	if _, err := msg.Deserialize(data, iota.DeSeriModePerformValidation); err != nil {
+		return nil, fmt.Errorf("unable to deserialize message %x: %w", id, err)
+	}
+	return msg, nil
+}
+
+// StoreMessageMetadata stores the metadata of the message with the given ID.
+func StoreMessageMetadata(w Writer, id iota.MessageID, meta *iota.MessageMetadata) error {
+	data, err := meta.Serialize(iota.DeSeriModePerformValidation)
+	if err != nil {
+		return fmt.Errorf("unable to serialize metadata of message %x: %w", id, err)
+	}
+	return w.Set(MessageMetadataKey(id), data)
+}
+
+// LoadMessageMetadata loads the metadata of the message with the given ID.
+func LoadMessageMetadata(s KVStore, id iota.MessageID) (*iota.MessageMetadata, error) {
+	data, err := s.Get(MessageMetadataKey(id))
+	if err != nil {
+		return nil, fmt.Errorf("unable to load metadata of message %x: %w", id, err)
+	}
+	meta := &iota.MessageMetadata{}
+	if _, err := meta.Deserialize(data, iota.DeSeriModePerformValidation); err != nil {
+		return nil, fmt.Errorf("unable to deserialize metadata of message %x: %w", id, err)
+	}
+	return meta, nil
+}
+
+// StoreUnspentOutput stores the given unspent output and indexes it by its address.
+func StoreUnspentOutput(w Writer, output *iota.UnspentOutput) error {
+	data, err := serializeOutput(output)
+	if err != nil {
+		return err
+	}
+	addrKey, err := addressOutputKey(output)
+	if err != nil {
+		return err
+	}
+	if err := w.Set(UnspentOutputKey(output.Input), data); err != nil {
+		return err
+	}
+	return w.Set(addrKey, []byte{})
+}
+
+// DeleteUnspentOutput deletes the given unspent output together with its address index entry.
+func DeleteUnspentOutput(w Writer, output *iota.UnspentOutput) error {
+	addrKey, err := addressOutputKey(output)
+	if err != nil {
+		return err
+	}
+	if err := w.Delete(UnspentOutputKey(output.Input)); err != nil {
+		return err
+	}
+	return w.Delete(addrKey)
+}
+
+// LoadUnspentOutput loads the unspent output referenced by the given input.
+func LoadUnspentOutput(s KVStore, input *iota.UTXOInput) (*iota.UnspentOutput, error) {
+	data, err := s.Get(UnspentOutputKey(input))
+	if err != nil {
+		return nil, fmt.Errorf("unable to load unspent output %x/%d: %w", input.TransactionID, input.TransactionOutputIndex, err)
+	}
+	return deserializeOutput(input, data)
+}
+
+// ForEachUnspentOutput calls f for every stored unspent output until f returns false.
+func ForEachUnspentOutput(s KVStore, f func(output *iota.UnspentOutput) bool) error {
+	var innerErr error
+	if err := s.Iterate([]byte{KeyPrefixUnspentOutput}, func(key []byte, value []byte) bool {
+		var output *iota.UnspentOutput
+		output, innerErr = deserializeOutput(inputFromOutputKey(key[1:]), value)
+		if innerErr != nil {
+			return false
+		}
+		return f(output)
+	}); err != nil {
+		return err
+	}
+	return innerErr
+}
+
+// UnspentOutputsByAddress loads the unspent outputs deposited onto the given address.
+func UnspentOutputsByAddress(s KVStore, addr iota.Serializable) ([]*iota.UnspentOutput, error) {
+	prefix, err := AddressOutputsKeyPrefix(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var inputs []*iota.UTXOInput
+	if err := s.Iterate(prefix, func(key []byte, _ []byte) bool {
+		inputs = append(inputs, inputFromOutputKey(key[len(prefix):]))
+		return true
+	}); err != nil {
+		return nil, err
+	}
+
+	outputs := make([]*iota.UnspentOutput, len(inputs))
+	for i, input := range inputs {
+		if outputs[i], err = LoadUnspentOutput(s, input); err != nil {
+			return nil, err
+		}
+	}
+	return outputs, nil
+}
+
+// StoreSpentOutput stores the given output as spent by the milestone with the given index.
+func StoreSpentOutput(w Writer, output *iota.UnspentOutput, milestoneIndex uint64) error {
+	data, err := serializeOutput(output)
+	if err != nil {
+		return err
+	}
+	value := make([]byte, iota.UInt64ByteSize, iota.UInt64ByteSize+len(data))
+	binary.LittleEndian.PutUint64(value, milestoneIndex)
+	return w.Set(SpentOutputKey(output.Input), append(value, data...))
+}
+
+// LoadSpentOutput loads the spent output referenced by the given input together with the index of the milestone which spent it.
+func LoadSpentOutput(s KVStore, input *iota.UTXOInput) (*iota.UnspentOutput, uint64, error) {
+	data, err := s.Get(SpentOutputKey(input))
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to load spent output %x/%d: %w", input.TransactionID, input.TransactionOutputIndex, err)
+	}
+	if len(data) < iota.UInt64ByteSize {
+		return nil, 0, fmt.Errorf("%w: spent output %x/%d", iota.ErrDeserializationNotEnoughData, input.TransactionID, input.TransactionOutputIndex)
+	}
+	output, err := deserializeOutput(input, data[iota.UInt64ByteSize:])
+	if err != nil {
+		return nil, 0, err
+	}
+	return output, binary.LittleEndian.Uint64(data), nil
+}
+
+func addressOutputKey(output *iota.UnspentOutput) ([]byte, error) {
+	prefix, err := AddressOutputsKeyPrefix(output.Address)
+	if err != nil {
+		return nil, err
+	}
+	return append(prefix, UnspentOutputKey(output.Input)[1:]...), nil
+}
+
+func inputFromOutputKey(key []byte) *iota.UTXOInput {
+	input := &iota.UTXOInput{TransactionOutputIndex: binary.LittleEndian.Uint16(key[iota.TransactionIDLength:])}
+	copy(input.TransactionID[:], key[:iota.TransactionIDLength])
+	return input
+}
+
+func serializeOutput(output *iota.UnspentOutput) ([]byte, error) {
+	data, err := (&iota.SigLockedSingleDeposit{Address: output.Address, Amount: output.Amount}).Serialize(iota.DeSeriModePerformValidation)
+	if err != nil {
+		return nil, fmt.Errorf("unable to serialize output %x/%d: %w", output.Input.TransactionID, output.Input.TransactionOutputIndex, err)
+	}
+	return data, nil
+}
+
+func deserializeOutput(input *iota.UTXOInput, data []byte) (*iota.UnspentOutput, error) {
+	dep := &iota.SigLockedSingleDeposit{}
+	if _, err := dep.Deserialize(data, iota.DeSeriModePerformValidation); err != nil {
+		return nil, fmt.Errorf("unable to deserialize output %x/%d: %w", input.TransactionID, input.TransactionOutputIndex, err)
+	}
+	return &iota.UnspentOutput{Input: input, Address: dep.Address, Amount: dep.Amount}, nil
+}
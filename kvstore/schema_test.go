@@ -0,0 +1,95 @@
+package kvstore_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/luca-moser/iota/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema_Message(t *testing.T) {
+	s := kvstore.NewMemoryStore()
+	msg := &iota.Message{
+		Parent1: iota.MessageID{1},
+		Parent2: iota.MessageID{2},
+		Payload: &iota.IndexationPayload{Index: "test", Data: []byte{1, 2, 3}},
+		Nonce:   1337,
+	}
+
+	id, err := kvstore.StoreMessage(s, msg)
+	require.NoError(t, err)
+	expectedID, err := msg.ID()
+	require.NoError(t, err)
+	assert.Equal(t, expectedID, id)
+
+	loaded, err := kvstore.LoadMessage(s, id)
+	require.NoError(t, err)
+	assert.EqualValues(t, msg, loaded)
+
+	_, err = kvstore.LoadMessage(s, iota.MessageID{9})
+	assert.True(t, errors.Is(err, kvstore.ErrKeyNotFound))
+
+	meta := &iota.MessageMetadata{}
+	meta.SetSolid()
+	meta.SetReferenced(5, iota.LedgerInclusionConflicting, iota.ConflictInputUTXONotFound)
+	require.NoError(t, kvstore.StoreMessageMetadata(s, id, meta))
+
+	loadedMeta, err := kvstore.LoadMessageMetadata(s, id)
+	require.NoError(t, err)
+	assert.True(t, loadedMeta.IsSolid())
+	index, referenced := loadedMeta.ReferencedBy()
+	assert.True(t, referenced)
+	assert.EqualValues(t, 5, index)
+	assert.Equal(t, iota.ConflictInputUTXONotFound, loadedMeta.ConflictReason())
+}
+
+func TestSchema_Outputs(t *testing.T) {
+	s := kvstore.NewMemoryStore()
+	addr1, addr2 := &iota.Ed25519Address{1}, &iota.Ed25519Address{2}
+	outputs := []*iota.UnspentOutput{
+		{Input: &iota.UTXOInput{TransactionID: [32]byte{1}, TransactionOutputIndex: 0}, Address: addr1, Amount: 100},
+		{Input: &iota.UTXOInput{TransactionID: [32]byte{1}, TransactionOutputIndex: 1}, Address: addr2, Amount: 200},
+		{Input: &iota.UTXOInput{TransactionID: [32]byte{2}, TransactionOutputIndex: 0}, Address: addr1, Amount: 300},
+	}
+
+	batch := kvstore.NewBatch()
+	for _, output := range outputs {
+		require.NoError(t, kvstore.StoreUnspentOutput(batch, output))
+	}
+	require.NoError(t, s.Write(batch))
+
+	loaded, err := kvstore.LoadUnspentOutput(s, outputs[1].Input)
+	require.NoError(t, err)
+	assert.EqualValues(t, outputs[1], loaded)
+
+	byAddr, err := kvstore.UnspentOutputsByAddress(s, addr1)
+	require.NoError(t, err)
+	assert.EqualValues(t, []*iota.UnspentOutput{outputs[0], outputs[2]}, byAddr)
+
+	var all []*iota.UnspentOutput
+	require.NoError(t, kvstore.ForEachUnspentOutput(s, func(output *iota.UnspentOutput) bool {
+		all = append(all, output)
+		return true
+	}))
+	assert.EqualValues(t, outputs, all)
+
+	// spend the first output
+	batch = kvstore.NewBatch()
+	require.NoError(t, kvstore.DeleteUnspentOutput(batch, outputs[0]))
+	require.NoError(t, kvstore.StoreSpentOutput(batch, outputs[0], 7))
+	require.NoError(t, s.Write(batch))
+
+	_, err = kvstore.LoadUnspentOutput(s, outputs[0].Input)
+	assert.True(t, errors.Is(err, kvstore.ErrKeyNotFound))
+	byAddr, err = kvstore.UnspentOutputsByAddress(s, addr1)
+	require.NoError(t, err)
+	assert.EqualValues(t, []*iota.UnspentOutput{outputs[2]}, byAddr)
+
+	spent, index, err := kvstore.LoadSpentOutput(s, outputs[0].Input)
+	require.NoError(t, err)
+	assert.EqualValues(t, outputs[0], spent)
+	assert.EqualValues(t, 7, index)
+}
@@ -0,0 +1,147 @@
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/luca-moser/iota"
+)
+
+// TransactionResult describes whether a transaction within a milestone cone was applied or is conflicting.
+type TransactionResult struct {
+	// The ID of the message carrying the transaction.
+	MessageID iota.MessageID
+	// The ID of the transaction.
+	TransactionID [iota.TransactionIDLength]byte
+	// The reason why the transaction is conflicting or iota.ConflictNone if it was applied.
+	Conflict iota.ConflictReason
+	// The error describing the conflict, nil if the transaction was applied.
+	Err error
+	// The outputs the transaction consumed, nil if it is conflicting.
+	Consumed []*iota.UnspentOutput
+	// The outputs the transaction created, nil if it is conflicting.
+	// Unlike the Diff, these hold outputs which a later transaction of the same cone consumed.
+	Created []*iota.UnspentOutput
+}
+
+// Applied tells whether the transaction was applied onto the ledger.
+func (r *TransactionResult) Applied() bool {
+	return r.Conflict == iota.ConflictNone
+}
+
+// ConflictResolution is the outcome of resolving the conflicts of the transactions within a milestone cone.
+type ConflictResolution struct {
+	// The results of the transactions in the order they were processed.
+	Transactions []*TransactionResult
+	// The mutations the applied transactions produce.
+	Diff *Diff
+}
+
+// AppliedMessageIDs returns the IDs of the messages whose transactions were applied, in the order they were processed.
+func (c *ConflictResolution) AppliedMessageIDs() []iota.MessageID {
+	var ids []iota.MessageID
+	for _, result := range c.Transactions {
+		if result.Applied() {
+			ids = append(ids, result.MessageID)
+		}
+	}
+	return ids
+}
+
+// ResolveConflicts processes the transactions of the given messages, which must be in the deterministic
+// order of the milestone cone's traversal, against the ledger state given by lookup.
+// Every transaction is semantically validated against the ledger state mutated by all transactions applied
+// before it: the first transaction spending an output wins, every later one spending it again is conflicting.
+// Transactions which are syntactically invalid, for example because they spend the same output twice,
+// are conflicting as well, so that they can't block the confirmation of the milestone.
+// Messages which don't carry a signed transaction payload are skipped, as are duplicate messages.
+// An error is only returned if looking up the ledger state or computing IDs failed.
+func ResolveConflicts(lookup iota.UTXOLookupFunc, milestoneIndex uint64, messages []*iota.Message) (*ConflictResolution, error) {
+	resolution := &ConflictResolution{}
+
+	// the outputs created and consumed by the transactions applied so far
+	created := make(map[OutputID]*iota.UnspentOutput)
+	consumed := make(map[OutputID]*iota.UnspentOutput)
+	seen := make(map[iota.MessageID]struct{})
+	var applied []*iota.SignedTransactionPayload
+
+	coneLookup := func(input *iota.UTXOInput) (*iota.UnspentOutput, bool, error) {
+		id := OutputIDFromUTXOInput(input)
+		if output, has := consumed[id]; has {
+			return output, true, nil
+		}
+		if output, has := created[id]; has {
+			return output, false, nil
+		}
+		return lookup(input)
+	}
+
+	for i, msg := range messages {
+		payload, isTx := msg.Payload.(*iota.SignedTransactionPayload)
+		if !isTx {
+			continue
+		}
+
+		msgID, err := msg.ID()
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		if _, has := seen[msgID]; has {
+			continue
+		}
+		seen[msgID] = struct{}{}
+
+		txID, err := payload.ID()
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+
+		result := &TransactionResult{MessageID: msgID, TransactionID: txID}
+		resolution.Transactions = append(resolution.Transactions, result)
+
+		conflict, err := payload.SemanticallyValid(coneLookup)
+		if err != nil && conflict == iota.ConflictNone {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		if conflict != iota.ConflictNone {
+			result.Conflict, result.Err = conflict, err
+			continue
+		}
+
+		// mutate the cone's view of the ledger
+		for _, input := range payload.Transaction.(*iota.UnsignedTransaction).Inputs {
+			utxoInput := input.(*iota.UTXOInput)
+			output, _, err := coneLookup(utxoInput)
+			if err != nil {
+				return nil, fmt.Errorf("message %d: %w", i, err)
+			}
+			consumed[OutputIDFromUTXOInput(utxoInput)] = output
+			result.Consumed = append(result.Consumed, output)
+		}
+		outputs, err := OutputsOfTransaction(payload)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		for _, output := range outputs {
+			created[OutputIDFromUTXOInput(output.Input)] = output
+		}
+		result.Created = outputs
+		applied = append(applied, payload)
+	}
+
+	diff, err := computeDiff(viewFunc(func(input *iota.UTXOInput) (*iota.UnspentOutput, error) {
+		output, _, err := lookup(input)
+		if err != nil {
+			return nil, err
+		}
+		if output == nil {
+			return nil, fmt.Errorf("%w: %x:%d", ErrOutputNotFound, input.TransactionID, input.TransactionOutputIndex)
+		}
+		return output, nil
+	}), milestoneIndex, applied)
+	if err != nil {
+		return nil, err
+	}
+	resolution.Diff = diff
+
+	return resolution, nil
+}
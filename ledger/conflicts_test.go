@@ -0,0 +1,147 @@
+package ledger_test
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/luca-moser/iota/ledger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type owner struct {
+	prvKey ed25519.PrivateKey
+	addr   *iota.Ed25519Address
+}
+
+func newOwner() *owner {
+	prvKey := ed25519.NewKeyFromSeed(randBytes(ed25519.SeedSize))
+	addr := iota.AddressFromEd25519PubKey(prvKey.Public().(ed25519.PublicKey))
+	return &owner{prvKey: prvKey, addr: &addr}
+}
+
+// send returns a message carrying a transaction which moves the given outputs onto the given address.
+func (o *owner) send(t *testing.T, to iota.Serializable, amount uint64, outputs ...*iota.UnspentOutput) *iota.Message {
+	builder := iota.NewTransactionBuilder().SetRemainderAddress(o.addr)
+	for _, output := range outputs {
+		builder.AddInput(&iota.ToBeSignedUTXOInput{Input: output.Input, Amount: output.Amount, PrivateKey: o.prvKey})
+	}
+	payload, err := builder.AddOutput(&iota.SigLockedSingleDeposit{Address: to, Amount: amount}).Build()
+	require.NoError(t, err)
+	msg := &iota.Message{Payload: payload}
+	copy(msg.Parent1[:], randBytes(iota.MessageHashLength))
+	copy(msg.Parent2[:], randBytes(iota.MessageHashLength))
+	return msg
+}
+
+func outputOf(t *testing.T, msg *iota.Message, addr *iota.Ed25519Address) *iota.UnspentOutput {
+	outputs, err := ledger.OutputsOfTransaction(msg.Payload.(*iota.SignedTransactionPayload))
+	require.NoError(t, err)
+	for _, output := range outputs {
+		if *output.Address.(*iota.Ed25519Address) == *addr {
+			return output
+		}
+	}
+	t.Fatalf("no output to %x", addr)
+	return nil
+}
+
+func TestResolveConflicts(t *testing.T) {
+	alice, bob, carol := newOwner(), newOwner(), newOwner()
+
+	l := ledger.New(0)
+	genesisOutput := &iota.UnspentOutput{Input: randUTXOInput(), Address: alice.addr, Amount: iota.TokenSupply}
+	require.NoError(t, l.Add(genesisOutput))
+
+	// alice sends to bob, then double spends the same output to carol
+	toBob := alice.send(t, bob.addr, 1000, genesisOutput)
+	doubleSpend := alice.send(t, carol.addr, 500, genesisOutput)
+	// bob forwards what he received within the same cone
+	bobToCarol := bob.send(t, carol.addr, 1000, outputOf(t, toBob, bob.addr))
+	// carol tries to spend bob's original output again
+	bobAgain := bob.send(t, alice.addr, 1000, outputOf(t, toBob, bob.addr))
+	// a message without a transaction
+	indexation := &iota.Message{Payload: &iota.IndexationPayload{Index: "test", Data: []byte{1}}}
+
+	messages := []*iota.Message{toBob, indexation, doubleSpend, bobToCarol, bobAgain, toBob}
+
+	resolution, err := ledger.ResolveConflicts(l.LookupUTXO, 1, messages)
+	require.NoError(t, err)
+	require.Len(t, resolution.Transactions, 4)
+
+	assert.True(t, resolution.Transactions[0].Applied())
+	assert.Equal(t, iota.ConflictInputUTXOAlreadySpent, resolution.Transactions[1].Conflict)
+	assert.True(t, resolution.Transactions[2].Applied())
+	assert.Equal(t, iota.ConflictInputUTXOAlreadySpent, resolution.Transactions[3].Conflict)
+	assert.Error(t, resolution.Transactions[3].Err)
+
+	toBobID, err := toBob.ID()
+	require.NoError(t, err)
+	bobToCarolID, err := bobToCarol.ID()
+	require.NoError(t, err)
+	assert.Equal(t, []iota.MessageID{toBobID, bobToCarolID}, resolution.AppliedMessageIDs())
+
+	// the intermediate output of bob is created and consumed by the transactions
+	bobsOutput := outputOf(t, toBob, bob.addr)
+	assert.Equal(t, []*iota.UnspentOutput{genesisOutput}, resolution.Transactions[0].Consumed)
+	assert.Contains(t, resolution.Transactions[0].Created, bobsOutput)
+	assert.Equal(t, []*iota.UnspentOutput{bobsOutput}, resolution.Transactions[2].Consumed)
+	assert.Len(t, resolution.Transactions[2].Created, 1)
+	assert.Nil(t, resolution.Transactions[1].Consumed)
+	assert.Nil(t, resolution.Transactions[1].Created)
+
+	// but neither created nor consumed by the diff
+	assert.Equal(t, []*iota.UnspentOutput{genesisOutput}, resolution.Diff.Consumed)
+	assert.Len(t, resolution.Diff.Created, 2)
+
+	require.NoError(t, l.ApplyDiff(resolution.Diff))
+	assert.NoError(t, l.CheckTotal())
+
+	balance, err := l.Balance(carol.addr)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1000, balance)
+	balance, err = l.Balance(bob.addr)
+	require.NoError(t, err)
+	assert.Zero(t, balance)
+}
+
+func TestResolveConflictsDuplicateInputs(t *testing.T) {
+	alice, bob := newOwner(), newOwner()
+
+	l := ledger.New(0)
+	genesisOutput := &iota.UnspentOutput{Input: randUTXOInput(), Address: alice.addr, Amount: iota.TokenSupply}
+	require.NoError(t, l.Add(genesisOutput))
+
+	// a transaction attached in-process without validation, which spends the same output twice
+	duplicate := alice.send(t, bob.addr, 1000, genesisOutput)
+	payload := duplicate.Payload.(*iota.SignedTransactionPayload)
+	tx := payload.Transaction.(*iota.UnsignedTransaction)
+	tx.Inputs = append(tx.Inputs, tx.Inputs[0])
+	payload.UnlockBlocks = append(payload.UnlockBlocks, &iota.ReferenceUnlockBlock{Reference: 0})
+	valid := alice.send(t, bob.addr, 1000, genesisOutput)
+
+	resolution, err := ledger.ResolveConflicts(l.LookupUTXO, 1, []*iota.Message{duplicate, valid})
+	require.NoError(t, err)
+	require.Len(t, resolution.Transactions, 2)
+	assert.Equal(t, iota.ConflictUnsupported, resolution.Transactions[0].Conflict)
+	assert.True(t, resolution.Transactions[1].Applied())
+
+	require.NoError(t, l.ApplyDiff(resolution.Diff))
+	assert.NoError(t, l.CheckTotal())
+}
+
+func TestResolveConflictsNotFound(t *testing.T) {
+	alice := newOwner()
+	l := ledger.New(0)
+
+	missing := &iota.UnspentOutput{Input: randUTXOInput(), Address: alice.addr, Amount: 100}
+	msg := alice.send(t, newOwner().addr, 100, missing)
+
+	resolution, err := ledger.ResolveConflicts(l.LookupUTXO, 1, []*iota.Message{msg})
+	require.NoError(t, err)
+	require.Len(t, resolution.Transactions, 1)
+	assert.Equal(t, iota.ConflictInputUTXONotFound, resolution.Transactions[0].Conflict)
+	assert.Empty(t, resolution.Diff.Created)
+	assert.Empty(t, resolution.Diff.Consumed)
+}
@@ -0,0 +1,486 @@
+// Package ledger implements an in-memory UTXO ledger which is mutated by milestones.
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/luca-moser/iota"
+)
+
+var (
+	ErrOutputNotFound         = errors.New("output not found in the unspent output set")
+	ErrOutputAlreadyExists    = errors.New("output already exists in the unspent output set")
+	ErrOutputConsumedTwice    = errors.New("output is consumed twice")
+	ErrDiffUnbalanced         = errors.New("the consumed and created outputs of the diff don't balance")
+	ErrMilestoneIndexMismatch = errors.New("milestone index doesn't follow the ledger's milestone index")
+	ErrTotalSupplyMismatch    = errors.New("the sum of the unspent outputs doesn't equal the total supply")
+	ErrUnsupportedTransaction = errors.New("unsupported transaction type")
+	ErrUnsupportedOutput      = errors.New("unsupported output type")
+)
+
+// OutputID identifies an output by the ID of the transaction which created it and its index within it,
+// see iota.UTXOInput.OutputID.
+type OutputID [iota.OutputIDLength]byte
+
+// OutputIDFromUTXOInput returns the OutputID of the output referenced by the given UTXOInput.
+func OutputIDFromUTXOInput(input *iota.UTXOInput) OutputID {
+	return input.OutputID()
+}
+
+// UTXOInput returns the UTXOInput referencing the output with this ID.
+func (id OutputID) UTXOInput() *iota.UTXOInput {
+	return iota.UTXOInputFromOutputID(id)
+}
+
+// Diff defines the outputs a milestone created and consumed.
+type Diff struct {
+	// The index of the milestone which produced the diff.
+	MilestoneIndex uint64 `json:"milestone_index"`
+	// The outputs created by the milestone.
+	Created []*iota.UnspentOutput `json:"created"`
+	// The outputs consumed by the milestone.
+	Consumed []*iota.UnspentOutput `json:"consumed"`
+}
+
+// View gives read access to the unspent output set.
+type View interface {
+	// Output returns the unspent output referenced by the given input or ErrOutputNotFound.
+	Output(input *iota.UTXOInput) (*iota.UnspentOutput, error)
+}
+
+// Ledger holds the set of unspent outputs and the index of the milestone it reflects.
+// It is safe for concurrent use.
+type Ledger struct {
+	mu             sync.RWMutex
+	milestoneIndex uint64
+	unspent        map[OutputID]*iota.UnspentOutput
+	byAddr         map[string]map[OutputID]struct{}
+	spent          map[OutputID]*spentOutput
+}
+
+// spentOutput is an output which was consumed by the milestone with the given index.
+type spentOutput struct {
+	output         *iota.UnspentOutput
+	milestoneIndex uint64
+}
+
+// New creates a new empty Ledger at the given milestone index.
+func New(milestoneIndex uint64) *Ledger {
+	return &Ledger{
+		milestoneIndex: milestoneIndex,
+		unspent:        make(map[OutputID]*iota.UnspentOutput),
+		byAddr:         make(map[string]map[OutputID]struct{}),
+		spent:          make(map[OutputID]*spentOutput),
+	}
+}
+
+// LSUTXOConsumer returns an iota.LSUTXOConsumerFunc which loads the unspent outputs of a local snapshot into the ledger.
+func (l *Ledger) LSUTXOConsumer() iota.LSUTXOConsumerFunc {
+	return func(txOutputs *iota.LSTransactionUnspentOutputs) error {
+		return l.Add(iota.UnspentOutputsFromLS(txOutputs)...)
+	}
+}
+
+// Add adds the given outputs to the unspent output set without any balance checks.
+// It is meant to load an initial state, for example from a local snapshot.
+func (l *Ledger) Add(outputs ...*iota.UnspentOutput) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, output := range outputs {
+		id := OutputIDFromUTXOInput(output.Input)
+		if _, has := l.unspent[id]; has {
+			return fmt.Errorf("%w: %x", ErrOutputAlreadyExists, id)
+		}
+		key, err := addrKey(output.Address)
+		if err != nil {
+			return err
+		}
+		l.add(id, key, output)
+	}
+	return nil
+}
+
+// MilestoneIndex returns the index of the milestone the ledger reflects.
+func (l *Ledger) MilestoneIndex() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.milestoneIndex
+}
+
+// Output returns the unspent output referenced by the given input or ErrOutputNotFound.
+func (l *Ledger) Output(input *iota.UTXOInput) (*iota.UnspentOutput, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.output(input)
+}
+
+// LookupUTXO implements iota.UTXOLookupFunc. Outputs which were spent are only known
+// until they are pruned via PruneSpent.
+func (l *Ledger) LookupUTXO(input *iota.UTXOInput) (*iota.UnspentOutput, bool, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	id := OutputIDFromUTXOInput(input)
+	if output, has := l.unspent[id]; has {
+		return output, false, nil
+	}
+	if spent, has := l.spent[id]; has {
+		return spent.output, true, nil
+	}
+	return nil, false, nil
+}
+
+// PruneSpent forgets about the outputs which were spent by milestones below the given index.
+func (l *Ledger) PruneSpent(belowMilestoneIndex uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for id, spent := range l.spent {
+		if spent.milestoneIndex < belowMilestoneIndex {
+			delete(l.spent, id)
+		}
+	}
+}
+
+// OutputsByAddress returns the unspent outputs depositing onto the given address.
+func (l *Ledger) OutputsByAddress(addr iota.Serializable) ([]*iota.UnspentOutput, error) {
+	key, err := addrKey(addr)
+	if err != nil {
+		return nil, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	outputs := make([]*iota.UnspentOutput, 0, len(l.byAddr[key]))
+	for id := range l.byAddr[key] {
+		outputs = append(outputs, l.unspent[id])
+	}
+	return outputs, nil
+}
+
+// Balance returns the sum of the unspent outputs depositing onto the given address.
+func (l *Ledger) Balance(addr iota.Serializable) (uint64, error) {
+	outputs, err := l.OutputsByAddress(addr)
+	if err != nil {
+		return 0, err
+	}
+	var balance uint64
+	for _, output := range outputs {
+		balance += output.Amount
+	}
+	return balance, nil
+}
+
+// Total returns the sum of all unspent outputs.
+func (l *Ledger) Total() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var total uint64
+	for _, output := range l.unspent {
+		total += output.Amount
+	}
+	return total
+}
+
+// CheckTotal checks whether the sum of all unspent outputs equals the total supply.
+func (l *Ledger) CheckTotal() error {
+	if total := l.Total(); total != iota.TokenSupply {
+		return fmt.Errorf("%w: total is %d", ErrTotalSupplyMismatch, total)
+	}
+	return nil
+}
+
+// ForEach calls f for every unspent output until f returns false.
+// The ledger must not be mutated from within f.
+func (l *Ledger) ForEach(f func(output *iota.UnspentOutput) bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, output := range l.unspent {
+		if !f(output) {
+			return
+		}
+	}
+}
+
+// ComputeDiff computes the Diff which applying the given confirmed transactions in order would produce.
+// Transactions may consume outputs created by previous transactions of the same milestone,
+// such outputs are neither part of the created nor consumed outputs of the Diff.
+func (l *Ledger) ComputeDiff(milestoneIndex uint64, payloads ...*iota.SignedTransactionPayload) (*Diff, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return computeDiff(viewFunc(l.output), milestoneIndex, payloads)
+}
+
+// ApplyMilestone applies the given confirmed transactions of a milestone onto the ledger and returns the applied Diff.
+func (l *Ledger) ApplyMilestone(milestoneIndex uint64, payloads ...*iota.SignedTransactionPayload) (*Diff, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	diff, err := computeDiff(viewFunc(l.output), milestoneIndex, payloads)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.applyDiff(diff); err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// ApplyDiff applies the given Diff onto the ledger. The Diff is either applied entirely or not at all.
+func (l *Ledger) ApplyDiff(diff *Diff) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.applyDiff(diff)
+}
+
+// RollbackDiff reverts the given Diff, which must be the last one applied onto the ledger.
+// The Diff is either reverted entirely or not at all.
+func (l *Ledger) RollbackDiff(diff *Diff) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if diff.MilestoneIndex != l.milestoneIndex {
+		return fmt.Errorf("%w: can't roll back milestone %d, ledger is at %d", ErrMilestoneIndexMismatch, diff.MilestoneIndex, l.milestoneIndex)
+	}
+
+	createdIDs, consumedIDs, err := checkDiff(diff)
+	if err != nil {
+		return err
+	}
+	for i, id := range createdIDs {
+		if _, has := l.unspent[id]; !has {
+			return fmt.Errorf("%w: created output %d of diff", ErrOutputNotFound, i)
+		}
+	}
+	for i, id := range consumedIDs {
+		if _, has := l.unspent[id]; has {
+			return fmt.Errorf("%w: consumed output %d of diff", ErrOutputAlreadyExists, i)
+		}
+	}
+	consumedKeys, err := addrKeys(diff.Consumed)
+	if err != nil {
+		return fmt.Errorf("consumed %w", err)
+	}
+
+	for i, id := range createdIDs {
+		l.remove(id, diff.Created[i])
+	}
+	for i, id := range consumedIDs {
+		delete(l.spent, id)
+		l.add(id, consumedKeys[i], diff.Consumed[i])
+	}
+	l.milestoneIndex = diff.MilestoneIndex - 1
+	return nil
+}
+
+func (l *Ledger) applyDiff(diff *Diff) error {
+	if diff.MilestoneIndex != l.milestoneIndex+1 {
+		return fmt.Errorf("%w: can't apply milestone %d, ledger is at %d", ErrMilestoneIndexMismatch, diff.MilestoneIndex, l.milestoneIndex)
+	}
+
+	createdIDs, consumedIDs, err := checkDiff(diff)
+	if err != nil {
+		return err
+	}
+
+	// check everything before mutating in order to keep the application atomic
+	for i, id := range consumedIDs {
+		if _, has := l.unspent[id]; !has {
+			return fmt.Errorf("%w: consumed output %d of diff", ErrOutputNotFound, i)
+		}
+	}
+	for i, id := range createdIDs {
+		if _, has := l.unspent[id]; has {
+			return fmt.Errorf("%w: created output %d of diff", ErrOutputAlreadyExists, i)
+		}
+	}
+	createdKeys, err := addrKeys(diff.Created)
+	if err != nil {
+		return fmt.Errorf("created %w", err)
+	}
+
+	for i, id := range consumedIDs {
+		l.remove(id, diff.Consumed[i])
+		l.spent[id] = &spentOutput{output: diff.Consumed[i], milestoneIndex: diff.MilestoneIndex}
+	}
+	for i, id := range createdIDs {
+		l.add(id, createdKeys[i], diff.Created[i])
+	}
+	l.milestoneIndex = diff.MilestoneIndex
+	return nil
+}
+
+// output returns the unspent output for the given input. The caller must hold the lock.
+func (l *Ledger) output(input *iota.UTXOInput) (*iota.UnspentOutput, error) {
+	output, has := l.unspent[OutputIDFromUTXOInput(input)]
+	if !has {
+		return nil, fmt.Errorf("%w: %x:%d", ErrOutputNotFound, input.TransactionID, input.TransactionOutputIndex)
+	}
+	return output, nil
+}
+
+// add adds the given output indexed under the given address key. The caller must hold the lock.
+func (l *Ledger) add(id OutputID, key string, output *iota.UnspentOutput) {
+	l.unspent[id] = output
+	set, has := l.byAddr[key]
+	if !has {
+		set = make(map[OutputID]struct{})
+		l.byAddr[key] = set
+	}
+	set[id] = struct{}{}
+}
+
+func (l *Ledger) remove(id OutputID, output *iota.UnspentOutput) {
+	delete(l.unspent, id)
+	key, err := addrKey(output.Address)
+	if err != nil {
+		// the output could not have been added in the first place
+		return
+	}
+	set := l.byAddr[key]
+	delete(set, id)
+	if len(set) == 0 {
+		delete(l.byAddr, key)
+	}
+}
+
+// viewFunc adapts a function to the View interface.
+type viewFunc func(input *iota.UTXOInput) (*iota.UnspentOutput, error)
+
+func (f viewFunc) Output(input *iota.UTXOInput) (*iota.UnspentOutput, error) {
+	return f(input)
+}
+
+// checkDiff checks that the given Diff's outputs balance and returns their OutputIDs.
+func checkDiff(diff *Diff) ([]OutputID, []OutputID, error) {
+	var createdSum, consumedSum uint64
+	createdIDs := make([]OutputID, len(diff.Created))
+	for i, output := range diff.Created {
+		createdIDs[i] = OutputIDFromUTXOInput(output.Input)
+		if createdSum+output.Amount < createdSum || createdSum+output.Amount > iota.TokenSupply {
+			return nil, nil, fmt.Errorf("%w: created outputs exceed the total supply", ErrDiffUnbalanced)
+		}
+		createdSum += output.Amount
+	}
+	consumedIDs := make([]OutputID, len(diff.Consumed))
+	seen := make(map[OutputID]struct{}, len(diff.Consumed))
+	for i, output := range diff.Consumed {
+		id := OutputIDFromUTXOInput(output.Input)
+		if _, has := seen[id]; has {
+			return nil, nil, fmt.Errorf("%w: consumed output %d of diff", ErrOutputConsumedTwice, i)
+		}
+		seen[id] = struct{}{}
+		consumedIDs[i] = id
+		if consumedSum+output.Amount < consumedSum || consumedSum+output.Amount > iota.TokenSupply {
+			return nil, nil, fmt.Errorf("%w: consumed outputs exceed the total supply", ErrDiffUnbalanced)
+		}
+		consumedSum += output.Amount
+	}
+	if createdSum != consumedSum {
+		return nil, nil, fmt.Errorf("%w: created %d, consumed %d", ErrDiffUnbalanced, createdSum, consumedSum)
+	}
+	return createdIDs, consumedIDs, nil
+}
+
+// computeDiff computes the Diff of the given transactions against the given View.
+func computeDiff(view View, milestoneIndex uint64, payloads []*iota.SignedTransactionPayload) (*Diff, error) {
+	diff := &Diff{MilestoneIndex: milestoneIndex}
+
+	// outputs created within this diff which haven't been consumed by a later transaction yet
+	created := make(map[OutputID]*iota.UnspentOutput)
+	var createdOrder []OutputID
+	consumed := make(map[OutputID]struct{})
+
+	for i, payload := range payloads {
+		tx, ok := payload.Transaction.(*iota.UnsignedTransaction)
+		if !ok {
+			return nil, fmt.Errorf("%w: transaction %d is %T", ErrUnsupportedTransaction, i, payload.Transaction)
+		}
+
+		for _, input := range tx.Inputs {
+			utxoInput, ok := input.(*iota.UTXOInput)
+			if !ok {
+				return nil, fmt.Errorf("%w: transaction %d has input %T", iota.ErrUnknownInputType, i, input)
+			}
+			id := OutputIDFromUTXOInput(utxoInput)
+			if _, has := consumed[id]; has {
+				return nil, fmt.Errorf("%w: transaction %d", ErrOutputConsumedTwice, i)
+			}
+			consumed[id] = struct{}{}
+
+			if _, has := created[id]; has {
+				delete(created, id)
+				continue
+			}
+
+			output, err := view.Output(utxoInput)
+			if err != nil {
+				return nil, fmt.Errorf("transaction %d: %w", i, err)
+			}
+			diff.Consumed = append(diff.Consumed, output)
+		}
+
+		outputs, err := OutputsOfTransaction(payload)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		for _, output := range outputs {
+			id := OutputIDFromUTXOInput(output.Input)
+			created[id] = output
+			createdOrder = append(createdOrder, id)
+		}
+	}
+
+	for _, id := range createdOrder {
+		if output, has := created[id]; has {
+			diff.Created = append(diff.Created, output)
+		}
+	}
+
+	return diff, nil
+}
+
+// OutputsOfTransaction returns the outputs the given transaction creates.
+func OutputsOfTransaction(payload *iota.SignedTransactionPayload) ([]*iota.UnspentOutput, error) {
+	tx, ok := payload.Transaction.(*iota.UnsignedTransaction)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedTransaction, payload.Transaction)
+	}
+	txID, err := payload.ID()
+	if err != nil {
+		return nil, err
+	}
+	outputs := make([]*iota.UnspentOutput, len(tx.Outputs))
+	for i, output := range tx.Outputs {
+		dep, ok := output.(*iota.SigLockedSingleDeposit)
+		if !ok {
+			return nil, fmt.Errorf("%w: output %d is %T", ErrUnsupportedOutput, i, output)
+		}
+		outputs[i] = &iota.UnspentOutput{
+			Input:   &iota.UTXOInput{TransactionID: txID, TransactionOutputIndex: uint16(i)},
+			Address: dep.Address,
+			Amount:  dep.Amount,
+		}
+	}
+	return outputs, nil
+}
+
+// addrKey returns the key under which outputs of the given address are indexed.
+func addrKey(addr iota.Serializable) (string, error) {
+	addrData, err := addr.Serialize(iota.DeSeriModeNoValidation)
+	if err != nil {
+		return "", err
+	}
+	return string(addrData), nil
+}
+
+// addrKeys returns the address keys of the given outputs.
+func addrKeys(outputs []*iota.UnspentOutput) ([]string, error) {
+	keys := make([]string, len(outputs))
+	for i, output := range outputs {
+		key, err := addrKey(output.Address)
+		if err != nil {
+			return nil, fmt.Errorf("output %d of diff: %w", i, err)
+		}
+		keys[i] = key
+	}
+	return keys, nil
+}
@@ -0,0 +1,247 @@
+package ledger_test
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/luca-moser/iota/ledger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func randBytes(length int) []byte {
+	b := make([]byte, length)
+	rand.Read(b)
+	return b
+}
+
+func randEd25519Addr() *iota.Ed25519Address {
+	addr := &iota.Ed25519Address{}
+	copy(addr[:], randBytes(iota.Ed25519AddressBytesLength))
+	return addr
+}
+
+var errUnserializable = errors.New("unserializable")
+
+// unserializableAddr is an address which fails to serialize.
+type unserializableAddr struct{}
+
+func (unserializableAddr) Deserialize([]byte, iota.DeSerializationMode) (int, error) {
+	return 0, errUnserializable
+}
+
+func (unserializableAddr) Serialize(iota.DeSerializationMode) ([]byte, error) {
+	return nil, errUnserializable
+}
+
+func randUTXOInput() *iota.UTXOInput {
+	input := &iota.UTXOInput{TransactionOutputIndex: uint16(rand.Intn(iota.RefUTXOIndexMax))}
+	copy(input.TransactionID[:], randBytes(iota.TransactionIDLength))
+	return input
+}
+
+// genesis returns a ledger holding the total supply on the given amount of outputs.
+func genesis(t *testing.T, count int) (*ledger.Ledger, []*iota.UnspentOutput) {
+	l := ledger.New(0)
+	outputs := make([]*iota.UnspentOutput, count)
+	var sum uint64
+	for i := 0; i < count; i++ {
+		amount := uint64(iota.TokenSupply / count)
+		if i == count-1 {
+			amount = iota.TokenSupply - sum
+		}
+		sum += amount
+		outputs[i] = &iota.UnspentOutput{Input: randUTXOInput(), Address: randEd25519Addr(), Amount: amount}
+	}
+	require.NoError(t, l.Add(outputs...))
+	require.NoError(t, l.CheckTotal())
+	return l, outputs
+}
+
+// spend returns a transaction which moves the given outputs onto the given address.
+func spend(t *testing.T, to iota.Serializable, outputs ...*iota.UnspentOutput) *iota.SignedTransactionPayload {
+	seed := randBytes(ed25519.SeedSize)
+	builder := iota.NewTransactionBuilder()
+	var sum uint64
+	for _, output := range outputs {
+		builder.AddInput(&iota.ToBeSignedUTXOInput{Input: output.Input, Amount: output.Amount, PrivateKey: ed25519.NewKeyFromSeed(seed)})
+		sum += output.Amount
+	}
+	payload, err := builder.AddOutput(&iota.SigLockedSingleDeposit{Address: to, Amount: sum}).Build()
+	require.NoError(t, err)
+	return payload
+}
+
+func TestOutputID(t *testing.T) {
+	input := randUTXOInput()
+	assert.Equal(t, input, ledger.OutputIDFromUTXOInput(input).UTXOInput())
+}
+
+func TestLedger_ApplyMilestoneAndRollback(t *testing.T) {
+	l, outputs := genesis(t, 3)
+
+	target := randEd25519Addr()
+	tx1 := spend(t, target, outputs[0], outputs[1])
+	created, err := ledger.OutputsOfTransaction(tx1)
+	require.NoError(t, err)
+
+	// the second transaction consumes the output of the first one within the same milestone
+	finalAddr := randEd25519Addr()
+	tx2 := spend(t, finalAddr, created[0])
+
+	diff, err := l.ApplyMilestone(1, tx1, tx2)
+	require.NoError(t, err)
+	require.NoError(t, l.CheckTotal())
+	assert.EqualValues(t, 1, l.MilestoneIndex())
+	assert.Len(t, diff.Consumed, 2)
+	assert.Len(t, diff.Created, 1)
+
+	_, err = l.Output(outputs[0].Input)
+	assert.True(t, errors.Is(err, ledger.ErrOutputNotFound))
+
+	balance, err := l.Balance(target)
+	require.NoError(t, err)
+	assert.Zero(t, balance)
+
+	balance, err = l.Balance(finalAddr)
+	require.NoError(t, err)
+	assert.Equal(t, outputs[0].Amount+outputs[1].Amount, balance)
+
+	require.NoError(t, l.RollbackDiff(diff))
+	require.NoError(t, l.CheckTotal())
+	assert.EqualValues(t, 0, l.MilestoneIndex())
+	for _, output := range outputs {
+		restored, err := l.Output(output.Input)
+		require.NoError(t, err)
+		assert.Equal(t, output, restored)
+	}
+	balance, err = l.Balance(finalAddr)
+	require.NoError(t, err)
+	assert.Zero(t, balance)
+}
+
+func TestLedger_ApplyMilestoneAtomic(t *testing.T) {
+	l, outputs := genesis(t, 3)
+
+	tx1 := spend(t, randEd25519Addr(), outputs[0])
+	// consumes an output which doesn't exist
+	tx2 := spend(t, randEd25519Addr(), &iota.UnspentOutput{Input: randUTXOInput(), Address: randEd25519Addr(), Amount: 10})
+
+	_, err := l.ApplyMilestone(1, tx1, tx2)
+	assert.True(t, errors.Is(err, ledger.ErrOutputNotFound))
+
+	// nothing must have been applied
+	_, err = l.Output(outputs[0].Input)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, l.MilestoneIndex())
+
+	// double spend within the same milestone
+	tx3 := spend(t, randEd25519Addr(), outputs[0])
+	_, err = l.ApplyMilestone(1, tx1, tx3)
+	assert.True(t, errors.Is(err, ledger.ErrOutputConsumedTwice))
+}
+
+func TestLedger_ApplyDiff(t *testing.T) {
+	l, outputs := genesis(t, 2)
+
+	tests := []struct {
+		name string
+		diff *ledger.Diff
+		err  error
+	}{
+		{"wrong milestone index", &ledger.Diff{MilestoneIndex: 5}, ledger.ErrMilestoneIndexMismatch},
+		{"unbalanced", &ledger.Diff{
+			MilestoneIndex: 1,
+			Consumed:       []*iota.UnspentOutput{outputs[0]},
+			Created:        []*iota.UnspentOutput{{Input: randUTXOInput(), Address: randEd25519Addr(), Amount: 1}},
+		}, ledger.ErrDiffUnbalanced},
+		{"overflowing", &ledger.Diff{
+			MilestoneIndex: 1,
+			Consumed:       []*iota.UnspentOutput{outputs[0]},
+			Created: []*iota.UnspentOutput{
+				{Input: randUTXOInput(), Address: randEd25519Addr(), Amount: math.MaxUint64},
+				{Input: randUTXOInput(), Address: randEd25519Addr(), Amount: outputs[0].Amount + 1},
+			},
+		}, ledger.ErrDiffUnbalanced},
+		{"created exists", &ledger.Diff{
+			MilestoneIndex: 1,
+			Consumed:       []*iota.UnspentOutput{outputs[0]},
+			Created:        []*iota.UnspentOutput{{Input: outputs[1].Input, Address: randEd25519Addr(), Amount: outputs[0].Amount}},
+		}, ledger.ErrOutputAlreadyExists},
+		// the ledger must be left untouched for the next case to apply
+		{"unserializable created address", &ledger.Diff{
+			MilestoneIndex: 1,
+			Consumed:       []*iota.UnspentOutput{outputs[0]},
+			Created:        []*iota.UnspentOutput{{Input: randUTXOInput(), Address: unserializableAddr{}, Amount: outputs[0].Amount}},
+		}, errUnserializable},
+		{"ok", &ledger.Diff{
+			MilestoneIndex: 1,
+			Consumed:       []*iota.UnspentOutput{outputs[0]},
+			Created:        []*iota.UnspentOutput{{Input: randUTXOInput(), Address: randEd25519Addr(), Amount: outputs[0].Amount}},
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := l.ApplyDiff(tt.diff)
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, l.CheckTotal())
+		})
+	}
+}
+
+func TestLedger_LSUTXOConsumer(t *testing.T) {
+	l := ledger.New(0)
+	addr := randEd25519Addr()
+	txOutputs := &iota.LSTransactionUnspentOutputs{
+		UnspentOutputs: []*iota.LSUnspentOutput{
+			{Index: 0, Address: addr, Value: 100},
+			{Index: 1, Address: addr, Value: iota.TokenSupply - 100},
+		},
+	}
+	copy(txOutputs.TransactionHash[:], randBytes(iota.TransactionIDLength))
+
+	require.NoError(t, l.LSUTXOConsumer()(txOutputs))
+	assert.NoError(t, l.CheckTotal())
+
+	balance, err := l.Balance(addr)
+	require.NoError(t, err)
+	assert.EqualValues(t, iota.TokenSupply, balance)
+
+	assert.True(t, errors.Is(l.LSUTXOConsumer()(txOutputs), ledger.ErrOutputAlreadyExists))
+}
+
+func TestLedger_LookupUTXO(t *testing.T) {
+	l, outputs := genesis(t, 2)
+
+	tx := spend(t, randEd25519Addr(), outputs[0])
+	_, err := l.ApplyMilestone(1, tx)
+	require.NoError(t, err)
+
+	output, spent, err := l.LookupUTXO(outputs[0].Input)
+	require.NoError(t, err)
+	assert.True(t, spent)
+	assert.Equal(t, outputs[0], output)
+
+	output, spent, err = l.LookupUTXO(outputs[1].Input)
+	require.NoError(t, err)
+	assert.False(t, spent)
+	assert.Equal(t, outputs[1], output)
+
+	// a transaction spending the already spent output conflicts
+	conflict, err := spend(t, randEd25519Addr(), outputs[0]).SemanticallyValid(l.LookupUTXO)
+	assert.Equal(t, iota.ConflictInputUTXOAlreadySpent, conflict)
+	assert.True(t, errors.Is(err, iota.ErrInputUTXOAlreadySpent))
+
+	l.PruneSpent(2)
+	output, _, err = l.LookupUTXO(outputs[0].Input)
+	require.NoError(t, err)
+	assert.Nil(t, output)
+}
@@ -3,7 +3,12 @@ package iota
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"strconv"
+
+	"golang.org/x/crypto/blake2b"
 )
 
 const (
@@ -19,6 +24,8 @@ func PayloadSelector(payloadType uint32) (Serializable, error) {
 	switch payloadType {
 	case SignedTransactionPayloadID:
 		seri = &SignedTransactionPayload{}
+	case MilestonePayloadID:
+		seri = &MilestonePayload{}
 	case IndexationPayloadID:
 		seri = &IndexationPayload{}
 	default:
@@ -27,6 +34,23 @@ func PayloadSelector(payloadType uint32) (Serializable, error) {
 	return seri, nil
 }
 
+// MessageID is the ID of a Message, which is the BLAKE2b-256 hash of the serialized message.
+type MessageID = [MessageHashLength]byte
+
+// MessageIDFromHex parses the given hex encoded message ID.
+func MessageIDFromHex(s string) (MessageID, error) {
+	var id MessageID
+	idBytes, err := hex.DecodeString(s)
+	if err != nil {
+		return id, fmt.Errorf("%w: message ID is not valid hex: %v", ErrInvalidBytes, err)
+	}
+	if err := checkExactByteLength(MessageHashLength, len(idBytes)); err != nil {
+		return id, fmt.Errorf("invalid message ID: %w", err)
+	}
+	copy(id[:], idBytes)
+	return id, nil
+}
+
 // Message carries a payload and references two other messages.
 type Message struct {
 	Parent1 [MessageHashLength]byte `json:"parent_1"`
@@ -118,3 +142,80 @@ func (m *Message) Serialize(deSeriMode DeSerializationMode) ([]byte, error) {
 
 	return b.Bytes(), nil
 }
+
+// jsonMessage defines the JSON form of a Message.
+type jsonMessage struct {
+	Version int             `json:"version"`
+	Parent1 string          `json:"parent_1"`
+	Parent2 string          `json:"parent_2"`
+	Payload json.RawMessage `json:"payload"`
+	Nonce   string          `json:"nonce"`
+}
+
+func (m *Message) MarshalJSON() ([]byte, error) {
+	payloadJSON, err := json.Marshal(m.Payload)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&jsonMessage{
+		Version: MessageVersion,
+		Parent1: hex.EncodeToString(m.Parent1[:]),
+		Parent2: hex.EncodeToString(m.Parent2[:]),
+		Payload: payloadJSON,
+		Nonce:   strconv.FormatUint(m.Nonce, 10),
+	})
+}
+
+func (m *Message) UnmarshalJSON(data []byte) error {
+	jMsg := &jsonMessage{}
+	if err := json.Unmarshal(data, jMsg); err != nil {
+		return err
+	}
+	if jMsg.Version != MessageVersion {
+		return fmt.Errorf("%w: message version must be %d but is %d", ErrDeserializationTypeMismatch, MessageVersion, jMsg.Version)
+	}
+	if err := decodeHexJSON("parent_1", jMsg.Parent1, m.Parent1[:]); err != nil {
+		return err
+	}
+	if err := decodeHexJSON("parent_2", jMsg.Parent2, m.Parent2[:]); err != nil {
+		return err
+	}
+	m.Payload = nil
+	if !isJSONNull(jMsg.Payload) {
+		payload, err := DeserializeObjectFromJSON(jMsg.Payload, PayloadSelector)
+		if err != nil {
+			return fmt.Errorf("unable to decode message payload: %w", err)
+		}
+		m.Payload = payload
+	}
+	nonce, err := strconv.ParseUint(jMsg.Nonce, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: nonce must be a decimal uint64: %v", ErrInvalidJSON, err)
+	}
+	m.Nonce = nonce
+	return nil
+}
+
+// ID computes the ID of the message.
+func (m *Message) ID() (MessageID, error) {
+	data, err := m.Serialize(DeSeriModeNoValidation)
+	if err != nil {
+		return MessageID{}, fmt.Errorf("can't compute message ID: %w", err)
+	}
+	return blake2b.Sum256(data), nil
+}
+
+// IndexationPayload returns the IndexationPayload the message carries, either as its payload or
+// within the unsigned transaction of its SignedTransactionPayload, and whether it carries one.
+func (m *Message) IndexationPayload() (*IndexationPayload, bool) {
+	payload := m.Payload
+	if sigTxPayload, ok := payload.(*SignedTransactionPayload); ok {
+		unsignedTx, ok := sigTxPayload.Transaction.(*UnsignedTransaction)
+		if !ok {
+			return nil, false
+		}
+		payload = unsignedTx.Payload
+	}
+	indexation, ok := payload.(*IndexationPayload)
+	return indexation, ok
+}
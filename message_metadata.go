@@ -0,0 +1,202 @@
+package iota
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	MessageMetadataVersion = 1
+	// version + flags + referenced milestone index + ledger inclusion state + conflict reason
+	MessageMetadataSize = MessageVersionByteSize + OneByte + UInt64ByteSize + OneByte + OneByte
+)
+
+var (
+	ErrInvalidMessageMetadata = errors.New("invalid message metadata")
+)
+
+// LedgerInclusionState defines whether a referenced message's transaction mutated the ledger.
+type LedgerInclusionState byte
+
+const (
+	// Denotes that the message doesn't carry a transaction.
+	LedgerInclusionNoTransaction LedgerInclusionState = iota
+	// Denotes that the message's transaction was applied onto the ledger.
+	LedgerInclusionIncluded
+	// Denotes that the message's transaction conflicts with the ledger and was not applied.
+	LedgerInclusionConflicting
+)
+
+func (s LedgerInclusionState) String() string {
+	switch s {
+	case LedgerInclusionNoTransaction:
+		return "noTransaction"
+	case LedgerInclusionIncluded:
+		return "included"
+	case LedgerInclusionConflicting:
+		return "conflicting"
+	default:
+		return fmt.Sprintf("unknown ledger inclusion state %d", byte(s))
+	}
+}
+
+const (
+	// Denotes that the message is solid.
+	messageMetadataFlagSolid byte = 1 << 0
+	// Denotes that the message was referenced by a milestone.
+	messageMetadataFlagReferenced byte = 1 << 1
+	// All flags which are known.
+	messageMetadataFlagsMask = messageMetadataFlagSolid | messageMetadataFlagReferenced
+)
+
+// MessageMetadata holds the state a node tracks per message: whether it is solid, which milestone
+// referenced it and whether its transaction was included in or conflicts with the ledger.
+// All accessors are safe for concurrent use.
+type MessageMetadata struct {
+	mu                sync.RWMutex
+	solid             bool
+	referenced        bool
+	referencedByIndex uint64
+	inclusionState    LedgerInclusionState
+	conflictReason    ConflictReason
+}
+
+// IsSolid tells whether the message is solid.
+func (m *MessageMetadata) IsSolid() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.solid
+}
+
+// SetSolid marks the message as solid and returns whether it wasn't solid before.
+func (m *MessageMetadata) SetSolid() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.solid {
+		return false
+	}
+	m.solid = true
+	return true
+}
+
+// ReferencedBy returns the index of the milestone which referenced the message and whether it was referenced at all.
+func (m *MessageMetadata) ReferencedBy() (uint64, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.referencedByIndex, m.referenced
+}
+
+// SetReferenced marks the message as referenced by the milestone with the given index together with the
+// ledger inclusion state and conflict reason of its transaction. All values are updated at once, so readers
+// never observe a partially referenced message. It returns whether the message wasn't referenced before,
+// a message which is already referenced is left untouched.
+func (m *MessageMetadata) SetReferenced(milestoneIndex uint64, state LedgerInclusionState, conflict ConflictReason) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.referenced {
+		return false
+	}
+	m.referenced = true
+	m.referencedByIndex = milestoneIndex
+	m.inclusionState = state
+	m.conflictReason = conflict
+	return true
+}
+
+// LedgerInclusionState returns the ledger inclusion state of the message's transaction.
+// It is only meaningful once the message was referenced.
+func (m *MessageMetadata) LedgerInclusionState() LedgerInclusionState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.inclusionState
+}
+
+// ConflictReason returns the reason why the message's transaction conflicts, ConflictNone if it doesn't.
+func (m *MessageMetadata) ConflictReason() ConflictReason {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.conflictReason
+}
+
+func (m *MessageMetadata) Deserialize(data []byte, deSeriMode DeSerializationMode) (int, error) {
+	if deSeriMode.HasMode(DeSeriModePerformValidation) {
+		if err := checkMinByteLength(MessageMetadataSize, len(data)); err != nil {
+			return 0, fmt.Errorf("invalid message metadata bytes: %w", err)
+		}
+		if err := checkTypeByte(data, MessageMetadataVersion); err != nil {
+			return 0, fmt.Errorf("unable to deserialize message metadata: %w", err)
+		}
+	}
+
+	data = data[MessageVersionByteSize:]
+	flags := data[0]
+	data = data[OneByte:]
+	referencedByIndex := binary.LittleEndian.Uint64(data)
+	data = data[UInt64ByteSize:]
+	inclusionState := LedgerInclusionState(data[0])
+	conflictReason := ConflictReason(data[1])
+
+	if deSeriMode.HasMode(DeSeriModePerformValidation) {
+		if err := validateMessageMetadata(flags, referencedByIndex, inclusionState, conflictReason); err != nil {
+			return 0, err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.solid = flags&messageMetadataFlagSolid != 0
+	m.referenced = flags&messageMetadataFlagReferenced != 0
+	m.referencedByIndex = referencedByIndex
+	m.inclusionState = inclusionState
+	m.conflictReason = conflictReason
+	return MessageMetadataSize, nil
+}
+
+func (m *MessageMetadata) Serialize(deSeriMode DeSerializationMode) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var flags byte
+	if m.solid {
+		flags |= messageMetadataFlagSolid
+	}
+	if m.referenced {
+		flags |= messageMetadataFlagReferenced
+	}
+
+	if deSeriMode.HasMode(DeSeriModePerformValidation) {
+		if err := validateMessageMetadata(flags, m.referencedByIndex, m.inclusionState, m.conflictReason); err != nil {
+			return nil, err
+		}
+	}
+
+	var b [MessageMetadataSize]byte
+	b[0] = MessageMetadataVersion
+	b[1] = flags
+	binary.LittleEndian.PutUint64(b[2:], m.referencedByIndex)
+	b[10] = byte(m.inclusionState)
+	b[11] = byte(m.conflictReason)
+	return b[:], nil
+}
+
+// validateMessageMetadata checks whether the given metadata values are consistent with each other.
+func validateMessageMetadata(flags byte, referencedByIndex uint64, state LedgerInclusionState, conflict ConflictReason) error {
+	if flags&^messageMetadataFlagsMask != 0 {
+		return fmt.Errorf("%w: unknown flags %08b", ErrInvalidMessageMetadata, flags)
+	}
+	if state > LedgerInclusionConflicting {
+		return fmt.Errorf("%w: %s", ErrInvalidMessageMetadata, state)
+	}
+	if conflict > ConflictUnsupported {
+		return fmt.Errorf("%w: %s", ErrInvalidMessageMetadata, conflict)
+	}
+	if flags&messageMetadataFlagReferenced == 0 && (referencedByIndex != 0 || state != LedgerInclusionNoTransaction || conflict != ConflictNone) {
+		return fmt.Errorf("%w: unreferenced message holds confirmation state", ErrInvalidMessageMetadata)
+	}
+	if (state == LedgerInclusionConflicting) != (conflict != ConflictNone) {
+		return fmt.Errorf("%w: ledger inclusion state %s with conflict reason %s", ErrInvalidMessageMetadata, state, conflict)
+	}
+	return nil
+}
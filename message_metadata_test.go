@@ -0,0 +1,112 @@
+package iota_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageMetadata_SerializeDeserialize(t *testing.T) {
+	type test struct {
+		name   string
+		source func() *iota.MessageMetadata
+	}
+	tests := []test{
+		{"unsolid", func() *iota.MessageMetadata { return &iota.MessageMetadata{} }},
+		{"solid", func() *iota.MessageMetadata {
+			m := &iota.MessageMetadata{}
+			m.SetSolid()
+			return m
+		}},
+		{"included", func() *iota.MessageMetadata {
+			m := &iota.MessageMetadata{}
+			m.SetSolid()
+			m.SetReferenced(1337, iota.LedgerInclusionIncluded, iota.ConflictNone)
+			return m
+		}},
+		{"conflicting", func() *iota.MessageMetadata {
+			m := &iota.MessageMetadata{}
+			m.SetSolid()
+			m.SetReferenced(42, iota.LedgerInclusionConflicting, iota.ConflictInvalidSignature)
+			return m
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := tt.source()
+			data, err := source.Serialize(iota.DeSeriModePerformValidation)
+			require.NoError(t, err)
+			assert.Len(t, data, iota.MessageMetadataSize)
+
+			target := &iota.MessageMetadata{}
+			bytesRead, err := target.Deserialize(data, iota.DeSeriModePerformValidation)
+			require.NoError(t, err)
+			assert.Equal(t, iota.MessageMetadataSize, bytesRead)
+
+			assert.Equal(t, source.IsSolid(), target.IsSolid())
+			sourceIndex, sourceReferenced := source.ReferencedBy()
+			targetIndex, targetReferenced := target.ReferencedBy()
+			assert.Equal(t, sourceIndex, targetIndex)
+			assert.Equal(t, sourceReferenced, targetReferenced)
+			assert.Equal(t, source.LedgerInclusionState(), target.LedgerInclusionState())
+			assert.Equal(t, source.ConflictReason(), target.ConflictReason())
+		})
+	}
+}
+
+func TestMessageMetadata_Deserialize(t *testing.T) {
+	type test struct {
+		name   string
+		source []byte
+		err    error
+	}
+	tests := []test{
+		{"ok", []byte{iota.MessageMetadataVersion, 3, 5, 0, 0, 0, 0, 0, 0, 0, 2, 1}, nil},
+		{"not enough data", []byte{iota.MessageMetadataVersion, 3, 5}, iota.ErrDeserializationNotEnoughData},
+		{"wrong version", []byte{2, 3, 5, 0, 0, 0, 0, 0, 0, 0, 2, 1}, iota.ErrDeserializationTypeMismatch},
+		{"unknown flags", []byte{iota.MessageMetadataVersion, 7, 5, 0, 0, 0, 0, 0, 0, 0, 2, 1}, iota.ErrInvalidMessageMetadata},
+		{"unknown inclusion state", []byte{iota.MessageMetadataVersion, 3, 5, 0, 0, 0, 0, 0, 0, 0, 3, 0}, iota.ErrInvalidMessageMetadata},
+		{"conflict without conflicting", []byte{iota.MessageMetadataVersion, 3, 5, 0, 0, 0, 0, 0, 0, 0, 1, 1}, iota.ErrInvalidMessageMetadata},
+		{"unreferenced with index", []byte{iota.MessageMetadataVersion, 1, 5, 0, 0, 0, 0, 0, 0, 0, 0, 0}, iota.ErrInvalidMessageMetadata},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := (&iota.MessageMetadata{}).Deserialize(tt.source, iota.DeSeriModePerformValidation)
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err))
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestMessageMetadata_SetReferencedConcurrently(t *testing.T) {
+	m := &iota.MessageMetadata{}
+
+	var wg sync.WaitGroup
+	var updated int32
+	for i := 1; i <= 100; i++ {
+		wg.Add(1)
+		go func(index uint64) {
+			defer wg.Done()
+			if m.SetReferenced(index, iota.LedgerInclusionIncluded, iota.ConflictNone) {
+				atomic.AddInt32(&updated, 1)
+			}
+		}(uint64(i))
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 1, updated)
+	index, referenced := m.ReferencedBy()
+	assert.True(t, referenced)
+	assert.NotZero(t, index)
+	assert.False(t, m.SetReferenced(1000, iota.LedgerInclusionNoTransaction, iota.ConflictNone))
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/luca-moser/iota"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/blake2b"
 )
 
 func TestMessage_Deserialize(t *testing.T) {
@@ -20,6 +21,10 @@ func TestMessage_Deserialize(t *testing.T) {
 			msgPayload, msgPayloadData := randMessage(iota.SignedTransactionPayloadID)
 			return test{"ok", msgPayloadData, msgPayload, nil}
 		}(),
+		func() test {
+			msgPayload, msgPayloadData := randMessage(iota.MilestonePayloadID)
+			return test{"ok milestone", msgPayloadData, msgPayload, nil}
+		}(),
 	}
 
 	for _, tt := range tests {
@@ -57,3 +62,33 @@ func TestMessage_Serialize(t *testing.T) {
 		})
 	}
 }
+
+func TestMessage_ID(t *testing.T) {
+	msg, msgData := randMessage(iota.IndexationPayloadID)
+	id, err := msg.ID()
+	assert.NoError(t, err)
+	assert.Equal(t, iota.MessageID(blake2b.Sum256(msgData)), id)
+}
+
+func TestMessage_IndexationPayload(t *testing.T) {
+	indexation := &iota.IndexationPayload{Index: "index", Data: []byte{1}}
+
+	msg := &iota.Message{Payload: indexation}
+	payload, ok := msg.IndexationPayload()
+	assert.True(t, ok)
+	assert.Equal(t, indexation, payload)
+
+	// an indexation payload within a transaction
+	msg = &iota.Message{Payload: &iota.SignedTransactionPayload{Transaction: &iota.UnsignedTransaction{Payload: indexation}}}
+	payload, ok = msg.IndexationPayload()
+	assert.True(t, ok)
+	assert.Equal(t, indexation, payload)
+
+	msg = &iota.Message{Payload: &iota.SignedTransactionPayload{Transaction: &iota.UnsignedTransaction{}}}
+	_, ok = msg.IndexationPayload()
+	assert.False(t, ok)
+
+	msg = &iota.Message{Payload: &iota.MilestonePayload{}}
+	_, ok = msg.IndexationPayload()
+	assert.False(t, ok)
+}
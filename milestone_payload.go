@@ -2,6 +2,8 @@ package iota
 
 import (
 	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 )
 
@@ -65,3 +67,41 @@ func (m *MilestonePayload) Serialize(deSeriMode DeSerializationMode) ([]byte, er
 	}
 	return b[:], nil
 }
+
+// jsonMilestonePayload defines the JSON form of a MilestonePayload.
+type jsonMilestonePayload struct {
+	Type                 int    `json:"type"`
+	Index                uint64 `json:"index"`
+	Timestamp            uint64 `json:"timestamp"`
+	InclusionMerkleProof string `json:"inclusion_merkle_proof"`
+	Signature            string `json:"signature"`
+}
+
+func (m *MilestonePayload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonMilestonePayload{
+		Type:                 int(MilestonePayloadID),
+		Index:                m.Index,
+		Timestamp:            m.Timestamp,
+		InclusionMerkleProof: hex.EncodeToString(m.InclusionMerkleProof[:]),
+		Signature:            hex.EncodeToString(m.Signature[:]),
+	})
+}
+
+func (m *MilestonePayload) UnmarshalJSON(data []byte) error {
+	jPayload := &jsonMilestonePayload{}
+	if err := json.Unmarshal(data, jPayload); err != nil {
+		return err
+	}
+	if err := checkJSONType(jPayload.Type, MilestonePayloadID); err != nil {
+		return fmt.Errorf("unable to decode milestone payload from JSON: %w", err)
+	}
+	if err := decodeHexJSON("inclusion_merkle_proof", jPayload.InclusionMerkleProof, m.InclusionMerkleProof[:]); err != nil {
+		return err
+	}
+	if err := decodeHexJSON("signature", jPayload.Signature, m.Signature[:]); err != nil {
+		return err
+	}
+	m.Index = jPayload.Index
+	m.Timestamp = jPayload.Timestamp
+	return nil
+}
@@ -0,0 +1,42 @@
+package nodeapi
+
+import (
+	"sync"
+
+	"github.com/luca-moser/iota"
+)
+
+// IndexationIndex is an in-memory IndexLookup. It is safe for concurrent use.
+type IndexationIndex struct {
+	mu      sync.RWMutex
+	byIndex map[string][]iota.MessageID
+}
+
+// NewIndexationIndex creates a new empty IndexationIndex.
+func NewIndexationIndex() *IndexationIndex {
+	return &IndexationIndex{byIndex: make(map[string][]iota.MessageID)}
+}
+
+// Add indexes the given message if it carries an IndexationPayload, see iota.Message.IndexationPayload.
+// Its signature matches tangle.AttachedCallbackFunc, so it can be registered via Tangle.OnAttached.
+func (i *IndexationIndex) Add(id iota.MessageID, msg *iota.Message) {
+	payload, ok := msg.IndexationPayload()
+	if !ok {
+		return
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.byIndex[payload.Index] = append(i.byIndex[payload.Index], id)
+}
+
+// MessageIDsByIndex returns the IDs of up to maxResults messages carrying an IndexationPayload with the given index
+// in the order they were added.
+func (i *IndexationIndex) MessageIDsByIndex(index string, maxResults int) []iota.MessageID {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	ids := i.byIndex[index]
+	if len(ids) > maxResults {
+		ids = ids[:maxResults]
+	}
+	return append([]iota.MessageID(nil), ids...)
+}
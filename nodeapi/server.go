@@ -0,0 +1,427 @@
+// Package nodeapi implements the server side of the HTTP REST API of a node, which iota.NodeAPIClient talks to.
+// The server is backed by pluggable stores, so it can expose a full node as well as an in-process node in tests.
+package nodeapi
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/luca-moser/iota"
+)
+
+const (
+	// The default maximum size of a submitted message.
+	DefaultMaxMessageSize = 32768
+	// The default maximum amount of results of routes returning lists.
+	DefaultMaxResults = 1000
+	// The default time the server spends on the PoW of a submitted message.
+	DefaultPoWTimeout = 30 * time.Second
+	// The default maximum amount of submitted messages the server does the PoW of at the same time.
+	DefaultMaxConcurrentPoW = 1
+
+	// The error code of requests with invalid parameters or bodies.
+	ErrCodeInvalidData = "invalid_data"
+	// The error code of requests for unknown objects.
+	ErrCodeNotFound = "not_found"
+	// The error code of requests the server doesn't support.
+	ErrCodeNotImplemented = "not_implemented"
+	// The error code of requests which failed within the server.
+	ErrCodeInternal = "internal_error"
+	// The error code of submitted messages whose PoW the server couldn't do in time.
+	ErrCodePoWFailed = "pow_failed"
+	// The error code of submitted messages whose PoW the server didn't start as it is busy with other ones.
+	ErrCodePoWBusy = "pow_busy"
+)
+
+// MessageStore stores the messages the server attaches and serves. *tangle.Tangle implements it.
+type MessageStore interface {
+	// Attach adds the given message and returns its ID and whether it was newly added.
+	Attach(msg *iota.Message) (iota.MessageID, bool, error)
+	// Message returns the message with the given ID.
+	Message(id iota.MessageID) (*iota.Message, bool)
+	// Metadata returns the metadata of the message with the given ID.
+	Metadata(id iota.MessageID) (*iota.MessageMetadata, bool)
+}
+
+// Ledger provides the outputs the server serves. *ledger.Ledger implements it.
+type Ledger interface {
+	// LookupUTXO returns the output referenced by the given input and whether it is spent, nil if it is unknown.
+	LookupUTXO(input *iota.UTXOInput) (*iota.UnspentOutput, bool, error)
+	// OutputsByAddress returns the unspent outputs depositing onto the given address.
+	OutputsByAddress(addr iota.Serializable) ([]*iota.UnspentOutput, error)
+	// MilestoneIndex returns the index of the milestone the ledger reflects.
+	MilestoneIndex() uint64
+}
+
+// IndexLookup finds messages by the index of their IndexationPayload. *IndexationIndex implements it.
+type IndexLookup interface {
+	// MessageIDsByIndex returns the IDs of up to maxResults messages carrying an IndexationPayload with the given index.
+	MessageIDsByIndex(index string, maxResults int) []iota.MessageID
+}
+
+// Config configures a Server.
+type Config struct {
+	// The name of the node software reported by the info route.
+	Name string
+	// The version of the node software reported by the info route.
+	Version string
+	// The ID of the network the node participates in.
+	NetworkID uint64
+	// The amount of leading zero bits the IDs of submitted messages must have, see iota.CheckPoW.
+	MinPoWLeadingZeroBits int
+	// Whether the server does the PoW of submitted messages whose nonce is zero.
+	PoWEnabled bool
+	// The amount of workers doing the PoW, see iota.DoPoW.
+	PoWWorkers int
+	// The time the server spends on the PoW of a submitted message, DefaultPoWTimeout if zero.
+	PoWTimeout time.Duration
+	// The maximum amount of submitted messages the server does the PoW of at the same time,
+	// DefaultMaxConcurrentPoW if zero. Further messages without PoW are rejected with http.StatusServiceUnavailable.
+	MaxConcurrentPoW int
+	// The maximum size of a submitted message, DefaultMaxMessageSize if zero.
+	MaxMessageSize int64
+	// The maximum amount of results of routes returning lists, DefaultMaxResults if zero.
+	MaxResults int
+	// The handler serving the event stream route, the route is not served if nil. See events.StreamHandler.
+	EventStream http.Handler
+}
+
+// Server serves the HTTP REST API of a node. It implements http.Handler.
+type Server struct {
+	store  MessageStore
+	ledger Ledger
+	index  IndexLookup
+	cfg    Config
+	mux    *http.ServeMux
+	// holds a token for every PoW in progress
+	powSlots chan struct{}
+}
+
+// New creates a new Server backed by the given stores.
+func New(store MessageStore, ledger Ledger, index IndexLookup, cfg Config) *Server {
+	if cfg.PoWTimeout == 0 {
+		cfg.PoWTimeout = DefaultPoWTimeout
+	}
+	if cfg.MaxConcurrentPoW == 0 {
+		cfg.MaxConcurrentPoW = DefaultMaxConcurrentPoW
+	}
+	if cfg.MaxMessageSize == 0 {
+		cfg.MaxMessageSize = DefaultMaxMessageSize
+	}
+	if cfg.MaxResults == 0 {
+		cfg.MaxResults = DefaultMaxResults
+	}
+	s := &Server{
+		store: store, ledger: ledger, index: index, cfg: cfg, mux: http.NewServeMux(),
+		powSlots: make(chan struct{}, cfg.MaxConcurrentPoW),
+	}
+	s.mux.HandleFunc(iota.NodeAPIRouteInfo, s.handleInfo)
+	s.mux.HandleFunc(iota.NodeAPIRouteMessages, s.handleMessages)
+	s.mux.HandleFunc(iota.NodeAPIRouteMessages+"/", s.handleMessage)
+	s.mux.HandleFunc(iota.NodeAPIRouteOutputs+"/", s.handleOutput)
+	s.mux.HandleFunc(iota.NodeAPIRouteAddresses+"/", s.handleAddress)
+	if cfg.EventStream != nil {
+		s.mux.Handle(iota.NodeAPIRouteEvents, cfg.EventStream)
+	}
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotImplemented, ErrCodeNotImplemented, fmt.Sprintf("route %s is not implemented", r.URL.Path))
+	})
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	msIndex := s.ledger.MilestoneIndex()
+	writeOk(w, &iota.NodeInfoResponse{
+		Name:                 s.cfg.Name,
+		Version:              s.cfg.Version,
+		IsHealthy:            true,
+		NetworkID:            s.cfg.NetworkID,
+		LatestMilestoneIndex: msIndex,
+		SolidMilestoneIndex:  msIndex,
+	})
+}
+
+func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.submitMessage(w, r)
+	case http.MethodGet:
+		s.messageIDsByIndex(w, r)
+	default:
+		allowMethods(w, r, http.MethodGet, http.MethodPost)
+	}
+}
+
+// submitMessage parses, validates and attaches a message, doing its PoW if its nonce is zero and the server does PoW.
+// Messages needing PoW while the server already does Config.MaxConcurrentPoW ones are rejected.
+func (s *Server) submitMessage(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, s.cfg.MaxMessageSize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidData, fmt.Sprintf("unable to read message: %s", err))
+		return
+	}
+
+	msg, err := parseMessage(r.Header.Get("Content-Type"), body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidData, err.Error())
+		return
+	}
+
+	if err := iota.CheckPoW(msg, s.cfg.MinPoWLeadingZeroBits); err != nil {
+		if msg.Nonce != 0 || !s.cfg.PoWEnabled {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidData, err.Error())
+			return
+		}
+		select {
+		case s.powSlots <- struct{}{}:
+			defer func() { <-s.powSlots }()
+		default:
+			writeError(w, http.StatusServiceUnavailable, ErrCodePoWBusy, "the node is busy doing the PoW of other messages")
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), s.cfg.PoWTimeout)
+		defer cancel()
+		if err := iota.DoPoW(ctx, msg, s.cfg.MinPoWLeadingZeroBits, s.cfg.PoWWorkers); err != nil {
+			writeError(w, http.StatusServiceUnavailable, ErrCodePoWFailed, fmt.Sprintf("unable to do PoW: %s", err))
+			return
+		}
+	}
+
+	id, _, err := s.store.Attach(msg)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidData, fmt.Sprintf("unable to attach message: %s", err))
+		return
+	}
+	writeOk(w, &iota.MessageIDResponse{MessageID: hex.EncodeToString(id[:])})
+}
+
+// parseMessage parses a message from its JSON or binary form and validates it by serializing
+// and deserializing it with iota.DeSeriModePerformValidation.
+func parseMessage(contentType string, body []byte) (*iota.Message, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid content type %q: %w", contentType, err)
+	}
+
+	msg := &iota.Message{}
+	switch mediaType {
+	case iota.MIMEApplicationJSON:
+		if err := json.Unmarshal(body, msg); err != nil {
+			return nil, fmt.Errorf("invalid message: %w", err)
+		}
+		// the JSON form doesn't pass the validation the binary form does
+		if body, err = msg.Serialize(iota.DeSeriModePerformValidation); err != nil {
+			return nil, fmt.Errorf("invalid message: %w", err)
+		}
+		msg = &iota.Message{}
+		fallthrough
+	case iota.MIMEApplicationOctetStream:
+		bytesRead, err := msg.Deserialize(body, iota.DeSeriModePerformValidation)
+		if err != nil {
+			return nil, fmt.Errorf("invalid message: %w", err)
+		}
+		if bytesRead != len(body) {
+			return nil, fmt.Errorf("invalid message: %w: %d bytes are left", iota.ErrDeserializationNotAllConsumed, len(body)-bytesRead)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported content type %q", mediaType)
+	}
+	return msg, nil
+}
+
+func (s *Server) messageIDsByIndex(w http.ResponseWriter, r *http.Request) {
+	index := r.URL.Query().Get(iota.NodeAPIQueryParameterIndex)
+	if index == "" {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidData, fmt.Sprintf("query parameter %q is missing", iota.NodeAPIQueryParameterIndex))
+		return
+	}
+	ids := s.index.MessageIDsByIndex(index, s.cfg.MaxResults)
+	res := &iota.MessageIDsByIndexResponse{Index: index, MaxResults: s.cfg.MaxResults, Count: len(ids), MessageIDs: make([]string, len(ids))}
+	for i, id := range ids {
+		res.MessageIDs[i] = hex.EncodeToString(id[:])
+	}
+	writeOk(w, res)
+}
+
+func (s *Server) handleMessage(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, iota.NodeAPIRouteMessages+"/")
+	idHex, suffix := path, ""
+	if i := strings.IndexByte(path, '/'); i != -1 {
+		idHex, suffix = path[:i], path[i:]
+	}
+	id, err := iota.MessageIDFromHex(idHex)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidData, err.Error())
+		return
+	}
+	msg, has := s.store.Message(id)
+	if !has {
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, fmt.Sprintf("message %s not found", idHex))
+		return
+	}
+
+	switch suffix {
+	case "":
+		writeOk(w, msg)
+	case iota.NodeAPIRouteMessageRawSuffix:
+		data, err := msg.Serialize(iota.DeSeriModeNoValidation)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", iota.MIMEApplicationOctetStream)
+		_, _ = w.Write(data)
+	case iota.NodeAPIRouteMessageMetadataSuffix:
+		res := &iota.MessageMetadataResponse{
+			MessageID: idHex,
+			Parent1:   hex.EncodeToString(msg.Parent1[:]),
+			Parent2:   hex.EncodeToString(msg.Parent2[:]),
+		}
+		if meta, has := s.store.Metadata(id); has {
+			res.Solid = meta.IsSolid()
+			if msIndex, referenced := meta.ReferencedBy(); referenced {
+				res.ReferencedByMilestoneIndex = &msIndex
+				res.LedgerInclusionState = meta.LedgerInclusionState().String()
+				res.ConflictReason = meta.ConflictReason()
+			}
+		}
+		writeOk(w, res)
+	default:
+		writeError(w, http.StatusNotImplemented, ErrCodeNotImplemented, fmt.Sprintf("route %s is not implemented", r.URL.Path))
+	}
+}
+
+func (s *Server) handleOutput(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	outputID := strings.TrimPrefix(r.URL.Path, iota.NodeAPIRouteOutputs+"/")
+	input, err := iota.UTXOInputFromOutputIDHex(outputID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidData, err.Error())
+		return
+	}
+	output, spent, err := s.ledger.LookupUTXO(input)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	if output == nil {
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, fmt.Sprintf("output %s not found", outputID))
+		return
+	}
+	outputJSON, err := json.Marshal(&iota.SigLockedSingleDeposit{Address: output.Address, Amount: output.Amount})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	writeOk(w, &iota.OutputResponse{
+		TransactionID: hex.EncodeToString(input.TransactionID[:]),
+		OutputIndex:   input.TransactionOutputIndex,
+		Spent:         spent,
+		RawOutput:     outputJSON,
+	})
+}
+
+func (s *Server) handleAddress(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, iota.NodeAPIRouteAddresses+"/")
+	addrHex := strings.TrimSuffix(path, iota.NodeAPIRouteAddressOutputsSuffix)
+	addr, err := parseAddress(addrHex)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidData, err.Error())
+		return
+	}
+	outputs, err := s.ledger.OutputsByAddress(addr)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	if path != addrHex {
+		if len(outputs) > s.cfg.MaxResults {
+			outputs = outputs[:s.cfg.MaxResults]
+		}
+		res := &iota.AddressOutputsResponse{Address: addrHex, MaxResults: s.cfg.MaxResults, Count: len(outputs), OutputIDs: make([]string, len(outputs))}
+		for i, output := range outputs {
+			res.OutputIDs[i] = output.Input.OutputIDHex()
+		}
+		writeOk(w, res)
+		return
+	}
+
+	// the balance covers every output, only lists of results are limited
+	res := &iota.AddressBalanceResponse{Address: addrHex, MaxResults: s.cfg.MaxResults, Count: len(outputs)}
+	for _, output := range outputs {
+		res.Balance += output.Amount
+	}
+	writeOk(w, res)
+}
+
+// parseAddress parses an address from its hex encoded serialized form.
+func parseAddress(addrHex string) (iota.Serializable, error) {
+	addrData, err := hex.DecodeString(addrHex)
+	if err != nil {
+		return nil, fmt.Errorf("%w: address is not valid hex: %v", iota.ErrInvalidBytes, err)
+	}
+	addr, bytesRead, err := iota.DeserializeObject(addrData, iota.DeSeriModePerformValidation, iota.TypeDenotationByte, iota.AddressSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address: %w", err)
+	}
+	if bytesRead != len(addrData) {
+		return nil, fmt.Errorf("invalid address: %w", iota.ErrDeserializationNotAllConsumed)
+	}
+	return addr, nil
+}
+
+// allowMethods responds with http.StatusMethodNotAllowed and returns false if the request's method isn't among the given ones.
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if r.Method == method {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, ErrCodeInvalidData, fmt.Sprintf("method %s is not allowed", r.Method))
+	return false
+}
+
+func writeOk(w http.ResponseWriter, data interface{}) {
+	writeJSON(w, http.StatusOK, &iota.HTTPOkResponseEnvelope{Data: data})
+}
+
+func writeError(w http.ResponseWriter, status int, code string, msg string) {
+	res := &iota.HTTPErrorResponseEnvelope{}
+	res.Error.Code = code
+	res.Error.Message = msg
+	writeJSON(w, status, res)
+}
+
+func writeJSON(w http.ResponseWriter, status int, res interface{}) {
+	data, err := json.Marshal(res)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", iota.MIMEApplicationJSON)
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,332 @@
+package nodeapi_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/luca-moser/iota"
+	"github.com/luca-moser/iota/events"
+	"github.com/luca-moser/iota/ledger"
+	"github.com/luca-moser/iota/nodeapi"
+	"github.com/luca-moser/iota/tangle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPoWLeadingZeroBits = 8
+
+var genesis = iota.MessageID{}
+
+// node is an in-process node exposing its tangle and ledger via a nodeapi.Server.
+type node struct {
+	url    string
+	tangle *tangle.Tangle
+	ledger *ledger.Ledger
+	client *iota.NodeAPIClient
+}
+
+func newNode(t *testing.T, powEnabled bool) *node {
+	n := &node{tangle: tangle.New(genesis), ledger: ledger.New(1)}
+	index := nodeapi.NewIndexationIndex()
+	n.tangle.OnAttached(index.Add)
+
+	srv := httptest.NewServer(nodeapi.New(n.tangle, n.ledger, index, nodeapi.Config{
+		Name:                  "test",
+		Version:               "0.1.0",
+		NetworkID:             1337,
+		MinPoWLeadingZeroBits: testPoWLeadingZeroBits,
+		PoWEnabled:            powEnabled,
+		PoWWorkers:            2,
+		MaxResults:            2,
+	}))
+	t.Cleanup(srv.Close)
+	n.url = srv.URL
+	n.client = iota.NewNodeAPIClient(srv.URL, srv.Client())
+	return n
+}
+
+func indexationMessage(index string, nonce uint64) *iota.Message {
+	return &iota.Message{
+		Parent1: genesis,
+		Parent2: genesis,
+		Payload: &iota.IndexationPayload{Index: index, Data: []byte(index)},
+		Nonce:   nonce,
+	}
+}
+
+func TestServer_Info(t *testing.T) {
+	n := newNode(t, false)
+	info, err := n.client.Info(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &iota.NodeInfoResponse{
+		Name: "test", Version: "0.1.0", IsHealthy: true, NetworkID: 1337, LatestMilestoneIndex: 1, SolidMilestoneIndex: 1,
+	}, info)
+}
+
+func TestServer_SubmitMessage(t *testing.T) {
+	n := newNode(t, true)
+	ctx := context.Background()
+
+	// the server does the PoW of messages with a zero nonce
+	jsonID, err := n.client.SubmitMessage(ctx, indexationMessage("a", 0))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, iota.PoWLeadingZeroBits(jsonID), testPoWLeadingZeroBits)
+	binaryID, err := n.client.SubmitMessageBinary(ctx, indexationMessage("b", 0))
+	require.NoError(t, err)
+	assert.True(t, n.tangle.Contains(jsonID))
+	assert.True(t, n.tangle.Contains(binaryID))
+
+	msg, err := n.client.MessageByMessageID(ctx, jsonID)
+	require.NoError(t, err)
+	assert.NoError(t, iota.CheckPoW(msg, testPoWLeadingZeroBits))
+	assert.Equal(t, "a", msg.Payload.(*iota.IndexationPayload).Index)
+
+	data, err := n.client.MessageBytesByMessageID(ctx, binaryID)
+	require.NoError(t, err)
+	stored, _ := n.tangle.Message(binaryID)
+	storedData, err := stored.Serialize(iota.DeSeriModeNoValidation)
+	require.NoError(t, err)
+	assert.Equal(t, storedData, data)
+
+	require.NoError(t, n.tangle.MarkReferenced(jsonID, 2, iota.LedgerInclusionNoTransaction, iota.ConflictNone))
+	metadata, err := n.client.MessageMetadataByMessageID(ctx, jsonID)
+	require.NoError(t, err)
+	assert.True(t, metadata.Solid)
+	require.NotNil(t, metadata.ReferencedByMilestoneIndex)
+	assert.EqualValues(t, 2, *metadata.ReferencedByMilestoneIndex)
+	assert.Equal(t, iota.LedgerInclusionNoTransaction.String(), metadata.LedgerInclusionState)
+
+	metadata, err = n.client.MessageMetadataByMessageID(ctx, binaryID)
+	require.NoError(t, err)
+	assert.Nil(t, metadata.ReferencedByMilestoneIndex)
+	assert.Empty(t, metadata.LedgerInclusionState)
+}
+
+func TestServer_SubmitMessageInvalid(t *testing.T) {
+	n := newNode(t, false)
+	ctx := context.Background()
+
+	// without PoW on the server, messages must come with it
+	_, err := n.client.SubmitMessage(ctx, indexationMessage("a", 0))
+	assert.True(t, errors.Is(err, iota.ErrHTTPBadRequest))
+
+	msg := indexationMessage("a", 0)
+	require.NoError(t, iota.DoPoW(ctx, msg, testPoWLeadingZeroBits, 2))
+	id, err := n.client.SubmitMessage(ctx, msg)
+	require.NoError(t, err)
+	expectedID, err := msg.ID()
+	require.NoError(t, err)
+	assert.Equal(t, expectedID, id)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+	}{
+		{"garbage", iota.MIMEApplicationOctetStream, []byte{1, 2, 3}},
+		{"invalid JSON", iota.MIMEApplicationJSON, []byte(`{"version": 1`)},
+		// an empty index passes the JSON form but not the validation of the binary form
+		{"empty index", iota.MIMEApplicationJSON, func() []byte {
+			data, err := json.Marshal(indexationMessage("", 1))
+			require.NoError(t, err)
+			return data
+		}()},
+		{"content type", "text/plain", []byte("message")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := http.Post(n.url+iota.NodeAPIRouteMessages, tt.contentType, bytes.NewReader(tt.body))
+			require.NoError(t, err)
+			defer res.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+			errRes := &iota.HTTPErrorResponseEnvelope{}
+			require.NoError(t, json.NewDecoder(res.Body).Decode(errRes))
+			assert.Equal(t, nodeapi.ErrCodeInvalidData, errRes.Error.Code)
+		})
+	}
+}
+
+func TestServer_SubmitMessagePoWBusy(t *testing.T) {
+	// the PoW of a message with a zero nonce never finishes in time
+	srv := httptest.NewServer(nodeapi.New(tangle.New(genesis), ledger.New(1), nodeapi.NewIndexationIndex(), nodeapi.Config{
+		MinPoWLeadingZeroBits: 200,
+		PoWEnabled:            true,
+		PoWWorkers:            1,
+		PoWTimeout:            time.Minute,
+		MaxConcurrentPoW:      1,
+	}))
+	t.Cleanup(srv.Close)
+	client := iota.NewNodeAPIClient(srv.URL, srv.Client())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	busyErr := make(chan error, 1)
+	go func() {
+		_, err := client.SubmitMessage(ctx, indexationMessage("a", 0))
+		busyErr <- err
+	}()
+
+	// until the first message holds the only PoW slot, further ones may take it for their own short lifetime
+	require.Eventually(t, func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+		_, err := client.SubmitMessage(ctx, indexationMessage("b", 0))
+		return errors.Is(err, iota.ErrHTTPServiceUnavailable)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.Error(t, <-busyErr)
+
+	// the canceled request released its slot, so the PoW of further messages starts again
+	require.Eventually(t, func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+		_, err := client.SubmitMessage(ctx, indexationMessage("c", 0))
+		return errors.Is(err, context.DeadlineExceeded)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestServer_MessageIDsByIndex(t *testing.T) {
+	n := newNode(t, true)
+	ctx := context.Background()
+
+	var expected []iota.MessageID
+	for i := 0; i < 3; i++ {
+		msg := indexationMessage("index", 0)
+		msg.Payload.(*iota.IndexationPayload).Data = []byte{byte(i)}
+		id, err := n.client.SubmitMessage(ctx, msg)
+		require.NoError(t, err)
+		expected = append(expected, id)
+	}
+	_, err := n.client.SubmitMessage(ctx, indexationMessage("other", 0))
+	require.NoError(t, err)
+
+	// limited by the max results
+	ids, err := n.client.MessageIDsByIndex(ctx, "index")
+	require.NoError(t, err)
+	assert.Equal(t, expected[:2], ids)
+
+	ids, err = n.client.MessageIDsByIndex(ctx, "unknown")
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestIndexationIndex(t *testing.T) {
+	index := nodeapi.NewIndexationIndex()
+	index.Add(iota.MessageID{1}, indexationMessage("index", 1))
+	// an indexation payload within a transaction
+	index.Add(iota.MessageID{2}, &iota.Message{Payload: &iota.SignedTransactionPayload{
+		Transaction: &iota.UnsignedTransaction{Payload: &iota.IndexationPayload{Index: "index"}},
+	}})
+	index.Add(iota.MessageID{3}, &iota.Message{Payload: &iota.SignedTransactionPayload{Transaction: &iota.UnsignedTransaction{}}})
+	index.Add(iota.MessageID{4}, indexationMessage("other", 1))
+
+	assert.Equal(t, []iota.MessageID{{1}, {2}}, index.MessageIDsByIndex("index", 10))
+	assert.Equal(t, []iota.MessageID{{1}}, index.MessageIDsByIndex("index", 1))
+	assert.Empty(t, index.MessageIDsByIndex("unknown", 10))
+}
+
+func TestServer_Outputs(t *testing.T) {
+	n := newNode(t, false)
+	ctx := context.Background()
+
+	addr := &iota.Ed25519Address{1}
+	otherAddr := &iota.Ed25519Address{2}
+	outputs := []*iota.UnspentOutput{
+		{Input: &iota.UTXOInput{TransactionID: [iota.TransactionIDLength]byte{1}, TransactionOutputIndex: 0}, Address: addr, Amount: 100},
+		{Input: &iota.UTXOInput{TransactionID: [iota.TransactionIDLength]byte{1}, TransactionOutputIndex: 1}, Address: addr, Amount: 50},
+		{Input: &iota.UTXOInput{TransactionID: [iota.TransactionIDLength]byte{2}, TransactionOutputIndex: 0}, Address: otherAddr, Amount: 1},
+		{Input: &iota.UTXOInput{TransactionID: [iota.TransactionIDLength]byte{3}, TransactionOutputIndex: 0}, Address: addr, Amount: 25},
+	}
+	require.NoError(t, n.ledger.Add(outputs...))
+
+	outputRes, err := n.client.OutputByUTXOInput(ctx, outputs[1].Input)
+	require.NoError(t, err)
+	assert.False(t, outputRes.Spent)
+	assert.EqualValues(t, 1, outputRes.OutputIndex)
+	output, err := outputRes.Output()
+	require.NoError(t, err)
+	assert.Equal(t, &iota.SigLockedSingleDeposit{Address: addr, Amount: 50}, output)
+
+	_, err = n.client.OutputByUTXOInput(ctx, &iota.UTXOInput{TransactionID: [iota.TransactionIDLength]byte{4}})
+	assert.True(t, errors.Is(err, iota.ErrHTTPNotFound))
+
+	balance, err := n.client.BalanceByAddress(ctx, addr)
+	require.NoError(t, err)
+	// the balance covers more outputs than the max results, unlike the list of their IDs
+	assert.EqualValues(t, 175, balance.Balance)
+	assert.Equal(t, 3, balance.Count)
+
+	inputs, err := n.client.OutputIDsByAddress(ctx, addr)
+	require.NoError(t, err)
+	assert.Len(t, inputs, 2)
+	assert.Subset(t, []*iota.UTXOInput{outputs[0].Input, outputs[1].Input, outputs[3].Input}, inputs)
+
+	inputs, err = n.client.OutputIDsByAddress(ctx, &iota.Ed25519Address{3})
+	require.NoError(t, err)
+	assert.Empty(t, inputs)
+}
+
+func TestServer_Errors(t *testing.T) {
+	n := newNode(t, false)
+	ctx := context.Background()
+
+	_, err := n.client.MessageByMessageID(ctx, iota.MessageID{1})
+	assert.True(t, errors.Is(err, iota.ErrHTTPNotFound))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+	}{
+		{"unknown route", http.MethodGet, "/api/v1/unknown", http.StatusNotImplemented},
+		{"invalid message ID", http.MethodGet, iota.NodeAPIRouteMessages + "/abc", http.StatusBadRequest},
+		{"invalid output ID", http.MethodGet, iota.NodeAPIRouteOutputs + "/abc", http.StatusBadRequest},
+		{"invalid address", http.MethodGet, iota.NodeAPIRouteAddresses + "/07aa", http.StatusBadRequest},
+		{"missing index", http.MethodGet, iota.NodeAPIRouteMessages, http.StatusBadRequest},
+		{"method", http.MethodDelete, iota.NodeAPIRouteInfo, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, n.url+tt.path, nil)
+			require.NoError(t, err)
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			assert.Equal(t, tt.status, res.StatusCode)
+			errRes := &iota.HTTPErrorResponseEnvelope{}
+			require.NoError(t, json.NewDecoder(res.Body).Decode(errRes))
+			assert.NotEmpty(t, errRes.Error.Message)
+		})
+	}
+}
+
+func TestServer_EventStream(t *testing.T) {
+	tngl := tangle.New(genesis)
+	bus := events.NewBus()
+	defer bus.Close()
+	events.PublishTangle(bus, tngl)
+	srv := httptest.NewServer(nodeapi.New(tngl, ledger.New(1), nodeapi.NewIndexationIndex(), nodeapi.Config{
+		EventStream: events.NewStreamHandler(bus, 0),
+	}))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + iota.NodeAPIRouteEvents + "?" + events.StreamQueryParameterTopic + "=" + string(events.TopicMessageSolid))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	id, _, err := tngl.Attach(indexationMessage("a", 1))
+	require.NoError(t, err)
+	event, err := bufio.NewReader(res.Body).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: "+string(events.TopicMessageSolid)+"\n", event)
+	assert.True(t, tngl.IsSolid(id))
+}
@@ -2,6 +2,7 @@ package iota
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -107,6 +108,42 @@ func (s *SigLockedSingleDeposit) Serialize(deSeriMode DeSerializationMode) (data
 	return b, nil
 }
 
+// jsonSigLockedSingleDeposit defines the JSON form of a SigLockedSingleDeposit.
+type jsonSigLockedSingleDeposit struct {
+	Type    int             `json:"type"`
+	Address json.RawMessage `json:"address"`
+	Amount  uint64          `json:"amount"`
+}
+
+func (s *SigLockedSingleDeposit) MarshalJSON() ([]byte, error) {
+	addrJSON, err := json.Marshal(s.Address)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&jsonSigLockedSingleDeposit{
+		Type:    int(OutputSigLockedSingleDeposit),
+		Address: addrJSON,
+		Amount:  s.Amount,
+	})
+}
+
+func (s *SigLockedSingleDeposit) UnmarshalJSON(data []byte) error {
+	jOutput := &jsonSigLockedSingleDeposit{}
+	if err := json.Unmarshal(data, jOutput); err != nil {
+		return err
+	}
+	if err := checkJSONType(jOutput.Type, uint32(OutputSigLockedSingleDeposit)); err != nil {
+		return fmt.Errorf("unable to decode signature locked single deposit from JSON: %w", err)
+	}
+	addr, err := DeserializeObjectFromJSON(jOutput.Address, AddressSelector)
+	if err != nil {
+		return fmt.Errorf("unable to decode address: %w", err)
+	}
+	s.Address = addr
+	s.Amount = jOutput.Amount
+	return nil
+}
+
 // OutputsValidatorFunc which given the index of an output and the output itself, runs validations and returns an error if any should fail.
 type OutputsValidatorFunc func(index int, output *SigLockedSingleDeposit) error
 
@@ -0,0 +1,594 @@
+package iota
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	// The magic bytes a serialized PartiallySignedTransaction starts with.
+	PartiallySignedTransactionMagic = "ipst"
+	// The version of the binary and JSON form of a PartiallySignedTransaction.
+	PartiallySignedTransactionVersion byte = 1
+
+	// The minimum size of a serialized PartiallySignedTransaction.
+	PartiallySignedTransactionMinSize = len(PartiallySignedTransactionMagic) + OneByte + UnsignedTransactionMinByteSize + StructArrayLengthByteSize
+	// The minimum size of a serialized PartiallySignedInput.
+	PartiallySignedInputMinSize = UInt64ByteSize + SmallTypeDenotationByteSize + UInt16ByteSize + OneByte
+)
+
+var (
+	ErrPSTInvalidMagic        = errors.New("data is not a partially signed transaction")
+	ErrPSTUnsupportedVersion  = errors.New("unsupported partially signed transaction version")
+	ErrPSTInputCountMismatch  = errors.New("the inputs of a partially signed transaction must match the inputs of its transaction")
+	ErrPSTAmountMismatch      = errors.New("the input amounts of a partially signed transaction must equal the sum of its outputs")
+	ErrPSTTransactionMismatch = errors.New("partially signed transactions hold different transactions")
+	ErrPSTInputMismatch       = errors.New("partially signed transactions hold different input metadata")
+	ErrPSTSignerMismatch      = errors.New("the signature's public key doesn't belong to the input's address")
+	ErrPSTInvalidSignature    = errors.New("the signature is invalid for the transaction")
+	ErrPSTMissingSignature    = errors.New("input has no signature unlocking it")
+	ErrPSTInputIndexInvalid   = errors.New("input index is out of range")
+)
+
+// PartiallySignedInput holds the metadata needed to sign one input of a PartiallySignedTransaction
+// and the signature collected for it so far.
+type PartiallySignedInput struct {
+	// The amount held by the output the input references.
+	Amount uint64 `json:"amount"`
+	// The address owning the output the input references.
+	Address Serializable `json:"address"`
+	// The derivation path of the key owning the address in the form parsed by ParseDerivationPath, empty if unknown.
+	DerivationPath string `json:"derivation_path"`
+	// The signature unlocking the input, nil if the input hasn't been signed yet.
+	Signature *Ed25519Signature `json:"signature"`
+}
+
+func (p *PartiallySignedInput) Deserialize(data []byte, deSeriMode DeSerializationMode) (int, error) {
+	if err := checkMinByteLength(PartiallySignedInputMinSize, len(data)); err != nil {
+		return 0, fmt.Errorf("invalid partially signed input bytes: %w", err)
+	}
+	p.Amount = binary.LittleEndian.Uint64(data)
+	bytesReadTotal := UInt64ByteSize
+	data = data[UInt64ByteSize:]
+
+	addr, addrBytesRead, err := DeserializeObject(data, deSeriMode, TypeDenotationByte, AddressSelector)
+	if err != nil {
+		return 0, err
+	}
+	p.Address = addr
+	bytesReadTotal += addrBytesRead
+	data = data[addrBytesRead:]
+
+	path, pathBytesRead, err := ReadStringFromBytes(data)
+	if err != nil {
+		return 0, fmt.Errorf("unable to deserialize derivation path: %w", err)
+	}
+	p.DerivationPath = path
+	bytesReadTotal += pathBytesRead
+	data = data[pathBytesRead:]
+
+	if err := checkMinByteLength(OneByte, len(data)); err != nil {
+		return 0, fmt.Errorf("unable to deserialize signature flag: %w", err)
+	}
+	hasSig := data[0]
+	bytesReadTotal += OneByte
+	data = data[OneByte:]
+
+	switch hasSig {
+	case 0:
+		p.Signature = nil
+	case 1:
+		if err := checkMinByteLength(Ed25519SignatureSerializedBytesSize, len(data)); err != nil {
+			return 0, fmt.Errorf("invalid signature bytes: %w", err)
+		}
+		p.Signature = &Ed25519Signature{}
+		sigBytesRead, err := p.Signature.Deserialize(data, deSeriMode)
+		if err != nil {
+			return 0, err
+		}
+		bytesReadTotal += sigBytesRead
+	default:
+		return 0, fmt.Errorf("%w: signature flag must be 0 or 1 but is %d", ErrInvalidBytes, hasSig)
+	}
+
+	return bytesReadTotal, nil
+}
+
+func (p *PartiallySignedInput) Serialize(deSeriMode DeSerializationMode) ([]byte, error) {
+	if len(p.DerivationPath) > 1<<16-1 {
+		return nil, fmt.Errorf("%w: derivation path exceeds %d bytes", ErrInvalidBytes, 1<<16-1)
+	}
+
+	var b bytes.Buffer
+	if err := binary.Write(&b, binary.LittleEndian, p.Amount); err != nil {
+		return nil, err
+	}
+	addrData, err := p.Address.Serialize(deSeriMode)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := b.Write(addrData); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(&b, binary.LittleEndian, uint16(len(p.DerivationPath))); err != nil {
+		return nil, err
+	}
+	if _, err := b.Write([]byte(p.DerivationPath)); err != nil {
+		return nil, err
+	}
+
+	if p.Signature == nil {
+		if err := b.WriteByte(0); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+	if err := b.WriteByte(1); err != nil {
+		return nil, err
+	}
+	sigData, err := p.Signature.Serialize(deSeriMode)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := b.Write(sigData); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// jsonPartiallySignedInput defines the JSON form of a PartiallySignedInput.
+type jsonPartiallySignedInput struct {
+	Amount         uint64          `json:"amount"`
+	Address        json.RawMessage `json:"address"`
+	DerivationPath string          `json:"derivation_path,omitempty"`
+	Signature      json.RawMessage `json:"signature,omitempty"`
+}
+
+func (p *PartiallySignedInput) MarshalJSON() ([]byte, error) {
+	addrJSON, err := json.Marshal(p.Address)
+	if err != nil {
+		return nil, err
+	}
+	jInput := &jsonPartiallySignedInput{Amount: p.Amount, Address: addrJSON, DerivationPath: p.DerivationPath}
+	if p.Signature != nil {
+		if jInput.Signature, err = json.Marshal(p.Signature); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(jInput)
+}
+
+func (p *PartiallySignedInput) UnmarshalJSON(data []byte) error {
+	jInput := &jsonPartiallySignedInput{}
+	if err := json.Unmarshal(data, jInput); err != nil {
+		return err
+	}
+	addr, err := DeserializeObjectFromJSON(jInput.Address, AddressSelector)
+	if err != nil {
+		return fmt.Errorf("unable to decode address: %w", err)
+	}
+	var sig *Ed25519Signature
+	if len(jInput.Signature) > 0 && !isJSONNull(jInput.Signature) {
+		sig = &Ed25519Signature{}
+		if err := json.Unmarshal(jInput.Signature, sig); err != nil {
+			return fmt.Errorf("unable to decode signature: %w", err)
+		}
+	}
+	p.Amount = jInput.Amount
+	p.Address = addr
+	p.DerivationPath = jInput.DerivationPath
+	p.Signature = sig
+	return nil
+}
+
+// PartiallySignedTransaction (PST) is a container passing an UnsignedTransaction between the parties signing it,
+// similar to Bitcoin's PSBT. Next to the transaction it holds for every input the metadata needed to sign it
+// and the signature collected for it so far. Once every input is unlocked, it is finalized into a SignedTransactionPayload.
+type PartiallySignedTransaction struct {
+	// The transaction to sign.
+	Transaction *UnsignedTransaction `json:"transaction"`
+	// The metadata and signatures of the transaction's inputs, in the order of the inputs.
+	Inputs []*PartiallySignedInput `json:"inputs"`
+}
+
+// NewPartiallySignedTransaction creates a PartiallySignedTransaction for the given transaction and the metadata of its inputs.
+func NewPartiallySignedTransaction(tx *UnsignedTransaction, inputs []*PartiallySignedInput) (*PartiallySignedTransaction, error) {
+	pst := &PartiallySignedTransaction{Transaction: tx, Inputs: inputs}
+	if err := pst.SyntacticallyValid(); err != nil {
+		return nil, err
+	}
+	return pst, nil
+}
+
+// SyntacticallyValid checks whether the transaction is syntactically valid, every input has its metadata,
+// the input amounts equal the sum of the outputs and every signature's public key belongs to the address of its input.
+// Signatures themselves are verified when they are added.
+func (p *PartiallySignedTransaction) SyntacticallyValid() error {
+	if p.Transaction == nil {
+		return fmt.Errorf("%w: no transaction", ErrPSTInputCountMismatch)
+	}
+	if err := p.Transaction.SyntacticallyValid(); err != nil {
+		return err
+	}
+	if len(p.Inputs) != len(p.Transaction.Inputs) {
+		return fmt.Errorf("%w: %d inputs for %d transaction inputs", ErrPSTInputCountMismatch, len(p.Inputs), len(p.Transaction.Inputs))
+	}
+
+	var inputsSum, outputsSum uint64
+	for i, input := range p.Inputs {
+		if input == nil || input.Address == nil {
+			return fmt.Errorf("%w: input %d has no address", ErrPSTInputCountMismatch, i)
+		}
+		if inputsSum+input.Amount < inputsSum {
+			return fmt.Errorf("%w: input amounts overflow", ErrPSTAmountMismatch)
+		}
+		inputsSum += input.Amount
+		if input.Signature != nil {
+			if err := checkSigner(input.Address, input.Signature); err != nil {
+				return fmt.Errorf("input %d: %w", i, err)
+			}
+		}
+	}
+	for _, output := range p.Transaction.Outputs {
+		if dep, ok := output.(*SigLockedSingleDeposit); ok {
+			outputsSum += dep.Amount
+		}
+	}
+	if inputsSum != outputsSum {
+		return fmt.Errorf("%w: inputs %d, outputs %d", ErrPSTAmountMismatch, inputsSum, outputsSum)
+	}
+	return nil
+}
+
+// checkSigner checks whether the public key of the given signature belongs to the given address.
+func checkSigner(addr Serializable, sig *Ed25519Signature) error {
+	edAddr, ok := addr.(*Ed25519Address)
+	if !ok {
+		return fmt.Errorf("%w: address is %T", ErrPSTSignerMismatch, addr)
+	}
+	if AddressFromEd25519PubKey(sig.PublicKey[:]) != *edAddr {
+		return ErrPSTSignerMismatch
+	}
+	return nil
+}
+
+// SigningMessage returns the data the inputs are signed over, which is the serialized transaction.
+func (p *PartiallySignedTransaction) SigningMessage() ([]byte, error) {
+	return p.Transaction.Serialize(DeSeriModePerformValidation)
+}
+
+// AddSignature verifies the given signature and adds it to the input at the given index.
+// The signature's public key must belong to the input's address.
+func (p *PartiallySignedTransaction) AddSignature(index int, sig *Ed25519Signature) error {
+	if index < 0 || index >= len(p.Inputs) {
+		return fmt.Errorf("%w: %d", ErrPSTInputIndexInvalid, index)
+	}
+	if err := checkSigner(p.Inputs[index].Address, sig); err != nil {
+		return fmt.Errorf("input %d: %w", index, err)
+	}
+	msg, err := p.SigningMessage()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(sig.PublicKey[:], msg, sig.Signature[:]) {
+		return fmt.Errorf("%w: input %d", ErrPSTInvalidSignature, index)
+	}
+	p.Inputs[index].Signature = sig
+	return nil
+}
+
+// Sign signs every input owned by the given private key and returns the amount of inputs it signed.
+func (p *PartiallySignedTransaction) Sign(prvKey ed25519.PrivateKey) (int, error) {
+	keyring, err := NewInMemoryKeyring(prvKey)
+	if err != nil {
+		return 0, err
+	}
+	return p.SignWith(keyring)
+}
+
+// SignWith lets the given signer sign every input whose address it holds the key of
+// and returns the amount of inputs it signed. The returned signatures are verified.
+func (p *PartiallySignedTransaction) SignWith(signer AddressSigner) (int, error) {
+	msg, err := p.SigningMessage()
+	if err != nil {
+		return 0, err
+	}
+
+	// every address is only signed once, inputs of the same address share the signature
+	sigs := map[string]*Ed25519Signature{}
+	var signed int
+	for i, input := range p.Inputs {
+		addrData, err := input.Address.Serialize(DeSeriModeNoValidation)
+		if err != nil {
+			return signed, fmt.Errorf("input %d: %w", i, err)
+		}
+		sig, has := sigs[string(addrData)]
+		if !has {
+			sig, err = signer.Sign(input.Address, msg)
+			switch {
+			case errors.Is(err, ErrAddressSignerUnknownAddress):
+				sigs[string(addrData)] = nil
+				continue
+			case err != nil:
+				return signed, fmt.Errorf("input %d: %w", i, err)
+			}
+			if err := checkSigner(input.Address, sig); err != nil {
+				return signed, fmt.Errorf("input %d: %w", i, err)
+			}
+			if !ed25519.Verify(sig.PublicKey[:], msg, sig.Signature[:]) {
+				return signed, fmt.Errorf("%w: input %d", ErrPSTInvalidSignature, i)
+			}
+			sigs[string(addrData)] = sig
+		}
+		if sig == nil {
+			continue
+		}
+		input.Signature = sig
+		signed++
+	}
+	return signed, nil
+}
+
+// Merge adds the signatures of the given copy of the same PartiallySignedTransaction, which are verified first.
+// Derivation paths missing in this copy are taken over.
+func (p *PartiallySignedTransaction) Merge(other *PartiallySignedTransaction) error {
+	if err := p.SyntacticallyValid(); err != nil {
+		return err
+	}
+	if err := other.SyntacticallyValid(); err != nil {
+		return fmt.Errorf("other partially signed transaction: %w", err)
+	}
+	txData, err := p.SigningMessage()
+	if err != nil {
+		return err
+	}
+	otherTxData, err := other.SigningMessage()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(txData, otherTxData) {
+		return ErrPSTTransactionMismatch
+	}
+	if len(p.Inputs) != len(other.Inputs) {
+		return fmt.Errorf("%w: %d and %d inputs", ErrPSTInputMismatch, len(p.Inputs), len(other.Inputs))
+	}
+
+	for i, input := range p.Inputs {
+		otherInput := other.Inputs[i]
+		addrData, err := input.Address.Serialize(DeSeriModeNoValidation)
+		if err != nil {
+			return err
+		}
+		otherAddrData, err := otherInput.Address.Serialize(DeSeriModeNoValidation)
+		if err != nil {
+			return err
+		}
+		if input.Amount != otherInput.Amount || !bytes.Equal(addrData, otherAddrData) {
+			return fmt.Errorf("%w: input %d", ErrPSTInputMismatch, i)
+		}
+		if input.DerivationPath != "" && otherInput.DerivationPath != "" && input.DerivationPath != otherInput.DerivationPath {
+			return fmt.Errorf("%w: derivation path of input %d", ErrPSTInputMismatch, i)
+		}
+	}
+
+	// verify everything before mutating, so that a failed merge leaves this copy untouched
+	for i, otherInput := range other.Inputs {
+		if otherInput.Signature == nil || p.Inputs[i].Signature != nil {
+			continue
+		}
+		if err := checkSigner(otherInput.Address, otherInput.Signature); err != nil {
+			return fmt.Errorf("input %d: %w", i, err)
+		}
+		if !ed25519.Verify(otherInput.Signature.PublicKey[:], txData, otherInput.Signature.Signature[:]) {
+			return fmt.Errorf("%w: input %d", ErrPSTInvalidSignature, i)
+		}
+	}
+	for i, input := range p.Inputs {
+		otherInput := other.Inputs[i]
+		if input.Signature == nil && otherInput.Signature != nil {
+			sig := *otherInput.Signature
+			input.Signature = &sig
+		}
+		if input.DerivationPath == "" {
+			input.DerivationPath = otherInput.DerivationPath
+		}
+	}
+	return nil
+}
+
+// MissingSignatures returns the indices of the inputs which are not unlocked yet.
+// An input is unlocked by its own signature or by the signature of another input deposited onto the same address.
+func (p *PartiallySignedTransaction) MissingSignatures() []int {
+	var missing []int
+	for i, input := range p.Inputs {
+		if _, has := p.signatureFor(input); !has {
+			missing = append(missing, i)
+		}
+	}
+	return missing
+}
+
+// signatureFor returns the signature of the given input or the signature of another input owned by the same key.
+func (p *PartiallySignedTransaction) signatureFor(input *PartiallySignedInput) (*Ed25519Signature, bool) {
+	if input.Signature != nil {
+		return input.Signature, true
+	}
+	for _, other := range p.Inputs {
+		if other.Signature != nil && checkSigner(input.Address, other.Signature) == nil {
+			return other.Signature, true
+		}
+	}
+	return nil, false
+}
+
+// Complete tells whether every input is unlocked, so that the PartiallySignedTransaction can be finalized.
+func (p *PartiallySignedTransaction) Complete() bool {
+	return len(p.MissingSignatures()) == 0
+}
+
+// Finalize creates the SignedTransactionPayload once every input is unlocked and verifies its signatures,
+// as those of a PartiallySignedTransaction read from its binary or JSON form were never verified.
+// The first input signed by a key gets a SignatureUnlockBlock, further inputs signed by the same key reference it.
+func (p *PartiallySignedTransaction) Finalize() (*SignedTransactionPayload, error) {
+	if err := p.SyntacticallyValid(); err != nil {
+		return nil, err
+	}
+	if missing := p.MissingSignatures(); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: inputs %v", ErrPSTMissingSignature, missing)
+	}
+	msg, err := p.SigningMessage()
+	if err != nil {
+		return nil, err
+	}
+
+	sigBlockPos := make(map[string]int)
+	unlockBlocks := make(Serializables, len(p.Inputs))
+	for i, input := range p.Inputs {
+		sig, _ := p.signatureFor(input)
+		if pos, has := sigBlockPos[string(sig.PublicKey[:])]; has {
+			unlockBlocks[i] = &ReferenceUnlockBlock{Reference: uint16(pos)}
+			continue
+		}
+		if !ed25519.Verify(sig.PublicKey[:], msg, sig.Signature[:]) {
+			return nil, fmt.Errorf("%w: input %d", ErrPSTInvalidSignature, i)
+		}
+		edSig := *sig
+		unlockBlocks[i] = &SignatureUnlockBlock{Signature: &edSig}
+		sigBlockPos[string(sig.PublicKey[:])] = i
+	}
+
+	payload := &SignedTransactionPayload{Transaction: p.Transaction, UnlockBlocks: unlockBlocks}
+	if err := ValidateUnlockBlocks(unlockBlocks, UnlockBlocksSigUniqueAndRefValidator()); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+func (p *PartiallySignedTransaction) Deserialize(data []byte, deSeriMode DeSerializationMode) (int, error) {
+	if err := checkMinByteLength(PartiallySignedTransactionMinSize, len(data)); err != nil {
+		return 0, fmt.Errorf("invalid partially signed transaction bytes: %w", err)
+	}
+	if string(data[:len(PartiallySignedTransactionMagic)]) != PartiallySignedTransactionMagic {
+		return 0, ErrPSTInvalidMagic
+	}
+	bytesReadTotal := len(PartiallySignedTransactionMagic)
+	if version := data[bytesReadTotal]; version != PartiallySignedTransactionVersion {
+		return 0, fmt.Errorf("%w: version %d", ErrPSTUnsupportedVersion, version)
+	}
+	bytesReadTotal += OneByte
+	data = data[bytesReadTotal:]
+
+	tx := &UnsignedTransaction{}
+	txBytesRead, err := tx.Deserialize(data, deSeriMode)
+	if err != nil {
+		return 0, fmt.Errorf("unable to deserialize transaction: %w", err)
+	}
+	bytesReadTotal += txBytesRead
+	data = data[txBytesRead:]
+
+	if err := checkMinByteLength(StructArrayLengthByteSize, len(data)); err != nil {
+		return 0, fmt.Errorf("unable to deserialize input count: %w", err)
+	}
+	inputCount := int(binary.LittleEndian.Uint16(data))
+	bytesReadTotal += StructArrayLengthByteSize
+	data = data[StructArrayLengthByteSize:]
+	if inputCount != len(tx.Inputs) {
+		return 0, fmt.Errorf("%w: %d inputs for %d transaction inputs", ErrPSTInputCountMismatch, inputCount, len(tx.Inputs))
+	}
+
+	inputs := make([]*PartiallySignedInput, inputCount)
+	for i := range inputs {
+		inputs[i] = &PartiallySignedInput{}
+		inputBytesRead, err := inputs[i].Deserialize(data, deSeriMode)
+		if err != nil {
+			return 0, fmt.Errorf("unable to deserialize input %d: %w", i, err)
+		}
+		bytesReadTotal += inputBytesRead
+		data = data[inputBytesRead:]
+	}
+
+	p.Transaction = tx
+	p.Inputs = inputs
+	if deSeriMode.HasMode(DeSeriModePerformValidation) {
+		if err := p.SyntacticallyValid(); err != nil {
+			return 0, err
+		}
+	}
+	return bytesReadTotal, nil
+}
+
+func (p *PartiallySignedTransaction) Serialize(deSeriMode DeSerializationMode) ([]byte, error) {
+	if deSeriMode.HasMode(DeSeriModePerformValidation) {
+		if err := p.SyntacticallyValid(); err != nil {
+			return nil, err
+		}
+	}
+
+	var b bytes.Buffer
+	if _, err := b.WriteString(PartiallySignedTransactionMagic); err != nil {
+		return nil, err
+	}
+	if err := b.WriteByte(PartiallySignedTransactionVersion); err != nil {
+		return nil, err
+	}
+	txData, err := p.Transaction.Serialize(deSeriMode)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := b.Write(txData); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(&b, binary.LittleEndian, uint16(len(p.Inputs))); err != nil {
+		return nil, err
+	}
+	for i, input := range p.Inputs {
+		inputData, err := input.Serialize(deSeriMode)
+		if err != nil {
+			return nil, fmt.Errorf("unable to serialize input %d: %w", i, err)
+		}
+		if _, err := b.Write(inputData); err != nil {
+			return nil, err
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// jsonPartiallySignedTransaction defines the JSON form of a PartiallySignedTransaction.
+type jsonPartiallySignedTransaction struct {
+	Version     int                     `json:"version"`
+	Transaction json.RawMessage         `json:"transaction"`
+	Inputs      []*PartiallySignedInput `json:"inputs"`
+}
+
+func (p *PartiallySignedTransaction) MarshalJSON() ([]byte, error) {
+	txJSON, err := json.Marshal(p.Transaction)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&jsonPartiallySignedTransaction{
+		Version:     int(PartiallySignedTransactionVersion),
+		Transaction: txJSON,
+		Inputs:      p.Inputs,
+	})
+}
+
+func (p *PartiallySignedTransaction) UnmarshalJSON(data []byte) error {
+	jPST := &jsonPartiallySignedTransaction{}
+	if err := json.Unmarshal(data, jPST); err != nil {
+		return err
+	}
+	if jPST.Version != int(PartiallySignedTransactionVersion) {
+		return fmt.Errorf("%w: version %d", ErrPSTUnsupportedVersion, jPST.Version)
+	}
+	tx, err := DeserializeObjectFromJSON(jPST.Transaction, TransactionSelector)
+	if err != nil {
+		return fmt.Errorf("unable to decode transaction: %w", err)
+	}
+	p.Transaction = tx.(*UnsignedTransaction)
+	p.Inputs = jPST.Inputs
+	return nil
+}
@@ -0,0 +1,225 @@
+package iota_test
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPST creates a PartiallySignedTransaction with three inputs of which the first and the last are owned by prvKey1.
+func testPST(t *testing.T, prvKey1 ed25519.PrivateKey, prvKey2 ed25519.PrivateKey) *iota.PartiallySignedTransaction {
+	addr1 := iota.AddressFromEd25519PubKey(prvKey1.Public().(ed25519.PublicKey))
+	addr2 := iota.AddressFromEd25519PubKey(prvKey2.Public().(ed25519.PublicKey))
+	outputAddr, _ := randEd25519Addr()
+
+	tx := &iota.UnsignedTransaction{
+		Inputs: iota.Serializables{
+			&iota.UTXOInput{TransactionID: [iota.TransactionIDLength]byte{1}},
+			&iota.UTXOInput{TransactionID: [iota.TransactionIDLength]byte{2}},
+			&iota.UTXOInput{TransactionID: [iota.TransactionIDLength]byte{3}},
+		},
+		Outputs: iota.Serializables{&iota.SigLockedSingleDeposit{Address: outputAddr, Amount: 60}},
+	}
+	pst, err := iota.NewPartiallySignedTransaction(tx, []*iota.PartiallySignedInput{
+		{Amount: 10, Address: &addr1, DerivationPath: "m/44'/4218'/0'/0'/0'"},
+		{Amount: 20, Address: &addr2},
+		{Amount: 30, Address: &addr1, DerivationPath: "m/44'/4218'/0'/0'/0'"},
+	})
+	require.NoError(t, err)
+	return pst
+}
+
+func TestNewPartiallySignedTransaction(t *testing.T) {
+	pst := testPST(t, randEd25519PrivateKey(), randEd25519PrivateKey())
+
+	_, err := iota.NewPartiallySignedTransaction(pst.Transaction, pst.Inputs[:2])
+	assert.True(t, errors.Is(err, iota.ErrPSTInputCountMismatch))
+
+	inputs := []*iota.PartiallySignedInput{pst.Inputs[0], pst.Inputs[1], {Amount: 31, Address: pst.Inputs[2].Address}}
+	_, err = iota.NewPartiallySignedTransaction(pst.Transaction, inputs)
+	assert.True(t, errors.Is(err, iota.ErrPSTAmountMismatch))
+
+	sig, _ := randEd25519Signature()
+	inputs = []*iota.PartiallySignedInput{pst.Inputs[0], pst.Inputs[1], {Amount: 30, Address: pst.Inputs[2].Address, Signature: sig}}
+	_, err = iota.NewPartiallySignedTransaction(pst.Transaction, inputs)
+	assert.True(t, errors.Is(err, iota.ErrPSTSignerMismatch))
+}
+
+func TestPartiallySignedTransaction_AddSignature(t *testing.T) {
+	prvKey1, prvKey2 := randEd25519PrivateKey(), randEd25519PrivateKey()
+	pst := testPST(t, prvKey1, prvKey2)
+	msg, err := pst.SigningMessage()
+	require.NoError(t, err)
+
+	sign := func(prvKey ed25519.PrivateKey, msg []byte) *iota.Ed25519Signature {
+		sig := &iota.Ed25519Signature{}
+		copy(sig.PublicKey[:], prvKey.Public().(ed25519.PublicKey))
+		copy(sig.Signature[:], ed25519.Sign(prvKey, msg))
+		return sig
+	}
+
+	assert.True(t, errors.Is(pst.AddSignature(3, sign(prvKey2, msg)), iota.ErrPSTInputIndexInvalid))
+	assert.True(t, errors.Is(pst.AddSignature(0, sign(prvKey2, msg)), iota.ErrPSTSignerMismatch))
+	assert.True(t, errors.Is(pst.AddSignature(1, sign(prvKey2, []byte("other"))), iota.ErrPSTInvalidSignature))
+	assert.Equal(t, []int{0, 1, 2}, pst.MissingSignatures())
+
+	require.NoError(t, pst.AddSignature(1, sign(prvKey2, msg)))
+	assert.Equal(t, []int{0, 2}, pst.MissingSignatures())
+	// a signature unlocks every input of the same address
+	require.NoError(t, pst.AddSignature(2, sign(prvKey1, msg)))
+	assert.Empty(t, pst.MissingSignatures())
+	assert.True(t, pst.Complete())
+}
+
+func TestPartiallySignedTransaction_MergeAndFinalize(t *testing.T) {
+	prvKey1, prvKey2 := randEd25519PrivateKey(), randEd25519PrivateKey()
+	pst := testPST(t, prvKey1, prvKey2)
+
+	// each party signs its own copy
+	data, err := pst.Serialize(iota.DeSeriModePerformValidation)
+	require.NoError(t, err)
+	copy1, copy2 := &iota.PartiallySignedTransaction{}, &iota.PartiallySignedTransaction{}
+	_, err = copy1.Deserialize(data, iota.DeSeriModePerformValidation)
+	require.NoError(t, err)
+	_, err = copy2.Deserialize(data, iota.DeSeriModePerformValidation)
+	require.NoError(t, err)
+
+	signed, err := copy1.Sign(prvKey1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, signed)
+	signed, err = copy2.Sign(prvKey2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, signed)
+
+	_, err = copy1.Finalize()
+	assert.True(t, errors.Is(err, iota.ErrPSTMissingSignature))
+
+	require.NoError(t, copy1.Merge(copy2))
+	require.True(t, copy1.Complete())
+	payload, err := copy1.Finalize()
+	require.NoError(t, err)
+
+	require.Len(t, payload.UnlockBlocks, 3)
+	assert.IsType(t, &iota.SignatureUnlockBlock{}, payload.UnlockBlocks[0])
+	assert.IsType(t, &iota.SignatureUnlockBlock{}, payload.UnlockBlocks[1])
+	assert.Equal(t, &iota.ReferenceUnlockBlock{Reference: 0}, payload.UnlockBlocks[2])
+
+	// the finalized payload unlocks the outputs the inputs reference
+	utxos := map[[iota.TransactionIDLength]byte]*iota.UnspentOutput{}
+	for i, input := range pst.Transaction.Inputs {
+		utxoInput := input.(*iota.UTXOInput)
+		utxos[utxoInput.TransactionID] = &iota.UnspentOutput{Input: utxoInput, Address: pst.Inputs[i].Address, Amount: pst.Inputs[i].Amount}
+	}
+	reason, err := payload.SemanticallyValid(func(input *iota.UTXOInput) (*iota.UnspentOutput, bool, error) {
+		return utxos[input.TransactionID], false, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, iota.ConflictNone, reason)
+	payloadData, err := payload.Serialize(iota.DeSeriModePerformValidation)
+	require.NoError(t, err)
+	_, err = (&iota.SignedTransactionPayload{}).Deserialize(payloadData, iota.DeSeriModePerformValidation)
+	require.NoError(t, err)
+}
+
+func TestPartiallySignedTransaction_Finalize_UnverifiedSignature(t *testing.T) {
+	prvKey1, prvKey2 := randEd25519PrivateKey(), randEd25519PrivateKey()
+	pst := testPST(t, prvKey1, prvKey2)
+	_, err := pst.Sign(prvKey1)
+	require.NoError(t, err)
+
+	// a garbage signature of the right key passes the syntactic checks of deserialization
+	garbage, _ := randEd25519Signature()
+	copy(garbage.PublicKey[:], prvKey2.Public().(ed25519.PublicKey))
+	pst.Inputs[1].Signature = garbage
+	data, err := pst.Serialize(iota.DeSeriModePerformValidation)
+	require.NoError(t, err)
+	loaded := &iota.PartiallySignedTransaction{}
+	_, err = loaded.Deserialize(data, iota.DeSeriModePerformValidation)
+	require.NoError(t, err)
+
+	_, err = loaded.Finalize()
+	assert.True(t, errors.Is(err, iota.ErrPSTInvalidSignature))
+}
+
+func TestPartiallySignedTransaction_Merge_Invalid(t *testing.T) {
+	prvKey1, prvKey2 := randEd25519PrivateKey(), randEd25519PrivateKey()
+	pst := testPST(t, prvKey1, prvKey2)
+
+	other := testPST(t, prvKey1, prvKey2)
+	assert.True(t, errors.Is(pst.Merge(other), iota.ErrPSTTransactionMismatch))
+
+	other = &iota.PartiallySignedTransaction{Transaction: pst.Transaction, Inputs: []*iota.PartiallySignedInput{
+		pst.Inputs[0], {Amount: 20, Address: pst.Inputs[0].Address}, pst.Inputs[2],
+	}}
+	assert.True(t, errors.Is(pst.Merge(other), iota.ErrPSTInputMismatch))
+
+	// forged signatures are rejected and leave the copy untouched
+	forged, _ := randEd25519Signature()
+	copy(forged.PublicKey[:], prvKey2.Public().(ed25519.PublicKey))
+	other = &iota.PartiallySignedTransaction{Transaction: pst.Transaction, Inputs: []*iota.PartiallySignedInput{
+		{Amount: 10, Address: pst.Inputs[0].Address},
+		{Amount: 20, Address: pst.Inputs[1].Address, DerivationPath: "m/44'/4218'/1'/0'/0'", Signature: forged},
+		pst.Inputs[2],
+	}}
+	assert.True(t, errors.Is(pst.Merge(other), iota.ErrPSTInvalidSignature))
+	assert.Equal(t, []int{0, 1, 2}, pst.MissingSignatures())
+	assert.Empty(t, pst.Inputs[1].DerivationPath)
+
+	other.Inputs[0].DerivationPath = "m/1'"
+	assert.True(t, errors.Is(pst.Merge(other), iota.ErrPSTInputMismatch))
+
+	// copies decoded without validation may lack addresses
+	other = &iota.PartiallySignedTransaction{Transaction: pst.Transaction, Inputs: []*iota.PartiallySignedInput{
+		{Amount: 10}, pst.Inputs[1], pst.Inputs[2],
+	}}
+	assert.True(t, errors.Is(pst.Merge(other), iota.ErrPSTInputCountMismatch))
+	assert.Error(t, other.Merge(pst))
+}
+
+func TestPartiallySignedTransaction_Serialization(t *testing.T) {
+	prvKey1, prvKey2 := randEd25519PrivateKey(), randEd25519PrivateKey()
+	pst := testPST(t, prvKey1, prvKey2)
+	_, err := pst.Sign(prvKey2)
+	require.NoError(t, err)
+
+	data, err := pst.Serialize(iota.DeSeriModePerformValidation)
+	require.NoError(t, err)
+	assert.Equal(t, iota.PartiallySignedTransactionMagic, string(data[:4]))
+	assert.Equal(t, iota.PartiallySignedTransactionVersion, data[4])
+
+	deserialized := &iota.PartiallySignedTransaction{}
+	bytesRead, err := deserialized.Deserialize(data, iota.DeSeriModePerformValidation)
+	require.NoError(t, err)
+	assert.Equal(t, len(data), bytesRead)
+	assert.Equal(t, pst, deserialized)
+
+	jsonData, err := json.Marshal(pst)
+	require.NoError(t, err)
+	fromJSON := &iota.PartiallySignedTransaction{}
+	require.NoError(t, json.Unmarshal(jsonData, fromJSON))
+	assert.Equal(t, pst, fromJSON)
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"invalid magic", append([]byte("psbt"), data[4:]...), iota.ErrPSTInvalidMagic},
+		{"unsupported version", append(append([]byte(nil), data[:4]...), append([]byte{2}, data[5:]...)...), iota.ErrPSTUnsupportedVersion},
+		{"truncated", data[:len(data)-10], iota.ErrDeserializationNotEnoughData},
+		{"too short", data[:10], iota.ErrDeserializationNotEnoughData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := (&iota.PartiallySignedTransaction{}).Deserialize(tt.data, iota.DeSeriModePerformValidation)
+			assert.True(t, errors.Is(err, tt.err), err)
+		})
+	}
+
+	assert.True(t, errors.Is(json.Unmarshal([]byte(`{"version": 2}`), &iota.PartiallySignedTransaction{}), iota.ErrPSTUnsupportedVersion))
+}
@@ -0,0 +1,107 @@
+package iota
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"runtime"
+	"sync"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// The maximum amount of leading zero bits a message ID can have.
+	MaxPoWLeadingZeroBits = MessageHashLength * 8
+	// The amount of nonces a PoW worker tries between checking whether it should stop.
+	powCheckInterval = 1 << 12
+)
+
+var (
+	ErrInsufficientPoW = errors.New("insufficient proof of work")
+)
+
+// PoWLeadingZeroBits returns the amount of leading zero bits of the given message ID, which denotes the work
+// which went into finding the message's nonce: on average 2^n nonces must be tried to get n leading zero bits.
+func PoWLeadingZeroBits(id MessageID) int {
+	var zeroBits int
+	for _, b := range id {
+		if b != 0 {
+			return zeroBits + bits.LeadingZeros8(b)
+		}
+		zeroBits += 8
+	}
+	return zeroBits
+}
+
+// CheckPoW checks whether the ID of the given message has at least the given amount of leading zero bits.
+func CheckPoW(msg *Message, minLeadingZeroBits int) error {
+	id, err := msg.ID()
+	if err != nil {
+		return err
+	}
+	if zeroBits := PoWLeadingZeroBits(id); zeroBits < minLeadingZeroBits {
+		return fmt.Errorf("%w: message ID has %d leading zero bits but %d are required", ErrInsufficientPoW, zeroBits, minLeadingZeroBits)
+	}
+	return nil
+}
+
+// DoPoW searches a nonce for which the ID of the given message has at least the given amount of leading zero bits
+// and sets it on the message. The search runs on the given amount of workers, runtime.NumCPU() are used if it is not positive.
+// It never picks a zero nonce, as a zero nonce denotes a message whose PoW is still to be done.
+func DoPoW(ctx context.Context, msg *Message, leadingZeroBits int, workers int) error {
+	if leadingZeroBits < 0 || leadingZeroBits > MaxPoWLeadingZeroBits {
+		return fmt.Errorf("leading zero bits must be between 0 and %d but are %d", MaxPoWLeadingZeroBits, leadingZeroBits)
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	data, err := msg.Serialize(DeSeriModeNoValidation)
+	if err != nil {
+		return fmt.Errorf("unable to serialize message for PoW: %w", err)
+	}
+	nonceOffset := len(data) - UInt64ByteSize
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	found := make(chan uint64, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(start uint64) {
+			defer wg.Done()
+			workerData := append([]byte(nil), data...)
+			for i, nonce := 0, start; ; i, nonce = i+1, nonce+uint64(workers) {
+				if i%powCheckInterval == 0 && ctx.Err() != nil {
+					return
+				}
+				if nonce == 0 {
+					continue
+				}
+				binary.LittleEndian.PutUint64(workerData[nonceOffset:], nonce)
+				if PoWLeadingZeroBits(blake2b.Sum256(workerData)) >= leadingZeroBits {
+					found <- nonce
+					return
+				}
+			}
+		}(uint64(i + 1))
+	}
+
+	var nonce uint64
+	select {
+	case nonce = <-found:
+	case <-ctx.Done():
+	}
+	cancel()
+	wg.Wait()
+
+	if nonce == 0 {
+		return ctx.Err()
+	}
+	msg.Nonce = nonce
+	return nil
+}
@@ -0,0 +1,54 @@
+package iota_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/luca-moser/iota"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoWLeadingZeroBits(t *testing.T) {
+	tests := []struct {
+		name string
+		id   iota.MessageID
+		bits int
+	}{
+		{"none", iota.MessageID{0xFF}, 0},
+		{"first byte", iota.MessageID{0x01}, 7},
+		{"second byte", iota.MessageID{0x00, 0x20}, 10},
+		{"all", iota.MessageID{}, iota.MaxPoWLeadingZeroBits},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.bits, iota.PoWLeadingZeroBits(tt.id))
+		})
+	}
+}
+
+func TestDoPoW(t *testing.T) {
+	msg, _ := randMessage(iota.IndexationPayloadID)
+	msg.Nonce = 0
+	require.NoError(t, iota.DoPoW(context.Background(), msg, 12, 4))
+	assert.NotZero(t, msg.Nonce)
+	assert.NoError(t, iota.CheckPoW(msg, 12))
+
+	msg.Nonce++
+	for iota.CheckPoW(msg, 12) == nil {
+		msg.Nonce++
+	}
+	assert.True(t, errors.Is(iota.CheckPoW(msg, 12), iota.ErrInsufficientPoW))
+}
+
+func TestDoPoW_Canceled(t *testing.T) {
+	msg, _ := randMessage(iota.IndexationPayloadID)
+	msg.Nonce = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := iota.DoPoW(ctx, msg, iota.MaxPoWLeadingZeroBits, 2)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Zero(t, msg.Nonce)
+}
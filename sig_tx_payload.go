@@ -3,8 +3,11 @@ package iota
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
+
+	"golang.org/x/crypto/blake2b"
 )
 
 const (
@@ -136,7 +139,190 @@ func (s *SignedTransactionPayload) Serialize(deSeriMode DeSerializationMode) ([]
 	return b.Bytes(), nil
 }
 
+// jsonSignedTransactionPayload defines the JSON form of a SignedTransactionPayload.
+type jsonSignedTransactionPayload struct {
+	Type         int               `json:"type"`
+	Transaction  json.RawMessage   `json:"transaction"`
+	UnlockBlocks []json.RawMessage `json:"unlock_blocks"`
+}
+
+func (s *SignedTransactionPayload) MarshalJSON() ([]byte, error) {
+	txJSON, err := json.Marshal(s.Transaction)
+	if err != nil {
+		return nil, err
+	}
+	jPayload := &jsonSignedTransactionPayload{
+		Type:         int(SignedTransactionPayloadID),
+		Transaction:  txJSON,
+		UnlockBlocks: make([]json.RawMessage, len(s.UnlockBlocks)),
+	}
+	for i, block := range s.UnlockBlocks {
+		if jPayload.UnlockBlocks[i], err = json.Marshal(block); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(jPayload)
+}
+
+func (s *SignedTransactionPayload) UnmarshalJSON(data []byte) error {
+	jPayload := &jsonSignedTransactionPayload{}
+	if err := json.Unmarshal(data, jPayload); err != nil {
+		return err
+	}
+	if err := checkJSONType(jPayload.Type, SignedTransactionPayloadID); err != nil {
+		return fmt.Errorf("unable to decode signed transaction payload from JSON: %w", err)
+	}
+	tx, err := DeserializeObjectFromJSON(jPayload.Transaction, TransactionSelector)
+	if err != nil {
+		return fmt.Errorf("unable to decode transaction: %w", err)
+	}
+	unlockBlocks, err := DeserializeArrayOfObjectsFromJSON(jPayload.UnlockBlocks, UnlockBlockSelector)
+	if err != nil {
+		return fmt.Errorf("unable to decode unlock blocks: %w", err)
+	}
+	s.Transaction = tx
+	s.UnlockBlocks = unlockBlocks
+	return nil
+}
+
 func (s *SignedTransactionPayload) Validate() error {
 
 	return nil
 }
+
+// ID computes the ID of the transaction, which is the BLAKE2b-256 hash of the serialized signed transaction payload.
+func (s *SignedTransactionPayload) ID() ([TransactionIDLength]byte, error) {
+	data, err := s.Serialize(DeSeriModeNoValidation)
+	if err != nil {
+		return [TransactionIDLength]byte{}, fmt.Errorf("can't compute transaction ID: %w", err)
+	}
+	return blake2b.Sum256(data), nil
+}
+
+// SemanticallyValid checks whether the syntactically valid transaction is valid against the ledger state given by utxoLookup,
+// by checking whether:
+//	1. every input references an existing and unspent UTXO
+//	2. the sum of the referenced UTXOs equals the sum of the outputs
+//	3. every signature unlock block holds a valid signature over the unsigned transaction, see VerifyEd25519
+//	4. the signer of every unlock block owns the address of the UTXO the corresponding input references
+// The returned ConflictReason denotes which check failed, the returned error then wraps the reason's error.
+// An error together with ConflictNone signals that the lookup itself failed.
+func (s *SignedTransactionPayload) SemanticallyValid(utxoLookup UTXOLookupFunc) (ConflictReason, error) {
+	unsignedTx, ok := s.Transaction.(*UnsignedTransaction)
+	if !ok {
+		return conflict(ConflictUnsupported, "transaction is %T", s.Transaction)
+	}
+
+	// transactions which didn't go through deserialization may hold duplicate inputs or overflowing outputs
+	if err := unsignedTx.SyntacticallyValid(); err != nil {
+		return conflict(ConflictUnsupported, "transaction is syntactically invalid: %v", err)
+	}
+
+	if len(s.UnlockBlocks) != len(unsignedTx.Inputs) {
+		return conflict(ConflictUnsupported, "%d unlock blocks for %d inputs", len(s.UnlockBlocks), len(unsignedTx.Inputs))
+	}
+
+	utxos := make([]*UnspentOutput, len(unsignedTx.Inputs))
+	var inputsSum, outputsSum uint64
+	for i, input := range unsignedTx.Inputs {
+		utxoInput, ok := input.(*UTXOInput)
+		if !ok {
+			return conflict(ConflictUnsupported, "input %d is %T", i, input)
+		}
+		utxo, spent, err := utxoLookup(utxoInput)
+		if err != nil {
+			return ConflictNone, fmt.Errorf("unable to look up UTXO of input %d: %w", i, err)
+		}
+		if utxo == nil {
+			return conflict(ConflictInputUTXONotFound, "input %d", i)
+		}
+		if spent {
+			return conflict(ConflictInputUTXOAlreadySpent, "input %d", i)
+		}
+		utxos[i] = utxo
+		inputsSum += utxo.Amount
+	}
+
+	for i, output := range unsignedTx.Outputs {
+		dep, ok := output.(*SigLockedSingleDeposit)
+		if !ok {
+			return conflict(ConflictUnsupported, "output %d is %T", i, output)
+		}
+		outputsSum += dep.Amount
+	}
+
+	if inputsSum != outputsSum {
+		return conflict(ConflictInputOutputSumMismatch, "inputs %d, outputs %d", inputsSum, outputsSum)
+	}
+
+	unsignedTxData, err := unsignedTx.Serialize(DeSeriModeNoValidation)
+	if err != nil {
+		return ConflictNone, fmt.Errorf("unable to serialize unsigned transaction: %w", err)
+	}
+
+	// the signatures are verified in parallel up front, the checks of the unlock blocks are then
+	// reported in their order, just as if every block was checked one after another
+	type checkedBlock struct {
+		edSig    *Ed25519Signature
+		utxoAddr *Ed25519Address
+		// the index of the signature within the verifier, -1 for reference unlock blocks
+		sigIndex int
+	}
+	checked := make([]checkedBlock, 0, len(s.UnlockBlocks))
+	verifier := NewEd25519ParallelVerifier()
+	var blockReason ConflictReason
+	var blockErr error
+	for i, block := range s.UnlockBlocks {
+		var sigBlock *SignatureUnlockBlock
+		var verify bool
+		switch b := block.(type) {
+		case *SignatureUnlockBlock:
+			sigBlock, verify = b, true
+		case *ReferenceUnlockBlock:
+			// the referenced signature is verified at its own index
+			if int(b.Reference) < len(s.UnlockBlocks) {
+				sigBlock, _ = s.UnlockBlocks[b.Reference].(*SignatureUnlockBlock)
+			}
+		}
+		if sigBlock == nil {
+			blockReason, blockErr = conflict(ConflictUnsupported, "unlock block %d neither is nor references a signature unlock block", i)
+			break
+		}
+
+		edSig, ok := sigBlock.Signature.(*Ed25519Signature)
+		if !ok {
+			blockReason, blockErr = conflict(ConflictUnsupported, "unlock block %d holds %T", i, sigBlock.Signature)
+			break
+		}
+
+		utxoAddr, ok := utxos[i].Address.(*Ed25519Address)
+		if !ok {
+			blockReason, blockErr = conflict(ConflictUnsupported, "UTXO of input %d deposits onto %T", i, utxos[i].Address)
+			break
+		}
+
+		sigIndex := -1
+		if verify {
+			sigIndex = verifier.Add(edSig, unsignedTxData)
+		}
+		checked = append(checked, checkedBlock{edSig: edSig, utxoAddr: utxoAddr, sigIndex: sigIndex})
+	}
+
+	invalid := make(map[int]bool)
+	for _, sigIndex := range verifier.Verify() {
+		invalid[sigIndex] = true
+	}
+	for i, block := range checked {
+		if block.sigIndex != -1 && invalid[block.sigIndex] {
+			return conflict(ConflictInvalidSignature, "unlock block %d", i)
+		}
+		if AddressFromEd25519PubKey(block.edSig.PublicKey[:]) != *block.utxoAddr {
+			return conflict(ConflictSignerAddressMismatch, "unlock block %d", i)
+		}
+	}
+	if blockErr != nil {
+		return blockReason, blockErr
+	}
+
+	return ConflictNone, nil
+}
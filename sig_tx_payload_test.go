@@ -1,11 +1,14 @@
 package iota_test
 
 import (
+	"crypto/ed25519"
 	"errors"
+	"math"
 	"testing"
 
 	"github.com/luca-moser/iota"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/blake2b"
 )
 
 func TestSignedTransactionPayload_Deserialize(t *testing.T) {
@@ -57,3 +60,132 @@ func TestSignedTransactionPayload_Serialize(t *testing.T) {
 		})
 	}
 }
+
+func TestSignedTransactionPayload_ID(t *testing.T) {
+	sigTxPayload, sigTxPayloadData := randSignedTransactionPayload()
+	id, err := sigTxPayload.ID()
+	assert.NoError(t, err)
+	assert.Equal(t, blake2b.Sum256(sigTxPayloadData), id)
+}
+
+func TestSignedTransactionPayload_SemanticallyValid(t *testing.T) {
+	prvKey := randEd25519PrivateKey()
+	ownerAddr := iota.AddressFromEd25519PubKey(prvKey.Public().(ed25519.PublicKey))
+	otherPrvKey := randEd25519PrivateKey()
+
+	type utxoState struct {
+		output *iota.UnspentOutput
+		spent  bool
+	}
+
+	// builds a transaction spending the given UTXOs with the given key and a lookup over them
+	setup := func(key ed25519.PrivateKey, outputsSum uint64, utxos ...utxoState) (*iota.SignedTransactionPayload, iota.UTXOLookupFunc) {
+		builder := iota.NewTransactionBuilder()
+		var inputsSum uint64
+		state := map[[iota.TransactionIDLength]byte]utxoState{}
+		for _, utxo := range utxos {
+			builder.AddInput(&iota.ToBeSignedUTXOInput{Input: utxo.output.Input, Amount: utxo.output.Amount, PrivateKey: key})
+			inputsSum += utxo.output.Amount
+			state[utxo.output.Input.TransactionID] = utxo
+		}
+		outputAddr, _ := randEd25519Addr()
+		payload, err := builder.AddOutput(&iota.SigLockedSingleDeposit{Address: outputAddr, Amount: inputsSum}).Build()
+		must(err)
+		if outputsSum != inputsSum {
+			payload.Transaction.(*iota.UnsignedTransaction).Outputs[0].(*iota.SigLockedSingleDeposit).Amount = outputsSum
+		}
+		return payload, func(input *iota.UTXOInput) (*iota.UnspentOutput, bool, error) {
+			utxo, has := state[input.TransactionID]
+			if !has {
+				return nil, false, nil
+			}
+			return utxo.output, utxo.spent, nil
+		}
+	}
+
+	utxo := func(amount uint64, spent bool) utxoState {
+		input, _ := randUTXOInput()
+		addr := ownerAddr
+		return utxoState{output: &iota.UnspentOutput{Input: input, Address: &addr, Amount: amount}, spent: spent}
+	}
+
+	type test struct {
+		name     string
+		payload  *iota.SignedTransactionPayload
+		lookup   iota.UTXOLookupFunc
+		conflict iota.ConflictReason
+	}
+	tests := []test{
+		func() test {
+			payload, lookup := setup(prvKey, 300, utxo(100, false), utxo(200, false))
+			return test{"ok", payload, lookup, iota.ConflictNone}
+		}(),
+		func() test {
+			payload, lookup := setup(prvKey, 300, utxo(100, false), utxo(200, true))
+			return test{"already spent", payload, lookup, iota.ConflictInputUTXOAlreadySpent}
+		}(),
+		func() test {
+			payload, _ := setup(prvKey, 100, utxo(100, false))
+			_, lookup := setup(prvKey, 100, utxo(100, false))
+			return test{"not found", payload, lookup, iota.ConflictInputUTXONotFound}
+		}(),
+		func() test {
+			payload, lookup := setup(prvKey, 99, utxo(100, false))
+			return test{"sum mismatch", payload, lookup, iota.ConflictInputOutputSumMismatch}
+		}(),
+		func() test {
+			payload, lookup := setup(prvKey, 100, utxo(100, false))
+			edSig := payload.UnlockBlocks[0].(*iota.SignatureUnlockBlock).Signature.(*iota.Ed25519Signature)
+			edSig.Signature[0] ^= 0xff
+			return test{"invalid signature", payload, lookup, iota.ConflictInvalidSignature}
+		}(),
+		func() test {
+			// the output amounts wrap around to the input amount
+			payload, lookup := setup(prvKey, 100, utxo(100, false))
+			tx := payload.Transaction.(*iota.UnsignedTransaction)
+			tx.Outputs[0].(*iota.SigLockedSingleDeposit).Amount = math.MaxUint64
+			otherAddr, _ := randEd25519Addr()
+			tx.Outputs = append(tx.Outputs, &iota.SigLockedSingleDeposit{Address: otherAddr, Amount: 101})
+			return test{"overflowing outputs", payload, lookup, iota.ConflictUnsupported}
+		}(),
+		func() test {
+			payload, lookup := setup(otherPrvKey, 100, utxo(100, false))
+			return test{"signer address mismatch", payload, lookup, iota.ConflictSignerAddressMismatch}
+		}(),
+		func() test {
+			payload, lookup := setup(otherPrvKey, 100, utxo(100, false))
+			edSig := payload.UnlockBlocks[0].(*iota.SignatureUnlockBlock).Signature.(*iota.Ed25519Signature)
+			edSig.Signature[0] ^= 0xff
+			return test{"invalid signature before signer address mismatch", payload, lookup, iota.ConflictInvalidSignature}
+		}(),
+		func() test {
+			payload, lookup := setup(prvKey, 300, utxo(100, false), utxo(200, false))
+			edSig := payload.UnlockBlocks[0].(*iota.SignatureUnlockBlock).Signature.(*iota.Ed25519Signature)
+			edSig.Signature[0] ^= 0xff
+			payload.UnlockBlocks[1] = &iota.SignatureUnlockBlock{Signature: &iota.WOTSSignature{}}
+			return test{"invalid signature before unsupported unlock block", payload, lookup, iota.ConflictInvalidSignature}
+		}(),
+		func() test {
+			payload, lookup := setup(otherPrvKey, 300, utxo(100, false), utxo(200, false))
+			payload.UnlockBlocks[1] = &iota.SignatureUnlockBlock{Signature: &iota.WOTSSignature{}}
+			return test{"signer address mismatch before unsupported unlock block", payload, lookup, iota.ConflictSignerAddressMismatch}
+		}(),
+		func() test {
+			payload, lookup := setup(prvKey, 300, utxo(100, false), utxo(200, false))
+			payload.UnlockBlocks[0] = &iota.SignatureUnlockBlock{Signature: &iota.WOTSSignature{}}
+			return test{"unsupported unlock block", payload, lookup, iota.ConflictUnsupported}
+		}(),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflict, err := tt.payload.SemanticallyValid(tt.lookup)
+			assert.Equal(t, tt.conflict, conflict)
+			if tt.conflict == iota.ConflictNone {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, tt.conflict.Err()))
+		})
+	}
+}
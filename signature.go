@@ -3,6 +3,8 @@ package iota
 import (
 	"crypto/ed25519"
 	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 )
 
@@ -48,6 +50,25 @@ func (w *WOTSSignature) Serialize(deSeriMode DeSerializationMode) ([]byte, error
 	panic("implement me")
 }
 
+func (w *WOTSSignature) MarshalJSON() ([]byte, error) {
+	ty := int(SignatureWOTS)
+	return json.Marshal(&jsonTypeDenotation{Type: &ty})
+}
+
+func (w *WOTSSignature) UnmarshalJSON(data []byte) error {
+	jSig := &jsonTypeDenotation{}
+	if err := json.Unmarshal(data, jSig); err != nil {
+		return err
+	}
+	if jSig.Type == nil {
+		return fmt.Errorf("%w: WOTS signature has no type denotation", ErrInvalidJSON)
+	}
+	if err := checkJSONType(*jSig.Type, SignatureWOTS); err != nil {
+		return fmt.Errorf("unable to decode WOTS signature from JSON: %w", err)
+	}
+	return nil
+}
+
 type Ed25519Signature struct {
 	PublicKey [ed25519.PublicKeySize]byte `json:"public_key"`
 	Signature [ed25519.SignatureSize]byte `json:"signature"`
@@ -76,3 +97,32 @@ func (e *Ed25519Signature) Serialize(deSeriMode DeSerializationMode) ([]byte, er
 	copy(b[TypeDenotationByteSize+ed25519.PublicKeySize:], e.Signature[:])
 	return b[:], nil
 }
+
+// jsonEd25519Signature defines the JSON form of an Ed25519Signature.
+type jsonEd25519Signature struct {
+	Type      int    `json:"type"`
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+func (e *Ed25519Signature) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonEd25519Signature{
+		Type:      int(SignatureEd25519),
+		PublicKey: hex.EncodeToString(e.PublicKey[:]),
+		Signature: hex.EncodeToString(e.Signature[:]),
+	})
+}
+
+func (e *Ed25519Signature) UnmarshalJSON(data []byte) error {
+	jSig := &jsonEd25519Signature{}
+	if err := json.Unmarshal(data, jSig); err != nil {
+		return err
+	}
+	if err := checkJSONType(jSig.Type, SignatureEd25519); err != nil {
+		return fmt.Errorf("unable to decode Ed25519 signature from JSON: %w", err)
+	}
+	if err := decodeHexJSON("public_key", jSig.PublicKey, e.PublicKey[:]); err != nil {
+		return err
+	}
+	return decodeHexJSON("signature", jSig.Signature, e.Signature[:])
+}
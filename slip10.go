@@ -0,0 +1,188 @@
+package iota
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// The offset of hardened indices in derivation paths.
+	HardenedIndex uint32 = 0x80000000
+	// The BIP-44 purpose of derivation paths.
+	BIP44Purpose uint32 = 44
+	// The registered SLIP-44 coin type of IOTA.
+	CoinTypeIOTA uint32 = 4218
+
+	// The minimum length of a seed to derive keys from.
+	MinSeedLength = 16
+	// The maximum length of a seed to derive keys from.
+	MaxSeedLength = 64
+
+	// The HMAC key deriving the Ed25519 master key from a seed.
+	slip10Ed25519Curve = "ed25519 seed"
+)
+
+var (
+	ErrInvalidDerivationPath = errors.New("invalid derivation path")
+	ErrNonHardenedIndex      = errors.New("ed25519 derivation only supports hardened indices")
+	ErrInvalidSeedLength     = errors.New("invalid seed length")
+)
+
+// DerivationPath is a path of indices along which keys are derived from a seed.
+type DerivationPath []uint32
+
+// ParseDerivationPath parses a derivation path like m/44'/4218'/0'/0'/0'. Hardened indices are denoted
+// by a trailing ' or h, as only those are supported by Ed25519 derivation.
+func ParseDerivationPath(s string) (DerivationPath, error) {
+	segments := strings.Split(s, "/")
+	if segments[0] != "m" {
+		return nil, fmt.Errorf("%w: %s must start with m", ErrInvalidDerivationPath, s)
+	}
+	path := make(DerivationPath, 0, len(segments)-1)
+	for _, segment := range segments[1:] {
+		trimmed := strings.TrimRight(segment, "'hH")
+		index, err := strconv.ParseUint(trimmed, 10, 31)
+		switch {
+		case err != nil:
+			return nil, fmt.Errorf("%w: segment '%s' of %s: %v", ErrInvalidDerivationPath, segment, s, err)
+		case len(segment) == len(trimmed):
+			return nil, fmt.Errorf("%w: segment '%s' of %s", ErrNonHardenedIndex, segment, s)
+		case len(segment)-len(trimmed) > 1:
+			return nil, fmt.Errorf("%w: segment '%s' of %s", ErrInvalidDerivationPath, segment, s)
+		}
+		path = append(path, uint32(index)|HardenedIndex)
+	}
+	return path, nil
+}
+
+// String returns the textual form of the path, denoting hardened indices with a trailing '.
+func (p DerivationPath) String() string {
+	var b strings.Builder
+	b.WriteString("m")
+	for _, index := range p {
+		if index >= HardenedIndex {
+			fmt.Fprintf(&b, "/%d'", index-HardenedIndex)
+			continue
+		}
+		fmt.Fprintf(&b, "/%d", index)
+	}
+	return b.String()
+}
+
+// AddressDerivationPath returns the BIP-44 path m/44'/4218'/account'/0'/addressIndex' of the given account and address index.
+func AddressDerivationPath(account uint32, addressIndex uint32) (DerivationPath, error) {
+	if account >= HardenedIndex || addressIndex >= HardenedIndex {
+		return nil, fmt.Errorf("%w: account %d and address index %d must be below %d", ErrInvalidDerivationPath, account, addressIndex, HardenedIndex)
+	}
+	return DerivationPath{
+		BIP44Purpose | HardenedIndex,
+		CoinTypeIOTA | HardenedIndex,
+		account | HardenedIndex,
+		HardenedIndex,
+		addressIndex | HardenedIndex,
+	}, nil
+}
+
+// ExtendedEd25519Key is an Ed25519 key derived as defined in SLIP-10, together with the chain code deriving its children.
+type ExtendedEd25519Key struct {
+	// The seed of the Ed25519 private key.
+	Key [ed25519.SeedSize]byte
+	// The chain code deriving the children of the key.
+	ChainCode [32]byte
+}
+
+func newExtendedEd25519Key(hmacKey []byte, data []byte) *ExtendedEd25519Key {
+	mac := hmac.New(sha512.New, hmacKey)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	key := &ExtendedEd25519Key{}
+	copy(key.Key[:], sum[:ed25519.SeedSize])
+	copy(key.ChainCode[:], sum[ed25519.SeedSize:])
+	return key
+}
+
+// NewEd25519MasterKey derives the master key of the given seed.
+func NewEd25519MasterKey(seed []byte) (*ExtendedEd25519Key, error) {
+	if len(seed) < MinSeedLength || len(seed) > MaxSeedLength {
+		return nil, fmt.Errorf("%w: seed is %d bytes long but must be between %d and %d", ErrInvalidSeedLength, len(seed), MinSeedLength, MaxSeedLength)
+	}
+	return newExtendedEd25519Key([]byte(slip10Ed25519Curve), seed), nil
+}
+
+// Child derives the child key at the given index, which must be hardened.
+func (k *ExtendedEd25519Key) Child(index uint32) (*ExtendedEd25519Key, error) {
+	if index < HardenedIndex {
+		return nil, fmt.Errorf("%w: index %d", ErrNonHardenedIndex, index)
+	}
+	var data [1 + ed25519.SeedSize + UInt32ByteSize]byte
+	copy(data[1:], k.Key[:])
+	binary.BigEndian.PutUint32(data[1+ed25519.SeedSize:], index)
+	return newExtendedEd25519Key(k.ChainCode[:], data[:]), nil
+}
+
+// Derive derives the key at the given path relative to this key.
+func (k *ExtendedEd25519Key) Derive(path DerivationPath) (*ExtendedEd25519Key, error) {
+	key := k
+	for _, index := range path {
+		var err error
+		if key, err = key.Child(index); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// PrivateKey returns the Ed25519 private key.
+func (k *ExtendedEd25519Key) PrivateKey() ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(k.Key[:])
+}
+
+// PublicKey returns the Ed25519 public key.
+func (k *ExtendedEd25519Key) PublicKey() ed25519.PublicKey {
+	return k.PrivateKey().Public().(ed25519.PublicKey)
+}
+
+// Address returns the Ed25519Address of the key.
+func (k *ExtendedEd25519Key) Address() Ed25519Address {
+	return AddressFromEd25519PubKey(k.PublicKey())
+}
+
+// DeriveEd25519Key derives the Ed25519 private key of the given seed along the given path.
+func DeriveEd25519Key(seed []byte, path DerivationPath) (ed25519.PrivateKey, error) {
+	master, err := NewEd25519MasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	key, err := master.Derive(path)
+	if err != nil {
+		return nil, err
+	}
+	return key.PrivateKey(), nil
+}
+
+// DeriveEd25519AddressKey derives the Ed25519 private key of the given seed for the given account and address index,
+// along the path returned by AddressDerivationPath.
+func DeriveEd25519AddressKey(seed []byte, account uint32, addressIndex uint32) (ed25519.PrivateKey, error) {
+	path, err := AddressDerivationPath(account, addressIndex)
+	if err != nil {
+		return nil, err
+	}
+	return DeriveEd25519Key(seed, path)
+}
+
+// DeriveEd25519Address derives the Ed25519Address of the given seed for the given account and address index
+// together with the private key owning it.
+func DeriveEd25519Address(seed []byte, account uint32, addressIndex uint32) (*Ed25519Address, ed25519.PrivateKey, error) {
+	prvKey, err := DeriveEd25519AddressKey(seed, account, addressIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+	addr := AddressFromEd25519PubKey(prvKey.Public().(ed25519.PublicKey))
+	return &addr, prvKey, nil
+}
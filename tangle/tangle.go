@@ -0,0 +1,341 @@
+// Package tangle implements a store for the DAG formed by messages referencing their parents.
+package tangle
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/luca-moser/iota"
+)
+
+// AttachedCallbackFunc is called when the given message was newly attached to the tangle.
+type AttachedCallbackFunc func(id iota.MessageID, msg *iota.Message)
+
+// SolidCallbackFunc is called when the given message became solid.
+type SolidCallbackFunc func(id iota.MessageID, msg *iota.Message)
+
+// MissingCallbackFunc is called when a message references the given parent which isn't in the tangle.
+type MissingCallbackFunc func(id iota.MessageID)
+
+// entry is a message in the tangle together with its metadata.
+type entry struct {
+	msg  *iota.Message
+	meta *iota.MessageMetadata
+}
+
+// Tangle stores messages and indexes them by their ID and their approvers.
+// A message is solid once its entire past cone is present in the tangle, which is the case
+// when both of its parents are solid or solid entry points.
+// It is safe for concurrent use.
+type Tangle struct {
+	mu               sync.RWMutex
+	messages         map[iota.MessageID]*entry
+	approvers        map[iota.MessageID]map[iota.MessageID]struct{}
+	missing          map[iota.MessageID]struct{}
+	solidEntryPoints map[iota.MessageID]struct{}
+
+	callbacksMu       sync.RWMutex
+	attachedCallbacks []AttachedCallbackFunc
+	solidCallbacks    []SolidCallbackFunc
+	missingCallbacks  []MissingCallbackFunc
+}
+
+// New creates a new Tangle with the given solid entry points, which are treated as solid
+// messages without needing to be present. They usually stem from a local snapshot.
+func New(solidEntryPoints ...iota.MessageID) *Tangle {
+	t := &Tangle{
+		messages:         make(map[iota.MessageID]*entry),
+		approvers:        make(map[iota.MessageID]map[iota.MessageID]struct{}),
+		missing:          make(map[iota.MessageID]struct{}),
+		solidEntryPoints: make(map[iota.MessageID]struct{}),
+	}
+	for _, sep := range solidEntryPoints {
+		t.solidEntryPoints[sep] = struct{}{}
+	}
+	return t
+}
+
+// LSSEPConsumer returns an iota.LSSEPConsumerFunc which adds the solid entry points of a local snapshot to the tangle.
+func (t *Tangle) LSSEPConsumer() iota.LSSEPConsumerFunc {
+	return func(sep [iota.SolidEntryPointHashLength]byte) error {
+		t.AddSolidEntryPoint(sep)
+		return nil
+	}
+}
+
+// OnAttached registers the given callback to be called whenever a message is newly attached.
+// Callbacks are called before the missing and solid callbacks the attachment triggers.
+func (t *Tangle) OnAttached(f AttachedCallbackFunc) {
+	t.callbacksMu.Lock()
+	defer t.callbacksMu.Unlock()
+	t.attachedCallbacks = append(t.attachedCallbacks, f)
+}
+
+// OnSolid registers the given callback to be called whenever a message becomes solid.
+// The messages an attachment solidifies are passed in the order in which they became solid, but callbacks triggered
+// by concurrent attachments may interleave, so a message can be passed before its parents. Callbacks are never
+// called while the tangle is locked.
+func (t *Tangle) OnSolid(f SolidCallbackFunc) {
+	t.callbacksMu.Lock()
+	defer t.callbacksMu.Unlock()
+	t.solidCallbacks = append(t.solidCallbacks, f)
+}
+
+// OnMissing registers the given callback to be called whenever a message references a parent which is missing.
+func (t *Tangle) OnMissing(f MissingCallbackFunc) {
+	t.callbacksMu.Lock()
+	defer t.callbacksMu.Unlock()
+	t.missingCallbacks = append(t.missingCallbacks, f)
+}
+
+// AddSolidEntryPoint adds the given solid entry point. Messages waiting on it might become solid.
+func (t *Tangle) AddSolidEntryPoint(id iota.MessageID) {
+	t.mu.Lock()
+	t.solidEntryPoints[id] = struct{}{}
+	delete(t.missing, id)
+	solidified := t.solidifyFutureCone(id)
+	t.mu.Unlock()
+	t.fireSolid(solidified)
+}
+
+// IsSolidEntryPoint tells whether the given ID is a solid entry point.
+func (t *Tangle) IsSolidEntryPoint(id iota.MessageID) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	_, has := t.solidEntryPoints[id]
+	return has
+}
+
+// Attach adds the given message to the tangle and returns its ID and whether it was newly added.
+// Attaching a message which is already in the tangle is a no-op.
+func (t *Tangle) Attach(msg *iota.Message) (iota.MessageID, bool, error) {
+	id, err := msg.ID()
+	if err != nil {
+		return id, false, err
+	}
+
+	t.mu.Lock()
+	if _, has := t.messages[id]; has {
+		t.mu.Unlock()
+		return id, false, nil
+	}
+
+	e := &entry{msg: msg, meta: &iota.MessageMetadata{}}
+	t.messages[id] = e
+	delete(t.missing, id)
+
+	var missing []iota.MessageID
+	for _, parent := range parentsOf(msg) {
+		set, has := t.approvers[parent]
+		if !has {
+			set = make(map[iota.MessageID]struct{})
+			t.approvers[parent] = set
+		}
+		set[id] = struct{}{}
+
+		if t.isKnown(parent) {
+			continue
+		}
+		if _, isMissing := t.missing[parent]; !isMissing {
+			t.missing[parent] = struct{}{}
+			missing = append(missing, parent)
+		}
+	}
+
+	var solidified []iota.MessageID
+	if t.parentsSolid(msg) {
+		e.meta.SetSolid()
+		solidified = append([]iota.MessageID{id}, t.solidifyFutureCone(id)...)
+	}
+	t.mu.Unlock()
+
+	t.fireAttached(id, msg)
+	t.fireMissing(missing)
+	t.fireSolid(solidified)
+	return id, true, nil
+}
+
+// Message returns the message with the given ID.
+func (t *Tangle) Message(id iota.MessageID) (*iota.Message, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	e, has := t.messages[id]
+	if !has {
+		return nil, false
+	}
+	return e.msg, true
+}
+
+// Contains tells whether the message with the given ID is in the tangle.
+func (t *Tangle) Contains(id iota.MessageID) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	_, has := t.messages[id]
+	return has
+}
+
+// IsSolid tells whether the message with the given ID is solid. Solid entry points are solid.
+func (t *Tangle) IsSolid(id iota.MessageID) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.isSolid(id)
+}
+
+// Metadata returns the metadata of the message with the given ID.
+func (t *Tangle) Metadata(id iota.MessageID) (*iota.MessageMetadata, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	e, has := t.messages[id]
+	if !has {
+		return nil, false
+	}
+	return e.meta, true
+}
+
+// MarkReferenced marks the given message as referenced by the milestone with the given index
+// together with the ledger inclusion state and conflict reason of its transaction.
+func (t *Tangle) MarkReferenced(id iota.MessageID, milestoneIndex uint64, state iota.LedgerInclusionState, conflict iota.ConflictReason) error {
+	meta, has := t.Metadata(id)
+	if !has {
+		return fmt.Errorf("%w: %x", ErrMessageNotFound, id)
+	}
+	meta.SetReferenced(milestoneIndex, state, conflict)
+	return nil
+}
+
+// ReferencedBy returns the index of the milestone which referenced the given message
+// and whether it was referenced at all.
+func (t *Tangle) ReferencedBy(id iota.MessageID) (uint64, bool) {
+	meta, has := t.Metadata(id)
+	if !has {
+		return 0, false
+	}
+	return meta.ReferencedBy()
+}
+
+// Approvers returns the IDs of the messages which reference the given message as a parent.
+func (t *Tangle) Approvers(id iota.MessageID) []iota.MessageID {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	approvers := make([]iota.MessageID, 0, len(t.approvers[id]))
+	for approver := range t.approvers[id] {
+		approvers = append(approvers, approver)
+	}
+	return approvers
+}
+
+// Missing returns the IDs of the messages which are referenced as parents but not in the tangle.
+func (t *Tangle) Missing() []iota.MessageID {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	missing := make([]iota.MessageID, 0, len(t.missing))
+	for id := range t.missing {
+		missing = append(missing, id)
+	}
+	return missing
+}
+
+// IsMissing tells whether the message with the given ID is referenced but not in the tangle.
+func (t *Tangle) IsMissing(id iota.MessageID) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	_, has := t.missing[id]
+	return has
+}
+
+// Size returns the amount of messages in the tangle.
+func (t *Tangle) Size() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.messages)
+}
+
+// isKnown tells whether the given ID is a message in the tangle or a solid entry point. The caller must hold the lock.
+func (t *Tangle) isKnown(id iota.MessageID) bool {
+	if _, has := t.solidEntryPoints[id]; has {
+		return true
+	}
+	_, has := t.messages[id]
+	return has
+}
+
+// isSolid tells whether the given ID is a solid message or solid entry point. The caller must hold the lock.
+func (t *Tangle) isSolid(id iota.MessageID) bool {
+	if _, has := t.solidEntryPoints[id]; has {
+		return true
+	}
+	e, has := t.messages[id]
+	return has && e.meta.IsSolid()
+}
+
+func (t *Tangle) parentsSolid(msg *iota.Message) bool {
+	return t.isSolid(msg.Parent1) && t.isSolid(msg.Parent2)
+}
+
+// solidifyFutureCone marks the approvers of the given solid message as solid if their parents are solid,
+// walking the future cone breadth-first without recursion. It returns the IDs of the newly solid messages.
+// The caller must hold the lock.
+func (t *Tangle) solidifyFutureCone(id iota.MessageID) []iota.MessageID {
+	var solidified []iota.MessageID
+	queue := []iota.MessageID{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for approver := range t.approvers[current] {
+			e, has := t.messages[approver]
+			if !has || e.meta.IsSolid() || !t.parentsSolid(e.msg) {
+				continue
+			}
+			e.meta.SetSolid()
+			solidified = append(solidified, approver)
+			queue = append(queue, approver)
+		}
+	}
+	return solidified
+}
+
+func (t *Tangle) fireAttached(id iota.MessageID, msg *iota.Message) {
+	t.callbacksMu.RLock()
+	callbacks := t.attachedCallbacks
+	t.callbacksMu.RUnlock()
+	for _, f := range callbacks {
+		f(id, msg)
+	}
+}
+
+func (t *Tangle) fireSolid(ids []iota.MessageID) {
+	if len(ids) == 0 {
+		return
+	}
+	t.callbacksMu.RLock()
+	callbacks := t.solidCallbacks
+	t.callbacksMu.RUnlock()
+	for _, id := range ids {
+		msg, _ := t.Message(id)
+		for _, f := range callbacks {
+			f(id, msg)
+		}
+	}
+}
+
+func (t *Tangle) fireMissing(ids []iota.MessageID) {
+	if len(ids) == 0 {
+		return
+	}
+	t.callbacksMu.RLock()
+	callbacks := t.missingCallbacks
+	t.callbacksMu.RUnlock()
+	for _, id := range ids {
+		for _, f := range callbacks {
+			f(id)
+		}
+	}
+}
+
+// parentsOf returns the distinct parents of the given message.
+func parentsOf(msg *iota.Message) []iota.MessageID {
+	if msg.Parent1 == msg.Parent2 {
+		return []iota.MessageID{msg.Parent1}
+	}
+	return []iota.MessageID{msg.Parent1, msg.Parent2}
+}
@@ -0,0 +1,174 @@
+package tangle_test
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/luca-moser/iota/tangle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var genesis = iota.MessageID{}
+
+func newMessage(parent1, parent2 iota.MessageID) *iota.Message {
+	return &iota.Message{Parent1: parent1, Parent2: parent2, Nonce: rand.Uint64()}
+}
+
+func mustID(t testing.TB, msg *iota.Message) iota.MessageID {
+	id, err := msg.ID()
+	require.NoError(t, err)
+	return id
+}
+
+// chain creates count messages where every message references the previous one and a random earlier one.
+func chain(t testing.TB, count int) ([]*iota.Message, []iota.MessageID) {
+	msgs := make([]*iota.Message, count)
+	ids := make([]iota.MessageID, count)
+	prev := genesis
+	for i := 0; i < count; i++ {
+		other := genesis
+		if i > 0 {
+			other = ids[rand.Intn(i)]
+		}
+		msgs[i] = newMessage(prev, other)
+		ids[i] = mustID(t, msgs[i])
+		prev = ids[i]
+	}
+	return msgs, ids
+}
+
+func TestTangle_AttachAndSolidify(t *testing.T) {
+	tngl := tangle.New(genesis)
+
+	var solidOrder []iota.MessageID
+	tngl.OnSolid(func(id iota.MessageID, msg *iota.Message) {
+		assert.NotNil(t, msg)
+		solidOrder = append(solidOrder, id)
+	})
+	var missing []iota.MessageID
+	tngl.OnMissing(func(id iota.MessageID) {
+		missing = append(missing, id)
+	})
+
+	a := newMessage(genesis, genesis)
+	aID := mustID(t, a)
+	b := newMessage(aID, genesis)
+	bID := mustID(t, b)
+	c := newMessage(bID, aID)
+	cID := mustID(t, c)
+
+	// attach in reverse order, c and b must wait for a
+	_, added, err := tngl.Attach(c)
+	require.NoError(t, err)
+	assert.True(t, added)
+	assert.False(t, tngl.IsSolid(cID))
+	assert.ElementsMatch(t, []iota.MessageID{bID, aID}, missing)
+
+	_, _, err = tngl.Attach(b)
+	require.NoError(t, err)
+	assert.False(t, tngl.IsSolid(bID))
+	assert.True(t, tngl.IsMissing(aID))
+	assert.False(t, tngl.IsMissing(bID))
+
+	id, added, err := tngl.Attach(a)
+	require.NoError(t, err)
+	assert.True(t, added)
+	assert.Equal(t, aID, id)
+
+	assert.True(t, tngl.IsSolid(aID))
+	assert.True(t, tngl.IsSolid(bID))
+	assert.True(t, tngl.IsSolid(cID))
+	assert.Equal(t, []iota.MessageID{aID, bID, cID}, solidOrder)
+	assert.Empty(t, tngl.Missing())
+	assert.ElementsMatch(t, []iota.MessageID{bID, cID}, tngl.Approvers(aID))
+	assert.Equal(t, 3, tngl.Size())
+
+	// attaching again is a no-op
+	_, added, err = tngl.Attach(a)
+	require.NoError(t, err)
+	assert.False(t, added)
+	assert.Len(t, solidOrder, 3)
+}
+
+func TestTangle_AddSolidEntryPoint(t *testing.T) {
+	tngl := tangle.New()
+	sep := iota.MessageID{1}
+	msg := newMessage(sep, sep)
+	id, _, err := tngl.Attach(msg)
+	require.NoError(t, err)
+	assert.False(t, tngl.IsSolid(id))
+	assert.True(t, tngl.IsMissing(sep))
+
+	require.NoError(t, tngl.LSSEPConsumer()(sep))
+	assert.True(t, tngl.IsSolidEntryPoint(sep))
+	assert.True(t, tngl.IsSolid(id))
+	assert.False(t, tngl.IsMissing(sep))
+}
+
+func TestTangle_MarkReferenced(t *testing.T) {
+	tngl := tangle.New(genesis)
+	id, _, err := tngl.Attach(newMessage(genesis, genesis))
+	require.NoError(t, err)
+
+	_, referenced := tngl.ReferencedBy(id)
+	assert.False(t, referenced)
+
+	require.NoError(t, tngl.MarkReferenced(id, 5, iota.LedgerInclusionConflicting, iota.ConflictInputUTXONotFound))
+	index, referenced := tngl.ReferencedBy(id)
+	assert.True(t, referenced)
+	assert.EqualValues(t, 5, index)
+
+	meta, has := tngl.Metadata(id)
+	require.True(t, has)
+	assert.True(t, meta.IsSolid())
+	assert.Equal(t, iota.LedgerInclusionConflicting, meta.LedgerInclusionState())
+	assert.Equal(t, iota.ConflictInputUTXONotFound, meta.ConflictReason())
+
+	// a later milestone doesn't overwrite the state
+	require.NoError(t, tngl.MarkReferenced(id, 6, iota.LedgerInclusionNoTransaction, iota.ConflictNone))
+	index, _ = tngl.ReferencedBy(id)
+	assert.EqualValues(t, 5, index)
+
+	err = tngl.MarkReferenced(iota.MessageID{9}, 5, iota.LedgerInclusionNoTransaction, iota.ConflictNone)
+	assert.True(t, errors.Is(err, tangle.ErrMessageNotFound))
+}
+
+func TestTangle_ConcurrentAttach(t *testing.T) {
+	const count = 2000
+	msgs, ids := chain(t, count)
+	rand.Shuffle(len(msgs), func(i, j int) { msgs[i], msgs[j] = msgs[j], msgs[i] })
+
+	tngl := tangle.New(genesis)
+	var solidMu sync.Mutex
+	solid := map[iota.MessageID]int{}
+	tngl.OnSolid(func(id iota.MessageID, _ *iota.Message) {
+		solidMu.Lock()
+		defer solidMu.Unlock()
+		solid[id]++
+	})
+
+	var wg sync.WaitGroup
+	const workers = 8
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < count; i += workers {
+				_, _, err := tngl.Attach(msgs[i])
+				assert.NoError(t, err)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	assert.Equal(t, count, tngl.Size())
+	assert.Len(t, solid, count)
+	for _, id := range ids {
+		assert.True(t, tngl.IsSolid(id))
+		assert.Equal(t, 1, solid[id], "solid callback must fire exactly once")
+	}
+}
@@ -0,0 +1,163 @@
+package tangle
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/luca-moser/iota"
+)
+
+const (
+	// The default amount of times a tip may be selected before it is removed from the tip pool.
+	DefaultMaxTipSelections = 3
+	// The default age after which a tip is removed from the tip pool.
+	DefaultMaxTipAge = 15 * time.Second
+)
+
+var (
+	ErrNoTipsAvailable = errors.New("no tips available")
+)
+
+// tip is a message in the tip pool.
+type tip struct {
+	added    time.Time
+	selected int
+}
+
+// TipPool tracks the non-lazy tips of a Tangle, which are solid messages without approvers
+// which haven't exceeded the max age and max amount of selections yet.
+// It is safe for concurrent use.
+type TipPool struct {
+	mu            sync.Mutex
+	tangle        *Tangle
+	tips          map[iota.MessageID]*tip
+	ids           []iota.MessageID
+	maxSelections int
+	maxAge        time.Duration
+	rng           *rand.Rand
+	now           func() time.Time
+}
+
+// NewTipPool creates a new TipPool which is fed by the given Tangle: solid messages without approvers
+// are added as tips and tips are removed as soon as an approver is attached.
+// A tip is removed after it was selected maxSelections times or once it is older than maxAge.
+// Zero values for maxSelections and maxAge fall back to DefaultMaxTipSelections and DefaultMaxTipAge.
+func NewTipPool(tngl *Tangle, maxSelections int, maxAge time.Duration) *TipPool {
+	if maxSelections <= 0 {
+		maxSelections = DefaultMaxTipSelections
+	}
+	if maxAge <= 0 {
+		maxAge = DefaultMaxTipAge
+	}
+	pool := &TipPool{
+		tangle:        tngl,
+		tips:          make(map[iota.MessageID]*tip),
+		maxSelections: maxSelections,
+		maxAge:        maxAge,
+		rng:           rand.New(rand.NewSource(time.Now().UnixNano())),
+		now:           time.Now,
+	}
+
+	tngl.OnAttached(func(id iota.MessageID, msg *iota.Message) {
+		pool.RemoveTip(msg.Parent1)
+		pool.RemoveTip(msg.Parent2)
+	})
+	tngl.OnSolid(func(id iota.MessageID, msg *iota.Message) {
+		if len(tngl.Approvers(id)) == 0 {
+			pool.AddTip(id)
+		}
+	})
+
+	return pool
+}
+
+// AddTip adds the given message as a tip.
+func (p *TipPool) AddTip(id iota.MessageID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, has := p.tips[id]; has {
+		return
+	}
+	p.tips[id] = &tip{added: p.now()}
+	p.ids = append(p.ids, id)
+}
+
+// RemoveTip removes the given message from the tips.
+func (p *TipPool) RemoveTip(id iota.MessageID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.remove(id)
+}
+
+// TipCount returns the amount of tips in the pool, including ones which already exceeded their max age.
+func (p *TipPool) TipCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.tips)
+}
+
+// SelectTips selects two tips uniformly at random. If only one tip is available, it is returned twice.
+func (p *TipPool) SelectTips() (iota.MessageID, iota.MessageID, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	first, err := p.selectTip()
+	if err != nil {
+		return iota.MessageID{}, iota.MessageID{}, err
+	}
+
+	// try to select a different second tip without counting the first one twice
+	firstTip, stillTip := p.tips[first]
+	if stillTip {
+		p.remove(first)
+	}
+	second, err := p.selectTip()
+	if stillTip {
+		p.tips[first] = firstTip
+		p.ids = append(p.ids, first)
+	}
+	if err != nil {
+		second = first
+	}
+
+	return first, second, nil
+}
+
+// selectTip selects a random tip, dropping expired tips and tips which gained approvers on the way.
+// The caller must hold the lock.
+func (p *TipPool) selectTip() (iota.MessageID, error) {
+	now := p.now()
+	for len(p.ids) > 0 {
+		id := p.ids[p.rng.Intn(len(p.ids))]
+		t := p.tips[id]
+
+		if now.Sub(t.added) > p.maxAge || len(p.tangle.Approvers(id)) > 0 {
+			p.remove(id)
+			continue
+		}
+
+		t.selected++
+		if t.selected >= p.maxSelections {
+			p.remove(id)
+		}
+		return id, nil
+	}
+	return iota.MessageID{}, ErrNoTipsAvailable
+}
+
+// remove removes the given tip. The caller must hold the lock.
+func (p *TipPool) remove(id iota.MessageID) {
+	if _, has := p.tips[id]; !has {
+		return
+	}
+	delete(p.tips, id)
+	for i, tipID := range p.ids {
+		if tipID == id {
+			p.ids[i] = p.ids[len(p.ids)-1]
+			p.ids = p.ids[:len(p.ids)-1]
+			return
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package tangle_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/luca-moser/iota"
+	"github.com/luca-moser/iota/tangle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTipPool_TipsFollowTangle(t *testing.T) {
+	tngl := tangle.New(genesis)
+	pool := tangle.NewTipPool(tngl, 100, time.Hour)
+
+	_, _, err := pool.SelectTips()
+	assert.True(t, errors.Is(err, tangle.ErrNoTipsAvailable))
+
+	a := newMessage(genesis, genesis)
+	aID, _, err := tngl.Attach(a)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pool.TipCount())
+
+	// only one tip, returned twice
+	tip1, tip2, err := pool.SelectTips()
+	require.NoError(t, err)
+	assert.Equal(t, aID, tip1)
+	assert.Equal(t, aID, tip2)
+
+	b := newMessage(aID, genesis)
+	bID, _, err := tngl.Attach(b)
+	require.NoError(t, err)
+	c := newMessage(aID, genesis)
+	cID, _, err := tngl.Attach(c)
+	require.NoError(t, err)
+	assert.Equal(t, 2, pool.TipCount())
+
+	tip1, tip2, err = pool.SelectTips()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []iota.MessageID{bID, cID}, []iota.MessageID{tip1, tip2})
+
+	// a non-solid approver removes the tip as well
+	d := newMessage(bID, iota.MessageID{42})
+	_, _, err = tngl.Attach(d)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pool.TipCount())
+}
+
+func TestTipPool_MaxSelections(t *testing.T) {
+	tngl := tangle.New(genesis)
+	pool := tangle.NewTipPool(tngl, 2, time.Hour)
+
+	_, _, err := tngl.Attach(newMessage(genesis, genesis))
+	require.NoError(t, err)
+
+	// a tip returned as both tips counts as one selection
+	_, _, err = pool.SelectTips()
+	require.NoError(t, err)
+	assert.Equal(t, 1, pool.TipCount())
+
+	_, _, err = pool.SelectTips()
+	require.NoError(t, err)
+	assert.Zero(t, pool.TipCount())
+}
+
+func TestTipPool_MaxAge(t *testing.T) {
+	tngl := tangle.New(genesis)
+	pool := tangle.NewTipPool(tngl, 100, 10*time.Millisecond)
+
+	_, _, err := tngl.Attach(newMessage(genesis, genesis))
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+
+	_, _, err = pool.SelectTips()
+	assert.True(t, errors.Is(err, tangle.ErrNoTipsAvailable))
+	assert.Zero(t, pool.TipCount())
+}
+
+func TestTipPool_Uniform(t *testing.T) {
+	tngl := tangle.New(genesis)
+	pool := tangle.NewTipPool(tngl, 1_000_000, time.Hour)
+
+	const tipsCount = 4
+	for i := 0; i < tipsCount; i++ {
+		_, _, err := tngl.Attach(newMessage(genesis, genesis))
+		require.NoError(t, err)
+	}
+
+	const rounds = 4000
+	counts := map[iota.MessageID]int{}
+	for i := 0; i < rounds; i++ {
+		tip1, tip2, err := pool.SelectTips()
+		require.NoError(t, err)
+		assert.NotEqual(t, tip1, tip2)
+		counts[tip1]++
+		counts[tip2]++
+	}
+	require.Len(t, counts, tipsCount)
+	expected := 2 * rounds / tipsCount
+	for _, count := range counts {
+		assert.InDelta(t, expected, count, float64(expected)/5)
+	}
+}
@@ -0,0 +1,170 @@
+package tangle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/luca-moser/iota"
+)
+
+var (
+	ErrMessageNotFound = errors.New("message not found in the tangle")
+)
+
+// ConditionFunc decides whether the given message is traversed. If it returns false, the message is neither
+// consumed nor are its parents (past cone) or approvers (future cone) traversed through it.
+// A returned error aborts the traversal.
+type ConditionFunc func(id iota.MessageID, msg *iota.Message) (bool, error)
+
+// ConsumerFunc consumes a traversed message. A returned error aborts the traversal.
+type ConsumerFunc func(id iota.MessageID, msg *iota.Message) error
+
+// MissingParentFunc is called for a message in the past cone which is neither in the tangle nor a solid entry point.
+// A returned error aborts the traversal.
+type MissingParentFunc func(id iota.MessageID) error
+
+// SolidEntryPointFunc is called for every solid entry point the past cone traversal reaches.
+type SolidEntryPointFunc func(id iota.MessageID)
+
+// TraverseAll is a ConditionFunc which traverses every message.
+func TraverseAll(iota.MessageID, *iota.Message) (bool, error) {
+	return true, nil
+}
+
+// WalkPastCone walks the past cone of the given start message, including the start message itself,
+// in the deterministic order white-flag confirmation requires: depth-first in post-order, traversing Parent1
+// before Parent2, so that every message is consumed after both of its parents and exactly once.
+// The walk stops at messages for which condition returns false, for example because they were already
+// confirmed by a previous milestone, and at solid entry points, which are passed to onSolidEntryPoint if given.
+// Messages which are missing are passed to onMissing, if it is nil, the walk aborts with ErrMessageNotFound.
+// The walk uses an explicit stack instead of recursion, so it doesn't overflow the stack on deep tangles.
+func (t *Tangle) WalkPastCone(start iota.MessageID, condition ConditionFunc, consumer ConsumerFunc,
+	onMissing MissingParentFunc, onSolidEntryPoint SolidEntryPointFunc) error {
+
+	// messages which are done: consumed or not to be traversed
+	processed := make(map[iota.MessageID]struct{})
+	// messages which passed the condition and whose parents are being traversed
+	traversing := make(map[iota.MessageID]*iota.Message)
+
+	stack := []iota.MessageID{start}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+
+		if _, done := processed[current]; done {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		msg, isTraversing := traversing[current]
+		if !isTraversing {
+			if t.IsSolidEntryPoint(current) {
+				if onSolidEntryPoint != nil {
+					onSolidEntryPoint(current)
+				}
+				processed[current] = struct{}{}
+				stack = stack[:len(stack)-1]
+				continue
+			}
+
+			var has bool
+			msg, has = t.Message(current)
+			if !has {
+				if onMissing == nil {
+					return fmt.Errorf("%w: %x", ErrMessageNotFound, current)
+				}
+				if err := onMissing(current); err != nil {
+					return err
+				}
+				processed[current] = struct{}{}
+				stack = stack[:len(stack)-1]
+				continue
+			}
+
+			traverse, err := condition(current, msg)
+			if err != nil {
+				return err
+			}
+			if !traverse {
+				processed[current] = struct{}{}
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			traversing[current] = msg
+		}
+
+		// descend into the first parent which isn't done yet, Parent1 before Parent2
+		descended := false
+		for _, parent := range [2]iota.MessageID{msg.Parent1, msg.Parent2} {
+			if _, done := processed[parent]; done {
+				continue
+			}
+			if _, inProgress := traversing[parent]; inProgress {
+				// only possible if the tangle contains a cycle
+				return fmt.Errorf("cycle detected at message %x", parent)
+			}
+			stack = append(stack, parent)
+			descended = true
+			break
+		}
+		if descended {
+			continue
+		}
+
+		// both parents are done
+		if err := consumer(current, msg); err != nil {
+			return err
+		}
+		delete(traversing, current)
+		processed[current] = struct{}{}
+		stack = stack[:len(stack)-1]
+	}
+
+	return nil
+}
+
+// WalkFutureCone walks the future cone of the given start message, including the start message itself,
+// breadth-first through the approvers of each message. Every message is consumed exactly once.
+// The walk doesn't continue through messages for which condition returns false.
+func (t *Tangle) WalkFutureCone(start iota.MessageID, condition ConditionFunc, consumer ConsumerFunc) error {
+	seen := map[iota.MessageID]struct{}{start: {}}
+	queue := []iota.MessageID{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		msg, has := t.Message(current)
+		if !has {
+			// the start message might be a solid entry point which isn't part of the tangle
+			if current != start {
+				return fmt.Errorf("%w: %x", ErrMessageNotFound, current)
+			}
+		} else {
+			traverse, err := condition(current, msg)
+			if err != nil {
+				return err
+			}
+			if !traverse {
+				continue
+			}
+			if err := consumer(current, msg); err != nil {
+				return err
+			}
+		}
+
+		// approvers are visited in the lexical order of their IDs to keep the walk deterministic
+		approvers := t.Approvers(current)
+		sort.Slice(approvers, func(i, j int) bool {
+			return bytes.Compare(approvers[i][:], approvers[j][:]) < 0
+		})
+		for _, approver := range approvers {
+			if _, has := seen[approver]; has {
+				continue
+			}
+			seen[approver] = struct{}{}
+			queue = append(queue, approver)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package tangle_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/luca-moser/iota/tangle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// diamond builds the following tangle, where arrows point to parents (Parent1 left, Parent2 right):
+//	  d
+//	 / \
+//	b   c
+//	 \ / \
+//	  a   sep
+func diamond(t *testing.T) (*tangle.Tangle, iota.MessageID, map[string]iota.MessageID) {
+	sep := iota.MessageID{1}
+	tngl := tangle.New(genesis, sep)
+	ids := map[string]iota.MessageID{}
+	attach := func(name string, parent1, parent2 iota.MessageID) {
+		id, _, err := tngl.Attach(newMessage(parent1, parent2))
+		require.NoError(t, err)
+		ids[name] = id
+	}
+	attach("a", genesis, genesis)
+	attach("b", ids["a"], ids["a"])
+	attach("c", ids["a"], sep)
+	attach("d", ids["b"], ids["c"])
+	return tngl, sep, ids
+}
+
+func collect(names map[string]iota.MessageID, order *[]string) tangle.ConsumerFunc {
+	return func(id iota.MessageID, _ *iota.Message) error {
+		for name, nameID := range names {
+			if nameID == id {
+				*order = append(*order, name)
+			}
+		}
+		return nil
+	}
+}
+
+func TestTangle_WalkPastCone(t *testing.T) {
+	tngl, sep, ids := diamond(t)
+
+	var order []string
+	var seps []iota.MessageID
+	err := tngl.WalkPastCone(ids["d"], tangle.TraverseAll, collect(ids, &order), nil, func(id iota.MessageID) {
+		seps = append(seps, id)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, order)
+	assert.ElementsMatch(t, []iota.MessageID{genesis, sep}, seps)
+
+	// stop at already "confirmed" messages
+	order = nil
+	err = tngl.WalkPastCone(ids["d"], func(id iota.MessageID, _ *iota.Message) (bool, error) {
+		return id != ids["b"] && id != ids["a"], nil
+	}, collect(ids, &order), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "d"}, order)
+}
+
+func TestTangle_WalkPastConeMissing(t *testing.T) {
+	tngl := tangle.New(genesis)
+	missingID := iota.MessageID{9}
+	id, _, err := tngl.Attach(newMessage(genesis, missingID))
+	require.NoError(t, err)
+
+	err = tngl.WalkPastCone(id, tangle.TraverseAll, func(iota.MessageID, *iota.Message) error { return nil }, nil, nil)
+	assert.True(t, errors.Is(err, tangle.ErrMessageNotFound))
+
+	var missing []iota.MessageID
+	err = tngl.WalkPastCone(id, tangle.TraverseAll, func(iota.MessageID, *iota.Message) error { return nil }, func(id iota.MessageID) error {
+		missing = append(missing, id)
+		return nil
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []iota.MessageID{missingID}, missing)
+}
+
+func TestTangle_WalkPastConeDeep(t *testing.T) {
+	const depth = 50_000
+	tngl := tangle.New(genesis)
+	prev := genesis
+	for i := 0; i < depth; i++ {
+		id, _, err := tngl.Attach(newMessage(prev, prev))
+		require.NoError(t, err)
+		prev = id
+	}
+
+	count := 0
+	err := tngl.WalkPastCone(prev, tangle.TraverseAll, func(iota.MessageID, *iota.Message) error {
+		count++
+		return nil
+	}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, depth, count)
+}
+
+func TestTangle_WalkFutureCone(t *testing.T) {
+	tngl, _, ids := diamond(t)
+
+	var order []string
+	require.NoError(t, tngl.WalkFutureCone(ids["a"], tangle.TraverseAll, collect(ids, &order)))
+	require.Len(t, order, 4)
+	assert.Equal(t, "a", order[0])
+	assert.ElementsMatch(t, []string{"b", "c"}, order[1:3])
+	assert.Equal(t, "d", order[3])
+
+	// don't walk through c
+	order = nil
+	require.NoError(t, tngl.WalkFutureCone(ids["a"], func(id iota.MessageID, _ *iota.Message) (bool, error) {
+		return id != ids["c"], nil
+	}, collect(ids, &order)))
+	assert.Equal(t, []string{"a", "b", "d"}, order)
+
+	// walk from a solid entry point
+	order = nil
+	require.NoError(t, tngl.WalkFutureCone(genesis, tangle.TraverseAll, collect(ids, &order)))
+	assert.Len(t, order, 4)
+}
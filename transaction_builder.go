@@ -0,0 +1,243 @@
+package iota
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrTransactionBuilderNoInputs            = errors.New("transaction builder has no inputs")
+	ErrTransactionBuilderInsufficientBalance = errors.New("inputs of the transaction builder don't cover the outputs")
+	ErrTransactionBuilderInputsExceedSupply  = errors.New("inputs of the transaction builder exceed the total supply")
+	ErrTransactionBuilderNoRemainderAddress  = errors.New("transaction builder needs a remainder address as the inputs exceed the outputs")
+	ErrTransactionBuilderInvalidPrivateKey   = errors.New("invalid Ed25519 private key")
+	ErrTransactionBuilderNoInputAddress      = errors.New("transaction builder input has no address")
+	ErrTransactionBuilderInvalidSignature    = errors.New("address signer returned an invalid signature")
+)
+
+// ToBeSignedUTXOInput defines a UTXO input which is consumed by a transaction and signed with the key owning it.
+type ToBeSignedUTXOInput struct {
+	// The input referencing the unspent output.
+	Input *UTXOInput
+	// The amount held by the referenced output.
+	Amount uint64
+	// The private key owning the referenced output, used by Build.
+	PrivateKey ed25519.PrivateKey
+	// The address of the referenced output, used by BuildWithSigner.
+	Address Serializable
+}
+
+// TransactionBuilder is used to easily build up a SignedTransactionPayload.
+type TransactionBuilder struct {
+	inputs        []*ToBeSignedUTXOInput
+	outputs       []*SigLockedSingleDeposit
+	remainderAddr Serializable
+	payload       *IndexationPayload
+}
+
+// NewTransactionBuilder creates a new TransactionBuilder.
+func NewTransactionBuilder() *TransactionBuilder {
+	return &TransactionBuilder{}
+}
+
+// AddInput adds the given input to the builder.
+func (b *TransactionBuilder) AddInput(input *ToBeSignedUTXOInput) *TransactionBuilder {
+	b.inputs = append(b.inputs, input)
+	return b
+}
+
+// AddOutput adds the given output to the builder.
+func (b *TransactionBuilder) AddOutput(output *SigLockedSingleDeposit) *TransactionBuilder {
+	b.outputs = append(b.outputs, output)
+	return b
+}
+
+// SetRemainderAddress sets the address onto which the amount of the inputs which isn't
+// consumed by the outputs is deposited.
+func (b *TransactionBuilder) SetRemainderAddress(addr Serializable) *TransactionBuilder {
+	b.remainderAddr = addr
+	return b
+}
+
+// AddIndexationPayload sets the indexation payload to embed within the transaction.
+func (b *TransactionBuilder) AddIndexationPayload(payload *IndexationPayload) *TransactionBuilder {
+	b.payload = payload
+	return b
+}
+
+// Build balances the inputs against the outputs, creates the remainder output if needed,
+// sorts the inputs and outputs in their lexical order and signs the transaction with the private keys of the inputs.
+// Inputs which are owned by the same private key are unlocked by reference unlock blocks.
+func (b *TransactionBuilder) Build() (*SignedTransactionPayload, error) {
+	if len(b.inputs) == 0 {
+		return nil, ErrTransactionBuilderNoInputs
+	}
+
+	keyring, _ := NewInMemoryKeyring()
+	addrs := make([]Serializable, len(b.inputs))
+	for i, input := range b.inputs {
+		if len(input.PrivateKey) != ed25519.PrivateKeySize {
+			return nil, fmt.Errorf("%w: input %d", ErrTransactionBuilderInvalidPrivateKey, i)
+		}
+		if err := keyring.Add(input.PrivateKey); err != nil {
+			return nil, err
+		}
+		addr := AddressFromEd25519PubKey(input.PrivateKey.Public().(ed25519.PublicKey))
+		addrs[i] = &addr
+	}
+	return b.build(keyring, addrs)
+}
+
+// BuildWithSigner works like Build but lets the given signer sign the inputs by their addresses,
+// so that the private keys never have to be handed to the builder. The returned signatures are verified.
+// Inputs with the same address are unlocked by reference unlock blocks.
+func (b *TransactionBuilder) BuildWithSigner(signer AddressSigner) (*SignedTransactionPayload, error) {
+	if len(b.inputs) == 0 {
+		return nil, ErrTransactionBuilderNoInputs
+	}
+
+	addrs := make([]Serializable, len(b.inputs))
+	for i, input := range b.inputs {
+		if input.Address == nil {
+			return nil, fmt.Errorf("%w: input %d", ErrTransactionBuilderNoInputAddress, i)
+		}
+		addrs[i] = input.Address
+	}
+	return b.build(signer, addrs)
+}
+
+// build builds the transaction, signing the inputs with the given signer by the given addresses owning them.
+func (b *TransactionBuilder) build(signer AddressSigner, addrs []Serializable) (*SignedTransactionPayload, error) {
+	var inputsSum, outputsSum uint64
+	for i, input := range b.inputs {
+		// like the outputs, the inputs can't hold more than the total supply, which also rules out overflows
+		if input.Amount > TokenSupply || inputsSum+input.Amount > TokenSupply {
+			return nil, fmt.Errorf("%w: input %d", ErrTransactionBuilderInputsExceedSupply, i)
+		}
+		inputsSum += input.Amount
+	}
+
+	outputs := make([]*SigLockedSingleDeposit, len(b.outputs))
+	for i, output := range b.outputs {
+		// copy so that adding the remainder doesn't mutate the caller's output
+		outputs[i] = &SigLockedSingleDeposit{Address: output.Address, Amount: output.Amount}
+		outputsSum += output.Amount
+	}
+
+	switch {
+	case outputsSum > inputsSum:
+		return nil, fmt.Errorf("%w: inputs %d, outputs %d", ErrTransactionBuilderInsufficientBalance, inputsSum, outputsSum)
+	case outputsSum < inputsSum:
+		if b.remainderAddr == nil {
+			return nil, fmt.Errorf("%w: remainder of %d", ErrTransactionBuilderNoRemainderAddress, inputsSum-outputsSum)
+		}
+		remainder := inputsSum - outputsSum
+		if err := b.addRemainder(&outputs, remainder); err != nil {
+			return nil, err
+		}
+	}
+
+	unsignedTx := &UnsignedTransaction{}
+	if b.payload != nil {
+		unsignedTx.Payload = b.payload
+	}
+
+	// sort inputs by their serialized form and keep track of the keys unlocking them
+	type sortableInput struct {
+		data  []byte
+		input *ToBeSignedUTXOInput
+		addr  Serializable
+	}
+	sortedInputs := make([]sortableInput, len(b.inputs))
+	for i, input := range b.inputs {
+		inputData, err := input.Input.Serialize(DeSeriModePerformValidation)
+		if err != nil {
+			return nil, fmt.Errorf("unable to serialize input %d: %w", i, err)
+		}
+		sortedInputs[i] = sortableInput{data: inputData, input: input, addr: addrs[i]}
+	}
+	sort.Slice(sortedInputs, func(i, j int) bool {
+		return bytes.Compare(sortedInputs[i].data, sortedInputs[j].data) < 0
+	})
+	for _, sortedInput := range sortedInputs {
+		unsignedTx.Inputs = append(unsignedTx.Inputs, sortedInput.input.Input)
+	}
+
+	type sortableOutput struct {
+		data   []byte
+		output *SigLockedSingleDeposit
+	}
+	sortedOutputs := make([]sortableOutput, len(outputs))
+	for i, output := range outputs {
+		outputData, err := output.Serialize(DeSeriModePerformValidation)
+		if err != nil {
+			return nil, fmt.Errorf("unable to serialize output %d: %w", i, err)
+		}
+		sortedOutputs[i] = sortableOutput{data: outputData, output: output}
+	}
+	sort.Slice(sortedOutputs, func(i, j int) bool {
+		return bytes.Compare(sortedOutputs[i].data, sortedOutputs[j].data) < 0
+	})
+	for _, sortedOutput := range sortedOutputs {
+		unsignedTx.Outputs = append(unsignedTx.Outputs, sortedOutput.output)
+	}
+
+	if err := unsignedTx.SyntacticallyValid(); err != nil {
+		return nil, err
+	}
+
+	unsignedTxData, err := unsignedTx.Serialize(DeSeriModePerformValidation)
+	if err != nil {
+		return nil, err
+	}
+
+	// inputs owned by the same address are unlocked by referencing the first signature unlock block
+	sigBlockPos := map[string]int{}
+	unlockBlocks := make(Serializables, len(sortedInputs))
+	for i, sortedInput := range sortedInputs {
+		addrData, err := sortedInput.addr.Serialize(DeSeriModeNoValidation)
+		if err != nil {
+			return nil, fmt.Errorf("unable to serialize address of input %d: %w", i, err)
+		}
+		if pos, has := sigBlockPos[string(addrData)]; has {
+			unlockBlocks[i] = &ReferenceUnlockBlock{Reference: uint16(pos)}
+			continue
+		}
+
+		edSig, err := signer.Sign(sortedInput.addr, unsignedTxData)
+		if err != nil {
+			return nil, fmt.Errorf("unable to sign input %d: %w", i, err)
+		}
+		if checkSigner(sortedInput.addr, edSig) != nil || !ed25519.Verify(edSig.PublicKey[:], unsignedTxData, edSig.Signature[:]) {
+			return nil, fmt.Errorf("%w: input %d", ErrTransactionBuilderInvalidSignature, i)
+		}
+		unlockBlocks[i] = &SignatureUnlockBlock{Signature: edSig}
+		sigBlockPos[string(addrData)] = i
+	}
+
+	return &SignedTransactionPayload{Transaction: unsignedTx, UnlockBlocks: unlockBlocks}, nil
+}
+
+// addRemainder deposits the remainder onto the remainder address. If an output already deposits
+// onto the remainder address, the remainder is added to it, as outputs must deposit to unique addresses.
+func (b *TransactionBuilder) addRemainder(outputs *[]*SigLockedSingleDeposit, remainder uint64) error {
+	remainderAddrData, err := b.remainderAddr.Serialize(DeSeriModeNoValidation)
+	if err != nil {
+		return err
+	}
+	for _, output := range *outputs {
+		addrData, err := output.Address.Serialize(DeSeriModeNoValidation)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(addrData, remainderAddrData) {
+			output.Amount += remainder
+			return nil
+		}
+	}
+	*outputs = append(*outputs, &SigLockedSingleDeposit{Address: b.remainderAddr, Amount: remainder})
+	return nil
+}
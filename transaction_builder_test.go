@@ -0,0 +1,188 @@
+package iota_test
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func randEd25519PrivateKey() ed25519.PrivateKey {
+	seed := randEd25519Seed()
+	return ed25519.NewKeyFromSeed(seed[:])
+}
+
+func TestTransactionBuilder_Build(t *testing.T) {
+	prvKey1 := randEd25519PrivateKey()
+	prvKey2 := randEd25519PrivateKey()
+
+	type test struct {
+		name    string
+		builder *iota.TransactionBuilder
+		err     error
+	}
+	tests := []test{
+		func() test {
+			input1, _ := randUTXOInput()
+			input2, _ := randUTXOInput()
+			input3, _ := randUTXOInput()
+			outputAddr, _ := randEd25519Addr()
+			remainderAddr, _ := randEd25519Addr()
+
+			builder := iota.NewTransactionBuilder().
+				AddInput(&iota.ToBeSignedUTXOInput{Input: input1, Amount: 50, PrivateKey: prvKey1}).
+				AddInput(&iota.ToBeSignedUTXOInput{Input: input2, Amount: 50, PrivateKey: prvKey2}).
+				AddInput(&iota.ToBeSignedUTXOInput{Input: input3, Amount: 50, PrivateKey: prvKey1}).
+				AddOutput(&iota.SigLockedSingleDeposit{Address: outputAddr, Amount: 100}).
+				SetRemainderAddress(remainderAddr)
+			return test{"ok with remainder", builder, nil}
+		}(),
+		func() test {
+			input, _ := randUTXOInput()
+			outputAddr, _ := randEd25519Addr()
+			indexationPayload, _ := randIndexationPayload()
+
+			builder := iota.NewTransactionBuilder().
+				AddInput(&iota.ToBeSignedUTXOInput{Input: input, Amount: 100, PrivateKey: prvKey1}).
+				AddOutput(&iota.SigLockedSingleDeposit{Address: outputAddr, Amount: 100}).
+				AddIndexationPayload(indexationPayload)
+			return test{"ok with indexation payload", builder, nil}
+		}(),
+		func() test {
+			input, _ := randUTXOInput()
+			outputAddr, _ := randEd25519Addr()
+
+			builder := iota.NewTransactionBuilder().
+				AddInput(&iota.ToBeSignedUTXOInput{Input: input, Amount: 100, PrivateKey: prvKey1}).
+				AddOutput(&iota.SigLockedSingleDeposit{Address: outputAddr, Amount: 50}).
+				SetRemainderAddress(outputAddr)
+			return test{"ok remainder onto existing output", builder, nil}
+		}(),
+		func() test {
+			outputAddr, _ := randEd25519Addr()
+			builder := iota.NewTransactionBuilder().
+				AddOutput(&iota.SigLockedSingleDeposit{Address: outputAddr, Amount: 50})
+			return test{"err no inputs", builder, iota.ErrTransactionBuilderNoInputs}
+		}(),
+		func() test {
+			input, _ := randUTXOInput()
+			outputAddr, _ := randEd25519Addr()
+			builder := iota.NewTransactionBuilder().
+				AddInput(&iota.ToBeSignedUTXOInput{Input: input, Amount: 10, PrivateKey: prvKey1}).
+				AddOutput(&iota.SigLockedSingleDeposit{Address: outputAddr, Amount: 50})
+			return test{"err insufficient balance", builder, iota.ErrTransactionBuilderInsufficientBalance}
+		}(),
+		func() test {
+			// the input amounts wrap around to 50
+			input1, _ := randUTXOInput()
+			input2, _ := randUTXOInput()
+			outputAddr, _ := randEd25519Addr()
+			builder := iota.NewTransactionBuilder().
+				AddInput(&iota.ToBeSignedUTXOInput{Input: input1, Amount: math.MaxUint64, PrivateKey: prvKey1}).
+				AddInput(&iota.ToBeSignedUTXOInput{Input: input2, Amount: 51, PrivateKey: prvKey1}).
+				AddOutput(&iota.SigLockedSingleDeposit{Address: outputAddr, Amount: 50})
+			return test{"err overflowing inputs", builder, iota.ErrTransactionBuilderInputsExceedSupply}
+		}(),
+		func() test {
+			input1, _ := randUTXOInput()
+			input2, _ := randUTXOInput()
+			outputAddr, _ := randEd25519Addr()
+			builder := iota.NewTransactionBuilder().
+				AddInput(&iota.ToBeSignedUTXOInput{Input: input1, Amount: iota.TokenSupply, PrivateKey: prvKey1}).
+				AddInput(&iota.ToBeSignedUTXOInput{Input: input2, Amount: 1, PrivateKey: prvKey1}).
+				AddOutput(&iota.SigLockedSingleDeposit{Address: outputAddr, Amount: iota.TokenSupply + 1})
+			return test{"err inputs exceed total supply", builder, iota.ErrTransactionBuilderInputsExceedSupply}
+		}(),
+		func() test {
+			input, _ := randUTXOInput()
+			outputAddr, _ := randEd25519Addr()
+			builder := iota.NewTransactionBuilder().
+				AddInput(&iota.ToBeSignedUTXOInput{Input: input, Amount: 100, PrivateKey: prvKey1}).
+				AddOutput(&iota.SigLockedSingleDeposit{Address: outputAddr, Amount: 50})
+			return test{"err no remainder address", builder, iota.ErrTransactionBuilderNoRemainderAddress}
+		}(),
+		func() test {
+			input, _ := randUTXOInput()
+			outputAddr, _ := randEd25519Addr()
+			builder := iota.NewTransactionBuilder().
+				AddInput(&iota.ToBeSignedUTXOInput{Input: input, Amount: 100}).
+				AddOutput(&iota.SigLockedSingleDeposit{Address: outputAddr, Amount: 100})
+			return test{"err invalid private key", builder, iota.ErrTransactionBuilderInvalidPrivateKey}
+		}(),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := tt.builder.Build()
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err))
+				return
+			}
+			require.NoError(t, err)
+
+			// must survive a validating round trip
+			payloadData, err := payload.Serialize(iota.DeSeriModePerformValidation)
+			require.NoError(t, err)
+			deserialized := &iota.SignedTransactionPayload{}
+			_, err = deserialized.Deserialize(payloadData, iota.DeSeriModePerformValidation)
+			require.NoError(t, err)
+			assert.EqualValues(t, payload, deserialized)
+
+			unsignedTx := payload.Transaction.(*iota.UnsignedTransaction)
+			unsignedTxData, err := unsignedTx.Serialize(iota.DeSeriModePerformValidation)
+			require.NoError(t, err)
+
+			var outputsSum uint64
+			for _, output := range unsignedTx.Outputs {
+				outputsSum += output.(*iota.SigLockedSingleDeposit).Amount
+			}
+			for i, block := range payload.UnlockBlocks {
+				switch b := block.(type) {
+				case *iota.SignatureUnlockBlock:
+					edSig := b.Signature.(*iota.Ed25519Signature)
+					assert.True(t, ed25519.Verify(edSig.PublicKey[:], unsignedTxData, edSig.Signature[:]), "signature %d invalid", i)
+				case *iota.ReferenceUnlockBlock:
+					assert.Less(t, int(b.Reference), i)
+				}
+			}
+			assert.Len(t, payload.UnlockBlocks, len(unsignedTx.Inputs))
+			assert.NotZero(t, outputsSum)
+		})
+	}
+}
+
+func TestTransactionBuilder_BuildRemainder(t *testing.T) {
+	input1, _ := randUTXOInput()
+	input2, _ := randUTXOInput()
+	outputAddr, _ := randEd25519Addr()
+	remainderAddr, _ := randEd25519Addr()
+	prvKey := randEd25519PrivateKey()
+
+	payload, err := iota.NewTransactionBuilder().
+		AddInput(&iota.ToBeSignedUTXOInput{Input: input1, Amount: 300, PrivateKey: prvKey}).
+		AddInput(&iota.ToBeSignedUTXOInput{Input: input2, Amount: 700, PrivateKey: prvKey}).
+		AddOutput(&iota.SigLockedSingleDeposit{Address: outputAddr, Amount: 600}).
+		SetRemainderAddress(remainderAddr).
+		Build()
+	require.NoError(t, err)
+
+	unsignedTx := payload.Transaction.(*iota.UnsignedTransaction)
+	require.Len(t, unsignedTx.Outputs, 2)
+
+	deposits := map[iota.Ed25519Address]uint64{}
+	for _, output := range unsignedTx.Outputs {
+		dep := output.(*iota.SigLockedSingleDeposit)
+		deposits[*dep.Address.(*iota.Ed25519Address)] = dep.Amount
+	}
+	assert.EqualValues(t, 600, deposits[*outputAddr])
+	assert.EqualValues(t, 400, deposits[*remainderAddr])
+
+	// both inputs are owned by the same key
+	require.Len(t, payload.UnlockBlocks, 2)
+	assert.IsType(t, &iota.SignatureUnlockBlock{}, payload.UnlockBlocks[0])
+	assert.Equal(t, &iota.ReferenceUnlockBlock{Reference: 0}, payload.UnlockBlocks[1])
+}
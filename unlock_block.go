@@ -2,6 +2,7 @@ package iota
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 )
@@ -76,6 +77,36 @@ func (s *SignatureUnlockBlock) Serialize(deSeriMode DeSerializationMode) ([]byte
 	return append([]byte{UnlockBlockSignature}, sigBytes...), nil
 }
 
+// jsonSignatureUnlockBlock defines the JSON form of a SignatureUnlockBlock.
+type jsonSignatureUnlockBlock struct {
+	Type      int             `json:"type"`
+	Signature json.RawMessage `json:"signature"`
+}
+
+func (s *SignatureUnlockBlock) MarshalJSON() ([]byte, error) {
+	sigJSON, err := json.Marshal(s.Signature)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&jsonSignatureUnlockBlock{Type: int(UnlockBlockSignature), Signature: sigJSON})
+}
+
+func (s *SignatureUnlockBlock) UnmarshalJSON(data []byte) error {
+	jBlock := &jsonSignatureUnlockBlock{}
+	if err := json.Unmarshal(data, jBlock); err != nil {
+		return err
+	}
+	if err := checkJSONType(jBlock.Type, uint32(UnlockBlockSignature)); err != nil {
+		return fmt.Errorf("unable to decode signature unlock block from JSON: %w", err)
+	}
+	sig, err := DeserializeObjectFromJSON(jBlock.Signature, SignatureSelector)
+	if err != nil {
+		return fmt.Errorf("unable to decode signature: %w", err)
+	}
+	s.Signature = sig
+	return nil
+}
+
 // ReferenceUnlockBlock is an unlock block which references a previous unlock block.
 type ReferenceUnlockBlock struct {
 	Reference uint16 `json:"reference"`
@@ -102,6 +133,28 @@ func (r *ReferenceUnlockBlock) Serialize(deSeriMode DeSerializationMode) ([]byte
 	return b[:], nil
 }
 
+// jsonReferenceUnlockBlock defines the JSON form of a ReferenceUnlockBlock.
+type jsonReferenceUnlockBlock struct {
+	Type      int    `json:"type"`
+	Reference uint16 `json:"reference"`
+}
+
+func (r *ReferenceUnlockBlock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonReferenceUnlockBlock{Type: int(UnlockBlockReference), Reference: r.Reference})
+}
+
+func (r *ReferenceUnlockBlock) UnmarshalJSON(data []byte) error {
+	jBlock := &jsonReferenceUnlockBlock{}
+	if err := json.Unmarshal(data, jBlock); err != nil {
+		return err
+	}
+	if err := checkJSONType(jBlock.Type, uint32(UnlockBlockReference)); err != nil {
+		return fmt.Errorf("unable to decode reference unlock block from JSON: %w", err)
+	}
+	r.Reference = jBlock.Reference
+	return nil
+}
+
 // UnlockBlockValidatorFunc which given the index of an unlock block and the unlock block itself, runs validations and returns an error if any should fail.
 type UnlockBlockValidatorFunc func(index int, unlockBlock Serializable) error
 
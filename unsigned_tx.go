@@ -3,6 +3,7 @@ package iota
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 )
@@ -188,7 +189,7 @@ func (u *UnsignedTransaction) Serialize(deSeriMode DeSerializationMode) (data []
 
 	// write payload
 	payloadSer, err := u.Payload.Serialize(deSeriMode)
-	if _, err := buf.Write(payloadSer); err != nil {
+	if err != nil {
 		return nil, err
 	}
 
@@ -202,6 +203,66 @@ func (u *UnsignedTransaction) Serialize(deSeriMode DeSerializationMode) (data []
 	return buf.Bytes(), nil
 }
 
+// jsonUnsignedTransaction defines the JSON form of an UnsignedTransaction.
+type jsonUnsignedTransaction struct {
+	Type    int               `json:"type"`
+	Inputs  []json.RawMessage `json:"inputs"`
+	Outputs []json.RawMessage `json:"outputs"`
+	Payload json.RawMessage   `json:"payload"`
+}
+
+func (u *UnsignedTransaction) MarshalJSON() ([]byte, error) {
+	var err error
+	jTx := &jsonUnsignedTransaction{
+		Type:    int(TransactionUnsigned),
+		Inputs:  make([]json.RawMessage, len(u.Inputs)),
+		Outputs: make([]json.RawMessage, len(u.Outputs)),
+	}
+	for i, input := range u.Inputs {
+		if jTx.Inputs[i], err = json.Marshal(input); err != nil {
+			return nil, err
+		}
+	}
+	for i, output := range u.Outputs {
+		if jTx.Outputs[i], err = json.Marshal(output); err != nil {
+			return nil, err
+		}
+	}
+	if jTx.Payload, err = json.Marshal(u.Payload); err != nil {
+		return nil, err
+	}
+	return json.Marshal(jTx)
+}
+
+func (u *UnsignedTransaction) UnmarshalJSON(data []byte) error {
+	jTx := &jsonUnsignedTransaction{}
+	if err := json.Unmarshal(data, jTx); err != nil {
+		return err
+	}
+	if err := checkJSONType(jTx.Type, TransactionUnsigned); err != nil {
+		return fmt.Errorf("unable to decode unsigned transaction from JSON: %w", err)
+	}
+	inputs, err := DeserializeArrayOfObjectsFromJSON(jTx.Inputs, InputSelector)
+	if err != nil {
+		return fmt.Errorf("unable to decode inputs: %w", err)
+	}
+	outputs, err := DeserializeArrayOfObjectsFromJSON(jTx.Outputs, OutputSelector)
+	if err != nil {
+		return fmt.Errorf("unable to decode outputs: %w", err)
+	}
+	u.Inputs = inputs
+	u.Outputs = outputs
+	u.Payload = nil
+	if !isJSONNull(jTx.Payload) {
+		payload, err := DeserializeObjectFromJSON(jTx.Payload, PayloadSelector)
+		if err != nil {
+			return fmt.Errorf("unable to decode transaction payload: %w", err)
+		}
+		u.Payload = payload
+	}
+	return nil
+}
+
 // SyntacticallyValid checks whether the unsigned transaction is syntactically valid by checking whether:
 //	1. every input references a unique UTXO and has valid UTXO index bounds
 //	2. every output deposits to a unique address and deposits more than zero
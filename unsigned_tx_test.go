@@ -62,3 +62,17 @@ func TestUnsignedTransaction_Serialize(t *testing.T) {
 		})
 	}
 }
+
+func TestUnsignedTransaction_SerializeDeserializeWithPayload(t *testing.T) {
+	unTx, _ := randUnsignedTransaction()
+	unTx.Payload, _ = randIndexationPayload()
+
+	unTxData, err := unTx.Serialize(iota.DeSeriModePerformValidation)
+	assert.NoError(t, err)
+
+	tx := &iota.UnsignedTransaction{}
+	bytesRead, err := tx.Deserialize(unTxData, iota.DeSeriModePerformValidation)
+	assert.NoError(t, err)
+	assert.Equal(t, len(unTxData), bytesRead)
+	assert.EqualValues(t, unTx, tx)
+}
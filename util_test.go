@@ -230,6 +230,8 @@ func randMessage(withPayloadType uint32) (*iota.Message, []byte) {
 	switch withPayloadType {
 	case iota.SignedTransactionPayloadID:
 		payload, payloadData = randSignedTransactionPayload()
+	case iota.MilestonePayloadID:
+		payload, payloadData = randMilestonePayload()
 	case iota.IndexationPayloadID:
 		payload, payloadData = randIndexationPayload()
 	}
@@ -0,0 +1,47 @@
+package whiteflag
+
+import (
+	"github.com/luca-moser/iota"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// The domain separation prefix of leaf hashes.
+	merkleLeafHashPrefix = 0x00
+	// The domain separation prefix of node hashes.
+	merkleNodeHashPrefix = 0x01
+)
+
+// MerkleRoot computes the BLAKE2b-512 Merkle tree root over the given message IDs:
+// leaves are hashed as H(0x00 || id) and nodes as H(0x01 || left || right), where the left subtree
+// holds the largest power of two of IDs which is less than their count. The root of no IDs is H().
+func MerkleRoot(ids []iota.MessageID) [iota.MilestoneInclusionMerkleProofLength]byte {
+	switch len(ids) {
+	case 0:
+		return blake2b.Sum512(nil)
+	case 1:
+		var data [1 + iota.MessageHashLength]byte
+		data[0] = merkleLeafHashPrefix
+		copy(data[1:], ids[0][:])
+		return blake2b.Sum512(data[:])
+	}
+
+	k := largestPowerOfTwo(len(ids))
+	left := MerkleRoot(ids[:k])
+	right := MerkleRoot(ids[k:])
+
+	var data [1 + 2*iota.MilestoneInclusionMerkleProofLength]byte
+	data[0] = merkleNodeHashPrefix
+	copy(data[1:], left[:])
+	copy(data[1+iota.MilestoneInclusionMerkleProofLength:], right[:])
+	return blake2b.Sum512(data[:])
+}
+
+// largestPowerOfTwo returns the largest power of two which is less than n, n must be greater than 1.
+func largestPowerOfTwo(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
@@ -0,0 +1,34 @@
+package whiteflag_test
+
+import (
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/luca-moser/iota/whiteflag"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/blake2b"
+)
+
+func leafHash(id iota.MessageID) [64]byte {
+	return blake2b.Sum512(append([]byte{0x00}, id[:]...))
+}
+
+func nodeHash(left, right [64]byte) [64]byte {
+	data := append([]byte{0x01}, left[:]...)
+	return blake2b.Sum512(append(data, right[:]...))
+}
+
+func TestMerkleRoot(t *testing.T) {
+	a, b, c := iota.MessageID{1}, iota.MessageID{2}, iota.MessageID{3}
+
+	assert.Equal(t, blake2b.Sum512(nil), whiteflag.MerkleRoot(nil))
+	assert.Equal(t, leafHash(a), whiteflag.MerkleRoot([]iota.MessageID{a}))
+	assert.Equal(t, nodeHash(leafHash(a), leafHash(b)), whiteflag.MerkleRoot([]iota.MessageID{a, b}))
+
+	// the left subtree holds the largest power of two less than the count
+	expected := nodeHash(nodeHash(leafHash(a), leafHash(b)), leafHash(c))
+	assert.Equal(t, expected, whiteflag.MerkleRoot([]iota.MessageID{a, b, c}))
+
+	// order matters
+	assert.NotEqual(t, expected, whiteflag.MerkleRoot([]iota.MessageID{c, b, a}))
+}
@@ -0,0 +1,129 @@
+// Package whiteflag implements the white-flag confirmation of milestones: the past cone of a milestone is
+// walked in a deterministic order, its transactions are applied onto the ledger with conflicts being resolved
+// instead of rejected, and the messages are marked as referenced by the milestone.
+package whiteflag
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/luca-moser/iota"
+	"github.com/luca-moser/iota/ledger"
+	"github.com/luca-moser/iota/tangle"
+)
+
+var (
+	ErrNotAMilestone                = errors.New("message doesn't carry a milestone payload")
+	ErrMilestoneIndexMismatch       = errors.New("milestone index doesn't follow the ledger's milestone index")
+	ErrInclusionMerkleProofMismatch = errors.New("computed inclusion merkle root doesn't match the milestone's inclusion merkle proof")
+	ErrMilestoneNotSolid            = errors.New("milestone's past cone is not solid")
+)
+
+// Confirmation describes the effect a milestone has on the tangle and ledger.
+type Confirmation struct {
+	// The index of the milestone.
+	MilestoneIndex uint64
+	// The ID of the milestone message.
+	MilestoneID iota.MessageID
+	// The IDs of the messages the milestone newly references, in white-flag order.
+	// The milestone message itself is the last one.
+	Referenced []iota.MessageID
+	// The results of the transactions within the referenced messages.
+	Transactions []*ledger.TransactionResult
+	// The IDs of the messages whose transactions were applied, over which the inclusion merkle root is computed.
+	Included []iota.MessageID
+	// The inclusion merkle root computed over the included messages.
+	InclusionMerkleRoot [iota.MilestoneInclusionMerkleProofLength]byte
+	// The mutations applied onto the ledger.
+	Diff *ledger.Diff
+}
+
+// ComputeConfirmation computes the Confirmation of the given milestone message without mutating the tangle or ledger.
+// The milestone payload is expected to already be verified, the milestone must follow the ledger's milestone
+// index and its past cone must be solid. Messages already referenced by a previous milestone are not traversed.
+func ComputeConfirmation(tngl *tangle.Tangle, l *ledger.Ledger, milestoneID iota.MessageID) (*Confirmation, error) {
+	msg, has := tngl.Message(milestoneID)
+	if !has {
+		return nil, fmt.Errorf("%w: milestone %x", tangle.ErrMessageNotFound, milestoneID)
+	}
+	ms, isMilestone := msg.Payload.(*iota.MilestonePayload)
+	if !isMilestone {
+		return nil, fmt.Errorf("%w: %x", ErrNotAMilestone, milestoneID)
+	}
+	if ledgerIndex := l.MilestoneIndex(); ms.Index != ledgerIndex+1 {
+		return nil, fmt.Errorf("%w: milestone %d, ledger %d", ErrMilestoneIndexMismatch, ms.Index, ledgerIndex)
+	}
+
+	conf := &Confirmation{MilestoneIndex: ms.Index, MilestoneID: milestoneID}
+
+	var msgs []*iota.Message
+	notReferenced := func(id iota.MessageID, _ *iota.Message) (bool, error) {
+		_, referenced := tngl.ReferencedBy(id)
+		return !referenced, nil
+	}
+	if err := tngl.WalkPastCone(milestoneID, notReferenced, func(id iota.MessageID, msg *iota.Message) error {
+		conf.Referenced = append(conf.Referenced, id)
+		msgs = append(msgs, msg)
+		return nil
+	}, func(id iota.MessageID) error {
+		return fmt.Errorf("%w: message %x is missing", ErrMilestoneNotSolid, id)
+	}, nil); err != nil {
+		return nil, err
+	}
+
+	resolution, err := ledger.ResolveConflicts(l.LookupUTXO, ms.Index, msgs)
+	if err != nil {
+		return nil, err
+	}
+	conf.Transactions = resolution.Transactions
+	conf.Diff = resolution.Diff
+	conf.Included = resolution.AppliedMessageIDs()
+	conf.InclusionMerkleRoot = MerkleRoot(conf.Included)
+
+	if conf.InclusionMerkleRoot != ms.InclusionMerkleProof {
+		return nil, fmt.Errorf("%w: computed %x, milestone has %x", ErrInclusionMerkleProofMismatch, conf.InclusionMerkleRoot, ms.InclusionMerkleProof)
+	}
+
+	return conf, nil
+}
+
+// ConfirmMilestone computes the Confirmation of the given milestone message, applies its mutations onto
+// the ledger and marks the referenced messages with the milestone index and the ledger inclusion states of their transactions.
+// Nothing is mutated if computing the Confirmation or applying its mutations onto the ledger fails.
+func ConfirmMilestone(tngl *tangle.Tangle, l *ledger.Ledger, milestoneID iota.MessageID) (*Confirmation, error) {
+	conf, err := ComputeConfirmation(tngl, l, milestoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the referenced messages are looked up before the ledger is mutated, so that marking them can't fail afterwards
+	metas := make([]*iota.MessageMetadata, len(conf.Referenced))
+	for i, id := range conf.Referenced {
+		meta, has := tngl.Metadata(id)
+		if !has {
+			return nil, fmt.Errorf("%w: %x", tangle.ErrMessageNotFound, id)
+		}
+		metas[i] = meta
+	}
+
+	if err := l.ApplyDiff(conf.Diff); err != nil {
+		return nil, fmt.Errorf("unable to apply milestone %d onto the ledger: %w", conf.MilestoneIndex, err)
+	}
+
+	transactions := make(map[iota.MessageID]*ledger.TransactionResult, len(conf.Transactions))
+	for _, tx := range conf.Transactions {
+		transactions[tx.MessageID] = tx
+	}
+	for i, id := range conf.Referenced {
+		state, conflict := iota.LedgerInclusionNoTransaction, iota.ConflictNone
+		if tx, has := transactions[id]; has {
+			state, conflict = iota.LedgerInclusionIncluded, tx.Conflict
+			if !tx.Applied() {
+				state = iota.LedgerInclusionConflicting
+			}
+		}
+		metas[i].SetReferenced(conf.MilestoneIndex, state, conflict)
+	}
+
+	return conf, nil
+}
@@ -0,0 +1,144 @@
+package whiteflag_test
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/luca-moser/iota"
+	"github.com/luca-moser/iota/ledger"
+	"github.com/luca-moser/iota/tangle"
+	"github.com/luca-moser/iota/whiteflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var genesis = iota.MessageID{}
+
+type owner struct {
+	prvKey ed25519.PrivateKey
+	addr   *iota.Ed25519Address
+}
+
+func newOwner() *owner {
+	seed := make([]byte, ed25519.SeedSize)
+	rand.Read(seed)
+	prvKey := ed25519.NewKeyFromSeed(seed)
+	addr := iota.AddressFromEd25519PubKey(prvKey.Public().(ed25519.PublicKey))
+	return &owner{prvKey: prvKey, addr: &addr}
+}
+
+func (o *owner) send(t *testing.T, to iota.Serializable, output *iota.UnspentOutput) *iota.SignedTransactionPayload {
+	payload, err := iota.NewTransactionBuilder().
+		AddInput(&iota.ToBeSignedUTXOInput{Input: output.Input, Amount: output.Amount, PrivateKey: o.prvKey}).
+		AddOutput(&iota.SigLockedSingleDeposit{Address: to, Amount: output.Amount}).
+		Build()
+	require.NoError(t, err)
+	return payload
+}
+
+type env struct {
+	t      *testing.T
+	tangle *tangle.Tangle
+	ledger *ledger.Ledger
+}
+
+func (e *env) attach(parent1, parent2 iota.MessageID, payload iota.Serializable) iota.MessageID {
+	id, _, err := e.tangle.Attach(&iota.Message{Parent1: parent1, Parent2: parent2, Payload: payload, Nonce: rand.Uint64()})
+	require.NoError(e.t, err)
+	return id
+}
+
+func (e *env) milestone(index uint64, included []iota.MessageID, parent1, parent2 iota.MessageID) iota.MessageID {
+	return e.attach(parent1, parent2, &iota.MilestonePayload{Index: index, InclusionMerkleProof: whiteflag.MerkleRoot(included)})
+}
+
+func TestConfirmMilestone(t *testing.T) {
+	alice, bob, carol := newOwner(), newOwner(), newOwner()
+
+	e := &env{t: t, tangle: tangle.New(genesis), ledger: ledger.New(0)}
+	genesisOutput := &iota.UnspentOutput{Input: &iota.UTXOInput{TransactionID: [32]byte{1}}, Address: alice.addr, Amount: iota.TokenSupply}
+	require.NoError(t, e.ledger.Add(genesisOutput))
+
+	toBobTx := alice.send(t, bob.addr, genesisOutput)
+	toBob := e.attach(genesis, genesis, toBobTx)
+	doubleSpend := e.attach(genesis, genesis, alice.send(t, carol.addr, genesisOutput))
+	data := e.attach(toBob, genesis, &iota.IndexationPayload{Index: "data", Data: []byte{1, 2, 3}})
+
+	// white-flag order: toBob, data (Parent1 cone), doubleSpend (Parent2 cone), milestone
+	ms1 := e.milestone(1, []iota.MessageID{toBob}, data, doubleSpend)
+
+	conf, err := whiteflag.ConfirmMilestone(e.tangle, e.ledger, ms1)
+	require.NoError(t, err)
+	assert.Equal(t, []iota.MessageID{toBob, data, doubleSpend, ms1}, conf.Referenced)
+	assert.Equal(t, []iota.MessageID{toBob}, conf.Included)
+	require.Len(t, conf.Transactions, 2)
+	assert.Equal(t, iota.ConflictInputUTXOAlreadySpent, conf.Transactions[1].Conflict)
+
+	assert.EqualValues(t, 1, e.ledger.MilestoneIndex())
+	require.NoError(t, e.ledger.CheckTotal())
+	balance, err := e.ledger.Balance(bob.addr)
+	require.NoError(t, err)
+	assert.EqualValues(t, iota.TokenSupply, balance)
+
+	for _, id := range conf.Referenced {
+		index, referenced := e.tangle.ReferencedBy(id)
+		assert.True(t, referenced)
+		assert.EqualValues(t, 1, index)
+	}
+	for id, state := range map[iota.MessageID]iota.LedgerInclusionState{
+		toBob:       iota.LedgerInclusionIncluded,
+		doubleSpend: iota.LedgerInclusionConflicting,
+		data:        iota.LedgerInclusionNoTransaction,
+	} {
+		meta, has := e.tangle.Metadata(id)
+		require.True(t, has)
+		assert.Equal(t, state, meta.LedgerInclusionState())
+	}
+	meta, _ := e.tangle.Metadata(doubleSpend)
+	assert.Equal(t, iota.ConflictInputUTXOAlreadySpent, meta.ConflictReason())
+
+	// the next milestone only references the new messages
+	outputs, err := ledger.OutputsOfTransaction(toBobTx)
+	require.NoError(t, err)
+	toCarol := e.attach(ms1, toBob, bob.send(t, carol.addr, outputs[0]))
+	ms2 := e.milestone(2, []iota.MessageID{toCarol}, toCarol, ms1)
+
+	conf, err = whiteflag.ConfirmMilestone(e.tangle, e.ledger, ms2)
+	require.NoError(t, err)
+	assert.Equal(t, []iota.MessageID{toCarol, ms2}, conf.Referenced)
+	balance, err = e.ledger.Balance(carol.addr)
+	require.NoError(t, err)
+	assert.EqualValues(t, iota.TokenSupply, balance)
+}
+
+func TestComputeConfirmationErrors(t *testing.T) {
+	alice := newOwner()
+
+	e := &env{t: t, tangle: tangle.New(genesis), ledger: ledger.New(0)}
+	genesisOutput := &iota.UnspentOutput{Input: &iota.UTXOInput{TransactionID: [32]byte{1}}, Address: alice.addr, Amount: iota.TokenSupply}
+	require.NoError(t, e.ledger.Add(genesisOutput))
+	tx := e.attach(genesis, genesis, alice.send(t, newOwner().addr, genesisOutput))
+
+	_, err := whiteflag.ComputeConfirmation(e.tangle, e.ledger, tx)
+	assert.True(t, errors.Is(err, whiteflag.ErrNotAMilestone))
+
+	_, err = whiteflag.ComputeConfirmation(e.tangle, e.ledger, e.milestone(2, []iota.MessageID{tx}, tx, genesis))
+	assert.True(t, errors.Is(err, whiteflag.ErrMilestoneIndexMismatch))
+
+	_, err = whiteflag.ComputeConfirmation(e.tangle, e.ledger, e.milestone(1, nil, tx, genesis))
+	assert.True(t, errors.Is(err, whiteflag.ErrInclusionMerkleProofMismatch))
+
+	_, err = whiteflag.ComputeConfirmation(e.tangle, e.ledger, e.milestone(1, []iota.MessageID{tx}, tx, iota.MessageID{42}))
+	assert.True(t, errors.Is(err, whiteflag.ErrMilestoneNotSolid))
+
+	// nothing was mutated
+	assert.EqualValues(t, 0, e.ledger.MilestoneIndex())
+	_, referenced := e.tangle.ReferencedBy(tx)
+	assert.False(t, referenced)
+
+	conf, err := whiteflag.ConfirmMilestone(e.tangle, e.ledger, e.milestone(1, []iota.MessageID{tx}, tx, genesis))
+	require.NoError(t, err)
+	assert.Equal(t, []iota.MessageID{tx}, conf.Included)
+}